@@ -0,0 +1,44 @@
+package contentcmds
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Selects the debug step of a watch history line ("#<arrivalIndex> ...") in the godebug watches row.
+func GoDebugWatchLine(ctx context.Context, erow *core.ERow, index int) (error, bool) {
+	if erow.Info.Name() != core.GoDebugWatchesERowName {
+		return nil, false
+	}
+	rw := erow.Row.TextArea.RW()
+	a, b, _, err := iorw.LinesIndexes(rw, index, index)
+	if err != nil {
+		return err, false
+	}
+	line, err := rw.ReadFastAt(a, b-a)
+	if err != nil {
+		return err, false
+	}
+	arrivalIndex, err := goDebugWatchLineArrivalIndex(string(line))
+	if err != nil {
+		return err, false
+	}
+	return erow.Ed.GoDebug.SelectArrivalIndex(arrivalIndex), true
+}
+
+func goDebugWatchLineArrivalIndex(line string) (int, error) {
+	s := strings.TrimSpace(line)
+	if !strings.HasPrefix(s, "#") {
+		return 0, fmt.Errorf("not a watch history line")
+	}
+	s = s[1:]
+	if i := strings.Index(s, " "); i >= 0 {
+		s = s[:i]
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,13 @@
+package contentcmds
+
+import "testing"
+
+func TestGoDebugWatchLineArrivalIndex(t *testing.T) {
+	v, err := goDebugWatchLineArrivalIndex("\t#12 goroutine 1: /a.go:3:2: 1\n")
+	if err != nil || v != 12 {
+		t.Fatal(v, err)
+	}
+	if _, err := goDebugWatchLineArrivalIndex("x: 2 entries\n"); err == nil {
+		t.Fatal("expecting error")
+	}
+}
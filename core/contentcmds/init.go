@@ -7,6 +7,9 @@ import (
 func init() {
 	// order matters
 
+	// only handles the godebug watches row
+	core.ContentCmds.Append("godebugwatchline", GoDebugWatchLine)
+
 	core.ContentCmds.Append("gotodefinition_lsproto", GoToDefinitionLSProto)
 
 	// "gopls query" might work where lsproto might fail (no views in session)
@@ -32,7 +32,11 @@ type Editor struct {
 	GoDebug           *GoDebugManager
 	LSProtoMan        *lsproto.Manager
 	InlineComplete    *InlineComplete
+	SignatureHelp     *SignatureHelp
+	LSProtoPreview    *LSProtoEditPreview
+	ReplacePreview    *ReplaceInFilesPreview
 	Plugins           *Plugins
+	KeyMap            *KeyMap
 	EEvents           *EEvents // editor events (used by plugins)
 	FsCaseInsensitive bool     // filesystem
 
@@ -55,7 +59,11 @@ func NewEditor(opt *Options) (*Editor, error) {
 	ed.dndh = NewDndHandler(ed)
 	ed.GoDebug = NewGoDebugManager(ed)
 	ed.InlineComplete = NewInlineComplete(ed)
+	ed.SignatureHelp = NewSignatureHelp(ed)
+	ed.LSProtoPreview = &LSProtoEditPreview{ed: ed}
+	ed.ReplacePreview = NewReplaceInFilesPreview(ed)
 	ed.EEvents = NewEEvents()
+	ed.KeyMap = NewKeyMap(ed)
 
 	if err := ed.init(opt); err != nil {
 		return nil, err
@@ -94,6 +102,13 @@ func (ed *Editor) init(opt *Options) error {
 	// TODO: ensure it has the window measure
 	ed.EnsureOneColumn()
 
+	// keymap (before plugins to allow them to override bindings)
+	if opt.KeyMap != "" {
+		if err := ed.KeyMap.LoadFile(opt.KeyMap); err != nil {
+			ed.Error(err)
+		}
+	}
+
 	// setup plugins
 	setupInitialRows := true
 	err = ed.setupPlugins(opt)
@@ -115,6 +130,9 @@ func (ed *Editor) init(opt *Options) error {
 func (ed *Editor) initLSProto(opt *Options) {
 	// language server protocol manager
 	ed.LSProtoMan = lsproto.NewManager(ed.Message)
+	ed.LSProtoMan.OnDiagnostics = ed.onLSProtoDiagnostics
+	ed.LSProtoMan.OnApplyWorkspaceEdit = ed.onLSProtoApplyWorkspaceEdit
+	ed.LSProtoMan.FormattingOptions.TabSize = opt.TabWidth
 	for _, reg := range opt.LSProtos.regs {
 		ed.LSProtoMan.Register(reg)
 	}
@@ -164,6 +182,9 @@ func (ed *Editor) uiEventLoop() {
 			//		log.Printf("uievloop: unhandled event: %#v", ev)
 			//	}
 			//}
+			if ed.KeyMap.handleEvent(ev) {
+				break
+			}
 			h1 := ed.handleGlobalShortcuts(ev)
 			h2 := ed.UI.HandleEvent(ev)
 			if !h1 && !h2 {
@@ -325,6 +346,13 @@ func (ed *Editor) setupUIRoot() {
 		ev2 := ev.(*ui.RootSelectAnnotationEvent)
 		ed.GoDebug.SelectAnnotation(rowPos, ev2)
 	})
+
+	// contextfloatbox cmd event (godebug inspector expand/collapse)
+	cfbta := ed.UI.Root.ContextFloatBox.TextArea
+	cfbta.EvReg.Add(ui.TextAreaCmdEventId, func(ev interface{}) {
+		ev2 := ev.(*ui.TextAreaCmdEvent)
+		ed.GoDebug.InspectorToggle(ev2.Index)
+	})
 }
 
 func (ed *Editor) setupRootToolbar() {
@@ -366,6 +394,9 @@ NewRow | ReopenRow | MaximizeRow
 ListDir | ListDir -hidden | ListDir -sub
 ListSessions | OpenSession | DeleteSession
 LsprotoRename | LsprotoCloseAll
+LsprotoDiagnostics | LsprotoReferences
+LsprotoSymbols | LsprotoWorkspaceSymbols
+LsprotoFormat | LsprotoCodeAction
 OpenFilemanager
 Reload | ReloadAll | ReloadAllFiles 
 RuneCodes
@@ -453,6 +484,16 @@ func (ed *Editor) setupTheme(opt *Options) {
 		ui.TextAreaStringsColor = imageutil.RgbaFromInt(opt.StringsColor)
 	}
 
+	// color keywords
+	if opt.KeywordsColor != 0 {
+		ui.TextAreaKeywordsColor = imageutil.RgbaFromInt(opt.KeywordsColor)
+	}
+
+	// color numbers
+	if opt.NumbersColor != 0 {
+		ui.TextAreaNumbersColor = imageutil.RgbaFromInt(opt.NumbersColor)
+	}
+
 	// font options
 	fontutil.DPI = opt.DPI
 	ui.TTFontOptions.DPI = opt.DPI
@@ -534,12 +575,8 @@ func (ed *Editor) handleGlobalShortcuts(ev interface{}) (handled bool) {
 			case m.Is(event.ModNone):
 				switch t2.KeySym {
 				case event.KSymEscape:
-					ed.GoDebug.CancelAndClear()
-					ed.InlineComplete.CancelAndClear()
-					ed.cancelERowsContentCmds()
-					ed.cancelERowsInternalCmds()
 					autoCloseInfo = false
-					ed.cancelInfoFloatBox()
+					ed.cancelAll()
 					return true
 				case event.KSymF1:
 					autoCloseInfo = false
@@ -561,6 +598,15 @@ func (ed *Editor) handleGlobalShortcuts(ev interface{}) (handled bool) {
 
 //----------
 
+func (ed *Editor) cancelAll() {
+	ed.GoDebug.CancelAndClear()
+	ed.InlineComplete.CancelAndClear()
+	ed.SignatureHelp.CancelAndClear()
+	ed.cancelERowsContentCmds()
+	ed.cancelERowsInternalCmds()
+	ed.cancelInfoFloatBox()
+}
+
 func (ed *Editor) cancelERowsContentCmds() {
 	for _, erow := range ed.ERows() {
 		erow.CancelContentCmd()
@@ -665,8 +711,14 @@ func (ed *Editor) toggleInfoFloatBox() {
 		// ui feedback while loading
 		v := fmt.Sprintf("Loading lsproto(%v)...", lang.Reg.Language)
 		showAsync(v)
-		// lsproto autocomplete
-		s, err := ed.lsprotoManAutoComplete(ctx, ta, erow)
+		// lsproto hover (type signature and documentation)
+		s, err := ed.lsprotoManHover(ctx, ta, erow)
+		if err == nil && s != "" {
+			showAsync(s)
+			return
+		}
+		// lsproto autocomplete (hover failed, not supported by the server, or no hover info at the cursor)
+		s, err = ed.lsprotoManAutoComplete(ctx, ta, erow)
 		if err != nil {
 			ed.Error(err)
 			showAsync("")
@@ -676,6 +728,14 @@ func (ed *Editor) toggleInfoFloatBox() {
 	})
 }
 
+func (ed *Editor) lsprotoManHover(ctx context.Context, ta *ui.TextArea, erow *ERow) (string, error) {
+	s, err := ed.LSProtoMan.TextDocumentHover(ctx, erow.Info.Name(), ta.RW(), ta.CursorIndex())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(s), nil
+}
+
 func (ed *Editor) lsprotoManAutoComplete(ctx context.Context, ta *ui.TextArea, erow *ERow) (string, error) {
 	//ta := erow.Row.TextArea
 	comps, err := ed.LSProtoMan.TextDocumentCompletionDetailStrings(ctx, erow.Info.Name(), ta.RW(), ta.CursorIndex())
@@ -736,12 +796,20 @@ func (ed *Editor) SetAnnotations(req EdAnnotationsRequester, ta *ui.TextArea, on
 		ta.MarkNeedsLayoutAndPaint()
 	}
 
-	// restore godebug annotations
-	if req == EdAnnReqInlineComplete && !on {
+	if !on {
 		// find erow info from textarea
 		for _, erow := range ed.ERows() {
 			if erow.Row.TextArea == ta {
-				ed.GoDebug.UpdateUIERowInfo(erow.Info)
+				switch req {
+				case EdAnnReqInlineComplete, EdAnnReqSignatureHelp:
+					// restore godebug annotations
+					ed.GoDebug.UpdateUIERowInfo(erow.Info)
+					// restore lsproto diagnostics
+					ed.UpdateLSProtoDiagnosticsAnnotations(erow.Info)
+				case EdAnnReqGoDebug:
+					// restore lsproto diagnostics
+					ed.UpdateLSProtoDiagnosticsAnnotations(erow.Info)
+				}
 			}
 		}
 	}
@@ -752,13 +820,32 @@ func (ed *Editor) CanModifyAnnotations(req EdAnnotationsRequester, ta *ui.TextAr
 	case EdAnnReqGoDebug:
 		if option == "starting_session" {
 			ed.InlineComplete.CancelAndClear()
+			ed.SignatureHelp.CancelAndClear()
 			return true
 		}
-		if ed.InlineComplete.IsOn(ta) {
+		if ed.InlineComplete.IsOn(ta) || ed.SignatureHelp.IsOn(ta) {
 			return false
 		}
 		return true
 	case EdAnnReqInlineComplete:
+		// replaces the signature help annotations
+		ed.SignatureHelp.setOff(ta)
+		return true
+	case EdAnnReqSignatureHelp:
+		if ed.InlineComplete.IsOn(ta) {
+			return false
+		}
+		return true
+	case EdAnnReqLSProtoDiagnostics:
+		if ed.InlineComplete.IsOn(ta) || ed.SignatureHelp.IsOn(ta) {
+			return false
+		}
+		// godebug session annotations have precedence
+		for _, erow := range ed.ERows() {
+			if erow.Row.TextArea == ta && erow.Row.HasState(ui.RowStateAnnotations) {
+				return false
+			}
+		}
 		return true
 	default:
 		panic(req)
@@ -770,6 +857,8 @@ type EdAnnotationsRequester int
 const (
 	EdAnnReqGoDebug EdAnnotationsRequester = iota
 	EdAnnReqInlineComplete
+	EdAnnReqLSProtoDiagnostics
+	EdAnnReqSignatureHelp
 )
 
 //----------
@@ -812,6 +901,8 @@ type Options struct {
 	ColorTheme     string
 	CommentsColor  int
 	StringsColor   int
+	KeywordsColor  int
+	NumbersColor   int
 	ScrollBarWidth int
 	ScrollBarLeft  bool
 	Shadows        bool
@@ -822,6 +913,7 @@ type Options struct {
 	UseMultiKey bool
 
 	Plugins string
+	KeyMap  string
 
 	LSProtos RegistrationsOpt
 }
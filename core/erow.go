@@ -12,6 +12,7 @@ import (
 
 	"github.com/jmigpin/editor/core/toolbarparser"
 	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/drawutil"
 	"github.com/jmigpin/editor/util/iout"
 	"github.com/jmigpin/editor/util/iout/iorw"
 	"github.com/jmigpin/editor/util/uiutil/event"
@@ -151,6 +152,18 @@ func newLoadedDirERow(info *ERowInfo, rowPos *ui.RowPos) (*ERow, error) {
 	return erow, nil
 }
 
+// Files with at least this size use a piece table to hold the content.
+const largeFileSize = 8 * 1024 * 1024
+
+// Empty ReadWriterAt to hold the content of a file with the given size.
+func newFileReadWriterAt(size int) iorw.ReadWriterAt {
+	if size >= largeFileSize {
+		// avoid shifting the whole content on each edit
+		return iorw.NewPieceTableReadWriterAt(nil)
+	}
+	return iorw.NewBytesReadWriterAt(nil)
+}
+
 func newLoadedFileERow(info *ERowInfo, rowPos *ui.RowPos) (*ERow, error) {
 	// read content from existing row
 	if erow0, ok := info.FirstERow(); ok {
@@ -161,6 +174,17 @@ func newLoadedFileERow(info *ERowInfo, rowPos *ui.RowPos) (*ERow, error) {
 		return erow, nil
 	}
 
+	// huge file: read-only, content read in chunks when needed
+	if info.isHugeFile() {
+		rw, err := info.openReadOnlyFile()
+		if err != nil {
+			return nil, err
+		}
+		erow := NewBasicERow(info, rowPos)
+		erow.Row.TextArea.SetRW(rw)
+		return erow, nil
+	}
+
 	// load
 	b, err := info.readFsFile()
 	if err != nil {
@@ -172,6 +196,7 @@ func newLoadedFileERow(info *ERowInfo, rowPos *ui.RowPos) (*ERow, error) {
 
 	// new erow (no other rows exist)
 	erow := NewBasicERow(info, rowPos)
+	erow.Row.TextArea.SetRW(newFileReadWriterAt(len(b)))
 	erow.Row.TextArea.SetBytesClearHistory(b)
 
 	return erow, nil
@@ -214,6 +239,7 @@ func (erow *ERow) initHandlers() {
 	erow.Info.UpdateDuplicateHighlightRowState()
 	erow.Info.UpdateExistsRowState()
 	erow.Info.UpdateFsDifferRowState()
+	erow.Info.UpdateReadOnlyRowState()
 
 	// register with watcher
 	if !erow.Info.IsSpecial() && len(erow.Info.ERows) == 1 {
@@ -231,10 +257,16 @@ func (erow *ERow) initHandlers() {
 	row.Toolbar.EvReg.Add(ui.TextAreaCmdEventId, func(ev0 interface{}) {
 		InternalCmdFromRowTb(erow)
 	})
+	// textarea on prewrite
+	row.TextArea.RWEvReg.Add(iorw.RWEvIdPreWrite, func(ev0 interface{}) {
+		ev := ev0.(*iorw.RWEvPreWrite)
+		erow.Info.HandleRWEvPreWrite(erow, ev)
+	})
 	// textarea on write
 	row.TextArea.RWEvReg.Add(iorw.RWEvIdWrite2, func(ev0 interface{}) {
 		ev := ev0.(*iorw.RWEvWrite2)
 		erow.Info.HandleRWEvWrite2(erow, ev)
+		erow.Ed.SignatureHelp.OnWrite(erow, ev)
 	})
 	// textarea content cmds
 	row.TextArea.EvReg.Add(ui.TextAreaCmdEventId, func(ev0 interface{}) {
@@ -256,6 +288,7 @@ func (erow *ERow) initHandlers() {
 	// key shortcuts
 	row.EvReg.Add(ui.RowInputEventId, func(ev0 interface{}) {
 		erow.Ed.InlineComplete.CancelOnCursorChange()
+		erow.Ed.SignatureHelp.CancelOnCursorChange()
 
 		ev := ev0.(*ui.RowInputEvent)
 		switch evt := ev.Event.(type) {
@@ -298,6 +331,8 @@ func (erow *ERow) initHandlers() {
 		erow.Info.RemoveERow(erow)
 		if len(erow.Info.ERows) == 0 {
 			erow.Ed.DeleteERowInfo(erow.Info.Name())
+			erow.Ed.lsprotoDidClose(erow.Info)
+			erow.Info.closeReadOnlyFile()
 		}
 
 		// update row state
@@ -429,6 +464,8 @@ func (erow *ERow) parseToolbarVars() {
 					erow.terminalOpt.filter = true
 				case "k":
 					erow.terminalOpt.keyEvents = true
+				case "p":
+					erow.terminalOpt.pty = true
 				}
 			}
 		}
@@ -562,6 +599,10 @@ func (erow *ERow) setupTextAreaSyntaxHighlight() {
 	setComments := func(a ...interface{}) {
 		ta.SetCommentStrings(a...)
 	}
+	setRules := func(r *drawutil.SyntaxHighlightRules) {
+		ta.SetSyntaxRules(r)
+	}
+	setRules(nil) // default rules
 
 	// ignore "." on files starting with "."
 	name := filepath.Base(erow.Info.Name())
@@ -573,6 +614,7 @@ func (erow *ERow) setupTextAreaSyntaxHighlight() {
 	switch name {
 	case "bashrc":
 		setComments("#")
+		setRules(shellSyntaxRules)
 		return
 	case "go.mod":
 		setComments("//")
@@ -582,17 +624,29 @@ func (erow *ERow) setupTextAreaSyntaxHighlight() {
 	// setup comments based on name extension
 	ext := strings.ToLower(filepath.Ext(name))
 	switch ext {
-	case ".sh",
-		".conf", ".list",
-		".py", // python
+	case ".sh":
+		setComments("#")
+		setRules(shellSyntaxRules)
+	case ".py": // python
+		setComments("#")
+		setRules(pythonSyntaxRules)
+	case ".conf", ".list",
 		".pl": // perl
 		setComments("#")
-	case ".go",
-		".c", ".h",
-		".cpp", ".hpp", ".cxx", ".hxx", // c++
-		".java",
-		".v",  // verilog
-		".js": // javascript
+	case ".go":
+		setComments("//", [2]string{"/*", "*/"})
+		setRules(goSyntaxRules)
+	case ".c", ".h",
+		".cpp", ".hpp", ".cxx", ".hxx": // c++
+		setComments("//", [2]string{"/*", "*/"})
+		setRules(cSyntaxRules)
+	case ".java":
+		setComments("//", [2]string{"/*", "*/"})
+		setRules(javaSyntaxRules)
+	case ".js": // javascript
+		setComments("//", [2]string{"/*", "*/"})
+		setRules(jsSyntaxRules)
+	case ".v": // verilog
 		setComments("//", [2]string{"/*", "*/"})
 	case ".ledger":
 		setComments(";", "//")
@@ -662,8 +716,9 @@ func (erow *ERow) cancelInternalCmd2() {
 type terminalOpt struct {
 	filter    bool
 	keyEvents bool
+	pty       bool // run under a pseudo-terminal, screen emulation
 }
 
 func (t *terminalOpt) On() bool {
-	return t.filter || t.keyEvents
+	return t.filter || t.keyEvents || t.pty
 }
@@ -56,6 +56,7 @@ func (ee *ERowExec) runAsync2(fn func(context.Context, io.ReadWriter) error) {
 		ee.erow.Ed.UI.RunOnUIGoRoutine(func() {
 			ee.erow.Row.SetState(ui.RowStateExecuting, true)
 			ee.erow.Row.TextArea.SetStrClearHistory("")
+			ee.erow.Row.TextArea.SetTerminalColorizeOps(nil)
 			ee.erow.Row.TextArea.ClearPos()
 		})
 
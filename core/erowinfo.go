@@ -47,6 +47,11 @@ type ERowInfo struct {
 			size    int
 			hash    []byte
 		}
+		// huge files are read in chunks (not loaded into memory), and can't be edited
+		readOnly struct {
+			on bool
+			f  *os.File
+		}
 	}
 }
 
@@ -151,6 +156,11 @@ func (info *ERowInfo) updateEditedHash() {
 	if info.fileData.edited.updated {
 		return
 	}
+	// content can't be changed and is not in memory
+	if info.IsReadOnly() {
+		info.setEditedHash(info.fileData.saved.hash, info.fileData.saved.size)
+		return
+	}
 	// read from one of the erows
 	erow0, ok := info.FirstERow()
 	if !ok {
@@ -195,6 +205,10 @@ func (info *ERowInfo) updateFsHashIfNeeded() {
 		return
 	}
 	if !info.fi.ModTime().Equal(info.fileData.fs.modTime) {
+		if info.IsReadOnly() {
+			info.setFsHash(readOnlyFileHash(info.fi))
+			return
+		}
 		info.readFsFile()
 	}
 }
@@ -254,7 +268,22 @@ func (info *ERowInfo) FirstERow() (*ERow, bool) {
 //----------
 
 func (info *ERowInfo) ReloadFile() error {
+	info.readFileInfo()
+	if info.isHugeFile() {
+		return info.reloadReadOnlyFile()
+	}
+	wasReadOnly := info.IsReadOnly()
+	if wasReadOnly {
+		info.closeReadOnlyFile()
+	}
+
 	b, err := info.readFsFile()
+	if wasReadOnly {
+		// not huge anymore, load into memory (same rw as when opening the file)
+		for _, erow := range info.ERows {
+			erow.Row.TextArea.SetRW(newFileReadWriterAt(len(b)))
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -275,6 +304,12 @@ func (info *ERowInfo) SaveFile() error {
 	if !info.IsFileButNotDir() {
 		return fmt.Errorf("not a file: %s", info.Name())
 	}
+	if info.IsReadOnly() {
+		return fmt.Errorf("read-only file: %s", info.Name())
+	}
+
+	// lsproto format (if enabled in the registration), updates content
+	info.Ed.lsprotoFormatOnSave(info)
 
 	// read from one of the erows
 	erow0, ok := info.FirstERow()
@@ -308,16 +343,8 @@ func (info *ERowInfo) SaveFile() error {
 	ev := &PostFileSaveEEvent{Info: info}
 	info.Ed.EEvents.emit(PostFileSaveEEventId, ev)
 
-	//// warn lsproto of file save
-	//go func() {
-	//	ctx0 := context.Background()
-	//	ctx, cancel := context.WithTimeout(ctx0, 2000*time.Millisecond)
-	//	defer cancel()
-	//	err := info.Ed.LSProtoMan.DidSave(ctx, info.Name(), nil)
-	//	if err != nil {
-	//		info.Ed.Error(err)
-	//	}
-	//}()
+	// warn lsproto of file save (updates diagnostics)
+	info.Ed.lsprotoDidSave(info)
 
 	return nil
 }
@@ -338,6 +365,76 @@ func (info *ERowInfo) readFsFile() ([]byte, error) {
 	return b, err
 }
 
+//----------
+
+// Files with at least this size are opened read-only, without loading the content into memory.
+const hugeFileSize = 256 * 1024 * 1024
+
+func (info *ERowInfo) isHugeFile() bool {
+	return info.IsFileButNotDir() && info.fi.Size() >= hugeFileSize
+}
+
+func (info *ERowInfo) IsReadOnly() bool {
+	return info.fileData.readOnly.on
+}
+
+// Opens the file to be read in chunks by the rows.
+func (info *ERowInfo) openReadOnlyFile() (iorw.ReadWriterAt, error) {
+	f, err := os.Open(info.Name())
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	info.closeReadOnlyFile()
+	info.fileData.readOnly.on = true
+	info.fileData.readOnly.f = f
+
+	// update data (no content hash, the file is not read)
+	info.readFileInfo() // get new modtime
+	h := readOnlyFileHash(fi)
+	info.setFsHash(h)
+	info.setSavedHash(h, int(fi.Size()))
+	info.editedHashNeedsUpdate()
+
+	rd := iorw.NewChunkedReaderAt(f, int(fi.Size()))
+	return iorw.NewReadOnlyReadWriterAt(rd), nil
+}
+
+func (info *ERowInfo) reloadReadOnlyFile() error {
+	rw, err := info.openReadOnlyFile()
+	if err != nil {
+		return err
+	}
+	for _, erow := range info.ERows {
+		erow.Row.TextArea.SetRW(rw)
+		erow.Row.TextArea.MarkNeedsLayoutAndPaint()
+	}
+	info.UpdateReadOnlyRowState()
+	info.UpdateEditedRowState()
+	return nil
+}
+
+func (info *ERowInfo) closeReadOnlyFile() {
+	if info.fileData.readOnly.f != nil {
+		info.fileData.readOnly.f.Close()
+	}
+	info.fileData.readOnly.on = false
+	info.fileData.readOnly.f = nil
+	info.UpdateReadOnlyRowState()
+}
+
+// Hash from the file size and modtime, since the content is not read.
+func readOnlyFileHash(fi os.FileInfo) []byte {
+	s := fmt.Sprintf("%v,%v", fi.Size(), fi.ModTime().UnixNano())
+	return bytesHash([]byte(s))
+}
+
+//----------
+
 func (info *ERowInfo) saveFsFile(b []byte) error {
 	flags := os.O_WRONLY | os.O_TRUNC | os.O_CREATE
 	f, err := os.OpenFile(info.Name(), flags, 0644)
@@ -405,6 +502,10 @@ func (info *ERowInfo) UpdateEditedRowState() {
 	info.updateRowsStates(ui.RowStateEdited, edited)
 }
 
+func (info *ERowInfo) UpdateReadOnlyRowState() {
+	info.updateRowsStates(ui.RowStateReadOnly, info.IsReadOnly())
+}
+
 func (info *ERowInfo) UpdateExistsRowState() {
 	info.updateRowsStates(ui.RowStateNotExist, info.IsNotExist())
 }
@@ -444,6 +545,10 @@ func (info *ERowInfo) UpdateAnnotationsEditedRowState(v bool) {
 	info.updateRowsStates(ui.RowStateAnnotationsEdited, v)
 }
 
+func (info *ERowInfo) UpdatePausedRowState(v bool) {
+	info.updateRowsStates(ui.RowStatePaused, v)
+}
+
 func (info *ERowInfo) UpdateActiveRowState(erow *ERow) {
 	// disable first the previous active row
 	for _, er := range info.Ed.ERows() {
@@ -489,6 +594,19 @@ func (info *ERowInfo) SetRowsBytes(b []byte) {
 
 //----------
 
+func (info *ERowInfo) HandleRWEvPreWrite(erow *ERow, ev *iorw.RWEvPreWrite) {
+	if info.IsReadOnly() {
+		ev.ReplyErr = fmt.Errorf("read-only file (huge file not loaded into memory): %v", info.Name())
+		return
+	}
+	if !info.IsFileButNotDir() {
+		return
+	}
+	// keep the lsproto server document in sync (content previous to the write)
+	rd := erow.Row.TextArea.RW()
+	info.Ed.LSProtoMan.TextDocumentChange(info.Name(), rd, ev.Index, ev.N, ev.P)
+}
+
 func (info *ERowInfo) HandleRWEvWrite2(erow *ERow, ev *iorw.RWEvWrite2) {
 	if !info.IsFileButNotDir() {
 		return
@@ -506,6 +624,7 @@ func (info *ERowInfo) setRWFromMaster(erow *ERow) {
 	}
 	info.UpdateEditedRowState()
 	info.Ed.GoDebug.UpdateUIERowInfo(info)
+	info.Ed.UpdateLSProtoDiagnosticsAnnotations(info)
 }
 
 func (info *ERowInfo) handleRWsWrite2(erow *ERow, ev *iorw.RWEvWrite2) {
@@ -542,3 +661,39 @@ func runGoImports(s []byte, dir string) ([]byte, error) {
 	r := bytes.NewReader(s)
 	return ExecCmdStdin(ctx, dir, r, osutil.ExecName("goimports"))
 }
+
+//----------
+
+// Uses the erow content if the file is open, otherwise reads the file.
+func erowOrFileReaderAt(ed *Editor, filename string) (iorw.ReaderAt, error) {
+	if info, ok := ed.ERowInfo(filename); ok {
+		if erow0, ok := info.FirstERow(); ok {
+			return erow0.Row.TextArea.RW(), nil
+		}
+	}
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return iorw.NewBytesReadWriterAt(b), nil
+}
+
+// UI safe. Same as erowOrFileReaderAt, but copies the erow content in the UI goroutine. Read-only (huge) files are read from disk.
+func erowOrFileReaderAtCopy(ed *Editor, filename string) (iorw.ReaderAt, error) {
+	var b []byte
+	ed.UI.WaitRunOnUIGoRoutine(func() {
+		if info, ok := ed.ERowInfo(filename); ok && !info.IsReadOnly() {
+			if erow0, ok := info.FirstERow(); ok {
+				b, _ = erow0.Row.TextArea.Bytes()
+			}
+		}
+	})
+	if b == nil {
+		b2, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		b = b2
+	}
+	return iorw.NewBytesReadWriterAt(b), nil
+}
@@ -138,14 +138,32 @@ func externalCmdDir2(ctx context.Context, erow *ERow, cargs []string, env []stri
 	cmd.Dir = erow.Info.Name()
 	cmd.Env = env
 
-	if err := cmd.SetupStdio(rw, rw, rw); err != nil {
-		return err
+	if erow.terminalOpt.pty {
+		cmd.Env = append(cmd.Env, "TERM=xterm") // last value is used
+		ptm, err := cmd.SetupPty(rw)
+		if err != nil {
+			return err
+		}
+		if tf, ok := rw.(*TerminalFilter); ok && tf.screen != nil {
+			if err := tf.SetPty(ptm); err != nil {
+				cmd.Cancel()
+				return err
+			}
+		}
+	} else {
+		if err := cmd.SetupStdio(rw, rw, rw); err != nil {
+			return err
+		}
 	}
 
 	// output pid before any output
 	cmd.PreOutputCallback = func() {
 		cargsStr := strings.Join(cargs, " ")
-		fmt.Fprintf(rw, "# pid %d: %s\n", cmd.Process.Pid, cargsStr)
+		nl := "\n"
+		if erow.terminalOpt.pty {
+			nl = "\r\n" // screen emulation: newline doesn't return the cursor
+		}
+		fmt.Fprintf(rw, "# pid %d: %s%s", cmd.Process.Pid, cargsStr, nl)
 	}
 
 	if err := cmd.Start(); err != nil {
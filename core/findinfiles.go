@@ -0,0 +1,427 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/osutil"
+)
+
+// Searches the files of a directory (skips ".gitignore" matches and binary files). Open files are searched in their rows content.
+type FilesSearch struct {
+	Dir      string
+	Searcher *iorw.Searcher
+
+	ed   *Editor
+	srcs map[string][]byte // [erowinfo key] open files content
+}
+
+// Should be called under UI goroutine (copies the open files content).
+func NewFilesSearch(ed *Editor, dir string, s *iorw.Searcher) *FilesSearch {
+	fs := &FilesSearch{Dir: dir, Searcher: s, ed: ed}
+	fs.srcs = map[string][]byte{}
+	for _, info := range ed.ERowInfos() {
+		if !info.IsFileButNotDir() || info.IsReadOnly() {
+			continue // read-only (huge) files are read from disk
+		}
+		if erow0, ok := info.FirstERow(); ok {
+			if b, err := erow0.Row.TextArea.Bytes(); err == nil {
+				fs.srcs[ed.ERowInfoKey(info.Name())] = b
+			}
+		}
+	}
+	return fs
+}
+
+//----------
+
+type FilesSearchMatch struct {
+	Filename  string
+	Line, Col int    // one-based, utf8 columns
+	M         []int  // match indexes (see iorw.Searcher)
+	LineText  string // truncated
+	Open      bool   // searched in the row content
+}
+
+// Calls fn for each match. The reader is only valid during the call.
+func (fs *FilesSearch) Walk(ctx context.Context, fn func(*FilesSearchMatch, iorw.ReaderAt) error) error {
+	gi := &osutil.GitIgnore{}
+	fs.addParentGitIgnores(gi)
+
+	return filepath.Walk(fs.Dir, func(path string, fi os.FileInfo, err error) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err != nil {
+			return nil // skip unreadable entries
+		}
+		if fi.IsDir() {
+			if path != fs.Dir && (fi.Name() == ".git" || gi.Ignored(path, true)) {
+				return filepath.SkipDir
+			}
+			_ = gi.AddDir(path) // best effort
+			return nil
+		}
+		if !fi.Mode().IsRegular() || gi.Ignored(path, false) {
+			return nil
+		}
+		return fs.searchFile(ctx, path, fi, fn)
+	})
+}
+
+func (fs *FilesSearch) searchFile(ctx context.Context, filename string, fi os.FileInfo, fn func(*FilesSearchMatch, iorw.ReaderAt) error) error {
+	var rd iorw.ReaderAt
+	src, open := fs.srcs[fs.ed.ERowInfoKey(filename)]
+	if open {
+		rd = iorw.NewBytesReadWriterAt(src)
+	} else {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil // skip
+		}
+		defer f.Close()
+		rd = iorw.NewChunkedReaderAt(f, int(fi.Size()))
+	}
+
+	// skip binary files (same heuristic as git: a zero byte at the start)
+	if b, err := rd.ReadFastAt(0, 8000); err != nil && err != io.EOF {
+		return nil
+	} else if bytes.IndexByte(b, 0) >= 0 {
+		return nil
+	}
+
+	lc := &fsLineCounter{rd: rd, line: 1}
+	for i := 0; ; {
+		m, err := fs.Searcher.Index(ctx, rd, i)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			return nil // skip
+		}
+		if m == nil {
+			return nil
+		}
+		line, col, lineStart, err := lc.position(m[0])
+		if err != nil {
+			return nil
+		}
+		fsm := &FilesSearchMatch{
+			Filename: filename,
+			Line:     line,
+			Col:      col,
+			M:        m,
+			LineText: fsLineText(rd, lineStart),
+			Open:     open,
+		}
+		if err := fn(fsm, rd); err != nil {
+			return err
+		}
+		i = m[1]
+	}
+}
+
+// The rules of the ".gitignore" files of the parent directories up to the git repository root.
+func (fs *FilesSearch) addParentGitIgnores(gi *osutil.GitIgnore) {
+	dirs := []string{}
+	for d := filepath.Dir(fs.Dir); ; d = filepath.Dir(d) {
+		dirs = append(dirs, d)
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			// add from the root down
+			for k := len(dirs) - 1; k >= 0; k-- {
+				_ = gi.AddDir(dirs[k])
+			}
+			return
+		}
+		if d == filepath.Dir(d) {
+			return // not inside a repository
+		}
+	}
+}
+
+//----------
+
+// Counts lines incrementally (the indexes must be increasing).
+type fsLineCounter struct {
+	rd        iorw.ReaderAt
+	i         int // counted up to this index
+	line      int
+	lineStart int
+}
+
+func (lc *fsLineCounter) position(index int) (line, col, lineStart int, _ error) {
+	for lc.i < index {
+		n := index - lc.i
+		if n > 32*1024 {
+			n = 32 * 1024
+		}
+		b, err := lc.rd.ReadFastAt(lc.i, n)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		for k := 0; ; {
+			j := bytes.IndexByte(b[k:], '\n')
+			if j < 0 {
+				break
+			}
+			k += j + 1
+			lc.line++
+			lc.lineStart = lc.i + k
+		}
+		lc.i += n
+	}
+	b, err := lc.rd.ReadFastAt(lc.lineStart, index-lc.lineStart)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return lc.line, utf8.RuneCount(b) + 1, lc.lineStart, nil
+}
+
+func fsLineText(rd iorw.ReaderAt, lineStart int) string {
+	b, err := rd.ReadFastAt(lineStart, 200)
+	if err != nil && err != io.EOF {
+		return ""
+	}
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	return string(bytes.TrimRight(b, "\r"))
+}
+
+//----------
+
+const findInFilesRowName = "+FindInFiles"
+
+// Streams the matches in the "file:line:col: text" format to a special row. Should be called under UI goroutine.
+func FindInFiles(ed *Editor, dir string, s *iorw.Searcher) {
+	fs := NewFilesSearch(ed, dir, s)
+	erow, _ := ExistingERowOrNewBasic(ed, findInFilesRowName)
+	erow.Flash()
+	erow.Exec.RunAsync(func(ctx context.Context, w io.ReadWriter) error {
+		nm, nf, last := 0, 0, ""
+		err := fs.Walk(ctx, func(fsm *FilesSearchMatch, rd iorw.ReaderAt) error {
+			nm++
+			if fsm.Filename != last {
+				nf++
+				last = fsm.Filename
+			}
+			_, err := fmt.Fprintf(w, "%v:%v:%v: %v\n", fsm.Filename, fsm.Line, fsm.Col, fsm.LineText)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "# %d matches in %d files\n", nm, nf)
+		return err
+	})
+}
+
+//----------
+
+// Files edits waiting to be confirmed.
+type ReplaceInFilesPreview struct {
+	ed    *Editor
+	files []*rifFile
+}
+
+type rifFile struct {
+	filename string
+	open     bool        // edits computed from the row content
+	hash     []byte      // open file content hash
+	fi       os.FileInfo // disk file state
+	edits    []*rifEdit  // increasing offsets
+}
+
+type rifEdit struct {
+	offset, n int
+	text      []byte
+}
+
+const replaceInFilesRowName = "+ReplaceInFiles"
+
+func NewReplaceInFilesPreview(ed *Editor) *ReplaceInFilesPreview {
+	return &ReplaceInFilesPreview{ed: ed}
+}
+
+// Streams the edits in the "file:line:col: old -> new" format to a special row. The edits are applied on confirm. Should be called under UI goroutine.
+func (p *ReplaceInFilesPreview) Show(dir string, s *iorw.Searcher, template string) {
+	p.files = nil
+	fs := NewFilesSearch(p.ed, dir, s)
+	erow, isNew := ExistingERowOrNewBasic(p.ed, replaceInFilesRowName)
+	if isNew {
+		erow.ToolbarSetStrAfterNameClearHistory(" | ReplaceInFilesConfirm | ReplaceInFilesCancel")
+	}
+	erow.Flash()
+	erow.Exec.RunAsync(func(ctx context.Context, w io.ReadWriter) error {
+		files := []*rifFile{}
+		var file *rifFile
+		nm := 0
+		err := fs.Walk(ctx, func(fsm *FilesSearchMatch, rd iorw.ReaderAt) error {
+			if file == nil || file.filename != fsm.Filename {
+				file = &rifFile{filename: fsm.Filename, open: fsm.Open}
+				if fsm.Open {
+					file.hash = bytesHash(fs.srcs[p.ed.ERowInfoKey(fsm.Filename)])
+				} else {
+					fi, err := os.Stat(fsm.Filename)
+					if err != nil {
+						return err
+					}
+					file.fi = fi
+				}
+				files = append(files, file)
+			}
+			old, err := rd.ReadFastAt(fsm.M[0], fsm.M[1]-fsm.M[0])
+			if err != nil {
+				return err
+			}
+			text, err := fs.Searcher.Expand(rd, template, fsm.M)
+			if err != nil {
+				return err
+			}
+			e := &rifEdit{offset: fsm.M[0], n: len(old), text: text}
+			file.edits = append(file.edits, e)
+			nm++
+			_, err = fmt.Fprintf(w, "%v:%v:%v: %q -> %q\n", fsm.Filename, fsm.Line, fsm.Col, old, text)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "# %d edits in %d files, use ReplaceInFilesConfirm to apply\n", nm, len(files)); err != nil {
+			return err
+		}
+		p.ed.UI.RunOnUIGoRoutine(func() {
+			if ctx.Err() == nil { // not replaced by a later run
+				p.files = files
+			}
+		})
+		return nil
+	})
+}
+
+// All files are checked before editing. Open files have their rows content edited (not saved), other files are edited on disk. Should be called under UI goroutine.
+func (p *ReplaceInFilesPreview) Confirm() error {
+	if p.files == nil {
+		return fmt.Errorf("no edits to confirm")
+	}
+	files := p.files
+	p.files = nil
+	for _, f := range files {
+		if err := p.check(f); err != nil {
+			p.setStatus(fmt.Sprintf("error: %v", err))
+			return err
+		}
+	}
+	for _, f := range files {
+		if err := p.apply(f); err != nil {
+			p.setStatus(fmt.Sprintf("error: %v", err))
+			return err
+		}
+	}
+	p.setStatus(fmt.Sprintf("applied edits to %d files", len(files)))
+	return nil
+}
+
+// Should be called under UI goroutine.
+func (p *ReplaceInFilesPreview) Cancel() {
+	p.files = nil
+	if erow0, ok := p.erow(); ok {
+		erow0.Exec.Stop()
+	}
+	p.setStatus("canceled")
+}
+
+//----------
+
+// Checks that the content is the same as when the edits were computed.
+func (p *ReplaceInFilesPreview) check(f *rifFile) error {
+	erow0, open := p.fileERow(f.filename)
+	if open && erow0.Info.IsReadOnly() {
+		return fmt.Errorf("read-only row: %v", f.filename)
+	}
+	changed := fmt.Errorf("file changed since the preview: %v", f.filename)
+	if f.open {
+		if !open {
+			return fmt.Errorf("row closed since the preview: %v", f.filename)
+		}
+		b, err := erow0.Row.TextArea.Bytes()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(bytesHash(b), f.hash) {
+			return changed
+		}
+		return nil
+	}
+	fi, err := os.Stat(f.filename)
+	if err != nil {
+		return err
+	}
+	if fi.Size() != f.fi.Size() || !fi.ModTime().Equal(f.fi.ModTime()) {
+		return changed
+	}
+	if open && erow0.Info.HasRowState(ui.RowStateEdited|ui.RowStateFsDiffer) {
+		return fmt.Errorf("row has edits, save first: %v", f.filename)
+	}
+	return nil
+}
+
+func (p *ReplaceInFilesPreview) apply(f *rifFile) error {
+	// edit through the row (opened after the preview if not f.open)
+	if erow0, ok := p.fileERow(f.filename); ok {
+		ta := erow0.Row.TextArea
+		ta.BeginUndoGroup()
+		defer ta.EndUndoGroup()
+		return rifPatchRW(ta.RW(), f.edits)
+	}
+
+	b, err := ioutil.ReadFile(f.filename)
+	if err != nil {
+		return err
+	}
+	rw := iorw.NewBytesReadWriterAt(b)
+	if err := rifPatchRW(rw, f.edits); err != nil {
+		return err
+	}
+	b2, err := iorw.ReadFastFull(rw)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(f.filename, b2, f.fi.Mode().Perm())
+}
+
+func (p *ReplaceInFilesPreview) fileERow(filename string) (*ERow, bool) {
+	if info, ok := p.ed.ERowInfo(filename); ok {
+		return info.FirstERow()
+	}
+	return nil, false
+}
+
+func (p *ReplaceInFilesPreview) erow() (*ERow, bool) {
+	return p.fileERow(replaceInFilesRowName)
+}
+
+func (p *ReplaceInFilesPreview) setStatus(s string) {
+	if erow0, ok := p.erow(); ok {
+		erow0.Row.TextArea.SetStrClearPos(s + "\n")
+	}
+}
+
+// Edits are applied from the end to keep the offsets valid.
+func rifPatchRW(rw iorw.ReadWriterAt, edits []*rifEdit) error {
+	for k := len(edits) - 1; k >= 0; k-- {
+		e := edits[k]
+		if err := rw.OverwriteAt(e.offset, e.n, e.text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestFilesSearch1(t *testing.T) {
+	dir, err := ioutil.TempDir("", "editor_filessearch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		".gitignore":     "*.log\nbuild/\n",
+		"a.txt":          "hello\nworld héllo hello\n",
+		"b.log":          "hello\n",
+		"c.bin":          "hello\x00\n",
+		"build/d.txt":    "hello\n",
+		"sub/e.txt":      "xhello hello",
+		"sub/.git/f":     "hello\n",
+		"sub/g/h.txt":    "hello",
+		"sub/.gitignore": "g/\n",
+	}
+	for name, s := range files {
+		fname := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(fname, []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := iorw.NewSearcher("hello", iorw.SearchOpt{WholeWord: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := &FilesSearch{Dir: dir, Searcher: s, ed: &Editor{}, srcs: map[string][]byte{}}
+	u := []string{}
+	err = fs.Walk(context.Background(), func(fsm *FilesSearchMatch, rd iorw.ReaderAt) error {
+		name, _ := filepath.Rel(dir, fsm.Filename)
+		u = append(u, fmt.Sprintf("%v:%v:%v: %v", name, fsm.Line, fsm.Col, fsm.LineText))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := strings.Join(u, "\n")
+	exp := strings.Join([]string{
+		"a.txt:1:1: hello",
+		"a.txt:2:13: world héllo hello",
+		"sub/e.txt:1:8: xhello hello",
+	}, "\n")
+	if res != exp {
+		t.Fatalf("expected:\n%v\ngot:\n%v", exp, res)
+	}
+}
+
+func TestRifPatchRW(t *testing.T) {
+	rw := iorw.NewBytesReadWriterAt([]byte("aa bb aa"))
+	edits := []*rifEdit{
+		{offset: 0, n: 2, text: []byte("c")},
+		{offset: 6, n: 2, text: []byte("ddd")},
+	}
+	if err := rifPatchRW(rw, edits); err != nil {
+		t.Fatal(err)
+	}
+	b, err := iorw.ReadFastFull(rw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "c bb ddd" {
+		t.Fatal(string(b))
+	}
+}
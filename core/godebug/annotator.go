@@ -3,9 +3,13 @@ package godebug
 import (
 	"fmt"
 	"go/ast"
+	"go/constant"
+	"go/parser"
 	"go/printer"
 	"go/token"
+	"go/types"
 	"io"
+	"sort"
 
 	"github.com/davecgh/go-spew/spew"
 )
@@ -18,14 +22,18 @@ type Annotator struct {
 	fileIndex         int
 
 	debugIndex         int
+	debugOffsets       map[int]struct{}
+	sampleIndex        int
 	builtDebugLineStmt bool
 
-	files         *Files
-	nodeAnnTypeFn func(ast.Node) AnnotationType
+	files        *Files
+	nodeAnnOptFn func(ast.Node) (*AnnotationOpt, bool)
+	typesInfo    *types.Info // can be nil (only in typed mode)
 }
 
-func NewAnnotator(fset *token.FileSet, nodeAnnTypeFn func(ast.Node) AnnotationType) *Annotator {
-	ann := &Annotator{fset: fset, nodeAnnTypeFn: nodeAnnTypeFn}
+func NewAnnotator(fset *token.FileSet, nodeAnnOptFn func(ast.Node) (*AnnotationOpt, bool)) *Annotator {
+	ann := &Annotator{fset: fset, nodeAnnOptFn: nodeAnnOptFn}
+	ann.debugOffsets = map[int]struct{}{}
 	ann.debugPkgName = string('Σ')
 	ann.debugVarPrefix = string('Σ')
 	return ann
@@ -45,6 +53,16 @@ func (ann *Annotator) AnnotateAstFile(astFile *ast.File, typ AnnotationType) {
 	ann.removeInnerFuncComments(astFile)
 }
 
+// Offsets of the debug stmts inserted, sorted.
+func (ann *Annotator) DebugOffsets() []int {
+	u := make([]int, 0, len(ann.debugOffsets))
+	for o := range ann.debugOffsets {
+		u = append(u, o)
+	}
+	sort.Ints(u)
+	return u
+}
+
 //----------
 
 func (ann *Annotator) removeInnerFuncComments(astFile *ast.File) {
@@ -287,10 +305,15 @@ func (ann *Annotator) visitForStmt(ctx *Ctx, fs *ast.ForStmt) {
 		ctx4, _ := ctx.withStmtIter(&fs.Body.List) // index at 0
 		ctx4.insertInStmtListBefore(bs)
 
+		// the condition that allows the iteration is part of the previous one
+		ann.insertLoopRecIter(ctx4, fs)
 		return
 	}
 
 	ann.visitBlockStmt(ctx, fs.Body)
+
+	ctx4, _ := ctx.withStmtIter(&fs.Body.List) // index at 0
+	ann.insertLoopRecIter(ctx4, fs)
 }
 
 func (ann *Annotator) visitRangeStmt(ctx *Ctx, rs *ast.RangeStmt) {
@@ -349,6 +372,7 @@ func (ann *Annotator) visitRangeStmt(ctx *Ctx, rs *ast.RangeStmt) {
 
 	// insert created blockstmt at the top (after visiting body).
 	ctx4, _ := ctx.withStmtIter(&rs.Body.List) // index at 0
+	ann.insertLoopRecIter(ctx4, rs)
 	ctx4.insertInStmtListBefore(bs)
 }
 
@@ -682,6 +706,9 @@ func (ann *Annotator) visitCallExpr(ctx *Ctx, ce *ast.CallExpr) {
 
 	// visit args
 	ctx2 = ctx2.withNResults(1)
+	if n, ok := ann.multiValueArgs(ce); ok {
+		ctx2 = ctx2.withNResults(n) // f(g()) // g returns n results
+	}
 	ctx2 = ctx2.withResultInVar(true)
 	args := ann.visitExprList(ctx2, &ce.Args)
 
@@ -1078,8 +1105,15 @@ func (ann *Annotator) visitStmtList(ctx *Ctx, list *[]ast.Stmt) {
 			ctx3 = ctx3.withInsertStmtAfter(true)
 		}
 
+		// directives that apply only to the stmt debug lines
+		ctx3, lr := ann.stmtLineDirectives(ctx3, stmt)
+
 		ann.visitStmt(ctx3, stmt)
 
+		if lr != nil {
+			ann.insertLoopRecDone(ctx3, lr)
+		}
+
 		iter.index += 1 + iter.step
 		iter.step = 0
 	}
@@ -1257,6 +1291,9 @@ func (ann *Annotator) assignToNewIdents(ctx *Ctx, nids int, exprs ...ast.Expr) [
 //----------
 
 func (ann *Annotator) newDebugCallExpr(fname string, u ...ast.Expr) *ast.CallExpr {
+	if fname == "IV" && len(u) == 1 {
+		u[0] = ann.typedConstExpr(u[0])
+	}
 	se := &ast.SelectorExpr{
 		X:   ast.NewIdent(ann.debugPkgName),
 		Sel: ast.NewIdent(fname),
@@ -1286,6 +1323,7 @@ func (ann *Annotator) newDebugLineStmt(ctx *Ctx, pos token.Pos, e ast.Expr) ast.
 
 	position := ann.fset.Position(pos)
 	lineOffset := position.Offset
+	ann.debugOffsets[lineOffset] = struct{}{}
 
 	args := []ast.Expr{
 		basicLitInt(ann.fileIndex),
@@ -1294,14 +1332,106 @@ func (ann *Annotator) newDebugLineStmt(ctx *Ctx, pos token.Pos, e ast.Expr) ast.
 		e,
 	}
 
+	// loop recorder sends only the first/last iterations
+	x := ast.NewIdent(ann.debugPkgName)
+	if lr, ok := ctx.loopRec(); ok {
+		x = ast.NewIdent(lr.name)
+	}
+
 	se := &ast.SelectorExpr{
-		X:   ast.NewIdent(ann.debugPkgName),
+		X:   x,
 		Sel: ast.NewIdent("Line"),
 	}
 	es := &ast.ExprStmt{X: &ast.CallExpr{Fun: se, Args: args}}
 
 	ann.builtDebugLineStmt = true
-	return es
+	return ann.guardDebugLineStmt(ctx, es)
+}
+
+// Wraps the debug line in an ifstmt if a sample/condition directive applies.
+func (ann *Annotator) guardDebugLineStmt(ctx *Ctx, stmt ast.Stmt) ast.Stmt {
+	lg, ok := ctx.lineGuard()
+	if !ok {
+		return stmt
+	}
+	cond := lg.cond
+	if cond == nil {
+		cond = ann.newDebugCallExpr("Sample",
+			basicLitInt(lg.every),
+			basicLitInt(ann.fileIndex),
+			basicLitInt(ann.sampleIndex))
+		ann.sampleIndex++
+	}
+	return &ast.IfStmt{Cond: cond, Body: &ast.BlockStmt{List: []ast.Stmt{stmt}}}
+}
+
+//----------
+
+// Sets up the directives that apply to the debug lines of the stmt (every/first/last/if). Returns a loop recorder that needs to be done after visiting the stmt.
+func (ann *Annotator) stmtLineDirectives(ctx *Ctx, stmt ast.Stmt) (*Ctx, *loopRec) {
+	if ctx.noAnnotations() {
+		return ctx, nil
+	}
+	opt, ok := ann.nodeAnnOptFn(stmt)
+	if !ok {
+		return ctx, nil
+	}
+	switch opt.Type {
+	case AnnotationTypeEvery:
+		return ctx.withLineGuard(&lineGuard{every: opt.intOpt()}), nil
+	case AnnotationTypeIf:
+		// parse in the fileset to have positions valid for printing
+		cond, err := parser.ParseExprFrom(ann.fset, "godebug:annotateif", opt.Opt, 0)
+		if err != nil {
+			return ctx, nil // already validated
+		}
+		return ctx.withLineGuard(&lineGuard{cond: cond}), nil
+	case AnnotationTypeFirst, AnnotationTypeLast:
+		loop, ok := annOptLoopStmt(stmt)
+		if !ok {
+			return ctx, nil
+		}
+		// already setup (stmt being revisited inside a wrapping block)
+		if lr, ok := ctx.loopRec(); ok && lr.loop == loop {
+			return ctx, nil
+		}
+		first, last := 0, 0
+		if opt.Type == AnnotationTypeFirst {
+			first = opt.intOpt()
+		} else {
+			last = opt.intOpt()
+		}
+		lr := &loopRec{loop: loop, name: ann.newVarName(ctx)}
+		ce := ann.newDebugCallExpr("NewLoop", basicLitInt(first), basicLitInt(last))
+		as := ann.newAssignStmt11(ast.NewIdent(lr.name), ce)
+		ctx.insertInStmtListBefore(as)
+		if last > 0 {
+			// send the kept iterations if the loop exits by return/panic
+			ds := &ast.DeferStmt{Call: ann.newLoopRecCallExpr(lr, "Done")}
+			ctx.insertInStmtListBefore(ds)
+		}
+		return ctx.withLoopRec(lr), lr
+	}
+	return ctx, nil
+}
+
+func (ann *Annotator) insertLoopRecIter(ctx *Ctx, loop ast.Stmt) {
+	lr, ok := ctx.loopRec()
+	if !ok || lr.loop != loop {
+		return
+	}
+	stmt := &ast.ExprStmt{X: ann.newLoopRecCallExpr(lr, "Iter")}
+	ctx.insertInStmtListBefore(stmt)
+}
+
+func (ann *Annotator) insertLoopRecDone(ctx *Ctx, lr *loopRec) {
+	stmt := &ast.ExprStmt{X: ann.newLoopRecCallExpr(lr, "Done")}
+	ctx.insertInStmtListAfter(stmt)
+}
+
+func (ann *Annotator) newLoopRecCallExpr(lr *loopRec, fname string) *ast.CallExpr {
+	se := &ast.SelectorExpr{X: ast.NewIdent(lr.name), Sel: ast.NewIdent(fname)}
+	return &ast.CallExpr{Fun: se}
 }
 
 //----------
@@ -1329,8 +1459,11 @@ func (ann *Annotator) newAssignStmt(lhs, rhs []ast.Expr) *ast.AssignStmt {
 
 // Returns on/off, ok.
 func (ann *Annotator) annotationsOn(n ast.Node) (bool, bool) {
-	at := ann.nodeAnnTypeFn(n)
-	switch at {
+	opt, ok := ann.nodeAnnOptFn(n)
+	if !ok {
+		return false, false
+	}
+	switch opt.Type {
 	case AnnotationTypeOff:
 		return false, true
 	case AnnotationTypeBlock:
@@ -1341,6 +1474,57 @@ func (ann *Annotator) annotationsOn(n ast.Node) (bool, bool) {
 
 //----------
 
+// Typed mode: number of results of a multi-value call being the only arg.
+func (ann *Annotator) multiValueArgs(ce *ast.CallExpr) (int, bool) {
+	if ann.typesInfo == nil || len(ce.Args) != 1 {
+		return 0, false
+	}
+	tv, ok := ann.typesInfo.Types[ce.Args[0]]
+	if !ok {
+		return 0, false
+	}
+	if t, ok := tv.Type.(*types.Tuple); ok && t.Len() >= 2 {
+		return t.Len(), true
+	}
+	return 0, false
+}
+
+// Typed mode: a const given to an interface{} arg gets the default type (int), which fails to compile if the const overflows it (ex: math.MaxUint64). Converts the const to its type.
+func (ann *Annotator) typedConstExpr(e ast.Expr) ast.Expr {
+	if ann.typesInfo == nil {
+		return e
+	}
+	tv, ok := ann.typesInfo.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return e
+	}
+	if _, exact := constant.Int64Val(tv.Value); exact {
+		return e // fits the default type
+	}
+	// basic types only, named types could need an import
+	b, ok := tv.Type.(*types.Basic)
+	if !ok || b.Info()&types.IsUntyped != 0 {
+		return e
+	}
+	return &ast.CallExpr{Fun: ast.NewIdent(b.Name()), Args: []ast.Expr{e}}
+}
+
+//----------
+
+// Debug lines guard: sample every nth execution, or only when the condition holds.
+type lineGuard struct {
+	every int
+	cond  ast.Expr
+}
+
+// Debug lines of the loop are sent by a debug.Loop (first/last iterations).
+type loopRec struct {
+	loop ast.Stmt // *ast.ForStmt or *ast.RangeStmt
+	name string   // debug.Loop var name
+}
+
+//----------
+
 func nilIdent() *ast.Ident {
 	return &ast.Ident{Name: "nil"}
 }
@@ -3,9 +3,11 @@ package godebug
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"go/types"
 	"testing"
 
 	"github.com/jmigpin/editor/util/parseutil"
@@ -2047,6 +2049,195 @@ func TestAnnotator111(t *testing.T) {
 	testAnnotator1(t, inout[0], inout[1], srcFunc1)
 }
 
+func TestAnnotator112(t *testing.T) {
+	inout := []string{
+		`//godebug:annotateevery:10
+		for i:=0; i<n;i++{
+			a=i
+		}`,
+		`{
+	        Σ0 := Σ.IV(0)
+	        i := 0
+	        Σ1 := Σ.IV(i)
+	        if Σ.Sample(10, 0, 0) {
+	        Σ.Line(0, 0, 58, Σ.IA(Σ.IL(Σ1), Σ.IL(Σ0)))
+	        }
+	        for ; ; i++ {
+	        {
+	        Σ2 := Σ.IV(i)
+	        Σ3 := Σ.IV(n)
+	        Σ4 := i < n
+	        Σ5 := Σ.IV(Σ4)
+	        Σ6 := Σ.IB(Σ5, 40, Σ2, Σ3)
+	        if Σ.Sample(10, 0, 1) {
+	        Σ.Line(0, 1, 63, Σ6)
+	        }
+	        if !Σ4 {
+	        break
+	        }
+	        }
+	        Σ7 := Σ.IV(i)
+	        a = i
+	        Σ8 := Σ.IV(a)
+	        if Σ.Sample(10, 0, 2) {
+	        Σ.Line(0, 2, 72, Σ.IA(Σ.IL(Σ8), Σ.IL(Σ7)))
+	        }
+	        }
+	        }`,
+	}
+	testAnnotator1(t, inout[0], inout[1], srcFunc1)
+}
+
+func TestAnnotator113(t *testing.T) {
+	inout := []string{
+		`//godebug:annotateif:i%100==0
+		for i:=0; i<n;i++{
+			a=i
+		}`,
+		`{
+	        Σ0 := Σ.IV(0)
+	        i := 0
+	        Σ1 := Σ.IV(i)
+	        if i%100 == 0 {
+	        Σ.Line(0, 0, 61, Σ.IA(Σ.IL(Σ1), Σ.IL(Σ0)))
+	        }
+	        for ; ; i++ {
+	        {
+	        Σ2 := Σ.IV(i)
+	        Σ3 := Σ.IV(n)
+	        Σ4 := i < n
+	        Σ5 := Σ.IV(Σ4)
+	        Σ6 := Σ.IB(Σ5, 40, Σ2, Σ3)
+	        if i%100 == 0 {
+	        Σ.Line(0, 1, 66, Σ6)
+	        }
+	        if !Σ4 {
+	        break
+	        }
+	        }
+	        Σ7 := Σ.IV(i)
+	        a = i
+	        Σ8 := Σ.IV(a)
+	        if i%100 == 0 {
+	        Σ.Line(0, 2, 75, Σ.IA(Σ.IL(Σ8), Σ.IL(Σ7)))
+	        }
+	        }
+	        }`,
+	}
+	testAnnotator1(t, inout[0], inout[1], srcFunc1)
+}
+
+func TestAnnotator114(t *testing.T) {
+	inout := []string{
+		`//godebug:annotatefirst:3
+		for i:=0; i<n;i++{
+			a=i
+		}
+		a=1`,
+		`Σ0 := Σ.NewLoop(3, 0)
+	        {
+	        Σ1 := Σ.IV(0)
+	        i := 0
+	        Σ2 := Σ.IV(i)
+	        Σ0.Line(0, 0, 57, Σ.IA(Σ.IL(Σ2), Σ.IL(Σ1)))
+	        for ; ; i++ {
+	        {
+	        Σ3 := Σ.IV(i)
+	        Σ4 := Σ.IV(n)
+	        Σ5 := i < n
+	        Σ6 := Σ.IV(Σ5)
+	        Σ7 := Σ.IB(Σ6, 40, Σ3, Σ4)
+	        Σ0.Line(0, 1, 62, Σ7)
+	        if !Σ5 {
+	        break
+	        }
+	        }
+	        Σ0.Iter()
+	        Σ8 := Σ.IV(i)
+	        a = i
+	        Σ9 := Σ.IV(a)
+	        Σ0.Line(0, 2, 71, Σ.IA(Σ.IL(Σ9), Σ.IL(Σ8)))
+	        }
+	        }
+	        Σ0.Done()
+	        Σ10 := Σ.IV(1)
+	        a = 1
+	        Σ11 := Σ.IV(a)
+	        Σ.Line(0, 3, 77, Σ.IA(Σ.IL(Σ11), Σ.IL(Σ10)))`,
+	}
+	testAnnotator1(t, inout[0], inout[1], srcFunc1)
+}
+
+func TestAnnotator115(t *testing.T) {
+	inout := []string{
+		`//godebug:annotatelast:2
+		loop1:
+		for _, v := range u {
+			a=v
+		}`,
+		`Σ0 := Σ.NewLoop(0, 2)
+	        defer Σ0.Done()
+	        Σ0.Line(0, 0, 48, Σ.ILa())
+	        Σ1 := Σ.IV(u)
+	        _ = Σ1
+	        Σ2 := Σ.IVl(len(u))
+	        loop1:
+	        for _, v := range u {
+	        Σ0.Iter()
+	        {
+	        Σ3 := Σ.IL(Σ2)
+	        Σ4 := Σ.IL(Σ.IAn(), Σ.IV(v))
+	        Σ0.Line(0, 1, 74, Σ.IA(Σ4, Σ3))
+	        }
+	        Σ5 := Σ.IV(v)
+	        a = v
+	        Σ6 := Σ.IV(a)
+	        Σ0.Line(0, 2, 80, Σ.IA(Σ.IL(Σ6), Σ.IL(Σ5)))
+	        }
+	        Σ0.Done()`,
+	}
+	testAnnotator1(t, inout[0], inout[1], srcFunc1)
+}
+
+func TestAnnotatorTyped1(t *testing.T) {
+	inout := []string{
+		`myfunc2(myfunc1())`,
+		`Σ.Line(0, 0, 137, Σ.ICe("myfunc1"))
+	        Σ0, Σ1 := myfunc1()
+	        Σ2 := Σ.IL(Σ.IV(Σ0), Σ.IV(Σ1))
+	        Σ3 := Σ.IC("myfunc1", Σ2)
+	        Σ.Line(0, 0, 138, Σ.ICe("myfunc2", Σ3))
+	        Σ4 := Σ.IC("myfunc2", nil, Σ3)
+	        myfunc2(Σ0, Σ1)
+	        Σ.Line(0, 0, 139, Σ4)`,
+	}
+	testAnnotatorTyped1(t, inout[0], inout[1], srcFuncTyped1)
+}
+
+func TestAnnotatorTyped2(t *testing.T) {
+	inout := []string{
+		`a = maxUint64
+		b := uint64(maxUint64)
+		_ = b`,
+		`Σ0 := Σ.IV(uint64(maxUint64))
+	        a = maxUint64
+	        Σ1 := Σ.IV(a)
+	        Σ.Line(0, 0, 134, Σ.IA(Σ.IL(Σ1), Σ.IL(Σ0)))
+	        Σ2 := Σ.IV(uint64(maxUint64))
+	        Σ.Line(0, 1, 156, Σ.ICe("uint64", Σ2))
+	        Σ3 := uint64(maxUint64)
+	        Σ4 := Σ.IV(Σ3)
+	        Σ5 := Σ.IC("uint64", Σ4, Σ2)
+	        b := Σ3
+	        Σ6 := Σ.IV(b)
+	        Σ.Line(0, 1, 157, Σ.IA(Σ.IL(Σ6), Σ.IL(Σ5)))
+	        Σ7 := Σ.IV(b)
+	        _ = b
+	        Σ.Line(0, 2, 163, Σ.IA(Σ.IL(Σ.IAn()), Σ.IL(Σ7)))`,
+	}
+	testAnnotatorTyped1(t, inout[0], inout[1], srcFuncTyped1)
+}
+
 func TestAnnotator_(t *testing.T) {
 	inout := []string{
 		``,
@@ -2106,7 +2297,7 @@ func testAnnotator1(t *testing.T, in0, out0 string, fn func(s string) string) {
 		t.Fatal(err)
 	}
 
-	ann := NewAnnotator(files.fset, files.NodeAnnType)
+	ann := NewAnnotator(files.fset, files.NodeAnnOpt)
 	ann.debugPkgName = "Σ"   // expected by tests
 	ann.debugVarPrefix = "Σ" // expected by tests
 	ann.AnnotateAstFile(astFile, typ)
@@ -2121,6 +2312,42 @@ func testAnnotator1(t *testing.T, in0, out0 string, fn func(s string) string) {
 	}
 }
 
+// Annotates with the types info of the src (typed mode).
+func testAnnotatorTyped1(t *testing.T, in0, out0 string, fn func(s string) string) {
+	t.Helper()
+
+	in := parseutil.TrimLineSpaces(fn(in0))
+	out := parseutil.TrimLineSpaces(fn(out0))
+	typ := AnnotationTypeFile
+
+	files, names := newFilesFromSrcs(t, in)
+	astFile, err := files.fullAstFile(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := &types.Info{Types: map[ast.Expr]types.TypeAndValue{}}
+	conf := &types.Config{}
+	if _, err := conf.Check("p1", files.fset, []*ast.File{astFile}, info); err != nil {
+		t.Fatal(err)
+	}
+
+	ann := NewAnnotator(files.fset, files.NodeAnnOpt)
+	ann.debugPkgName = "Σ"   // expected by tests
+	ann.debugVarPrefix = "Σ" // expected by tests
+	ann.typesInfo = info
+	ann.AnnotateAstFile(astFile, typ)
+
+	var buf bytes.Buffer
+	ann.PrintSimple(&buf, astFile)
+	res := parseutil.TrimLineSpaces(buf.String())
+
+	if res != out {
+		u := fmt.Sprintf("\n*in:\n%s\n*expecting:\n%s\n*got:\n%s", in, out, res)
+		t.Fatalf(u)
+	}
+}
+
 //----------
 
 func srcFunc1(s string) string {
@@ -2130,6 +2357,17 @@ func srcFunc1(s string) string {
 		}`
 }
 
+func srcFuncTyped1(s string) string {
+	return `package p1
+		const maxUint64 = 1<<64 - 1
+		var a uint64
+		var myfunc1 func() (int, int)
+		var myfunc2 func(int, int)
+		func f0() {
+			` + s + `
+		}`
+}
+
 func srcFunc2(s string) string {
 	return `package p1
 		func f0() (a int, b *int, c *Struct1) {
@@ -352,6 +352,34 @@ func (ctx *Ctx) labeledStmt() (*ast.LabeledStmt, bool) {
 
 //----------
 
+func (ctx *Ctx) withLineGuard(lg *lineGuard) *Ctx {
+	return ctx.WithValue("line_guard", lg)
+}
+func (ctx *Ctx) lineGuard() (*lineGuard, bool) {
+	v, _ := ctx.Value("line_guard")
+	if v == nil {
+		return nil, false
+	}
+	u := v.(*lineGuard)
+	return u, true
+}
+
+//----------
+
+func (ctx *Ctx) withLoopRec(lr *loopRec) *Ctx {
+	return ctx.WithValue("loop_rec", lr)
+}
+func (ctx *Ctx) loopRec() (*loopRec, bool) {
+	v, _ := ctx.Value("loop_rec")
+	if v == nil {
+		return nil, false
+	}
+	u := v.(*loopRec)
+	return u, true
+}
+
+//----------
+
 func (ctx *Ctx) valuesReset() *Ctx {
 	ctx = ctx.WithValue("n_results", nil)
 	ctx = ctx.WithValue("static_debug_index", nil)
@@ -368,6 +396,8 @@ func (ctx *Ctx) valuesReset() *Ctx {
 	// 	stmt_iter
 	// 	insert_in_stmt_list_after
 	// 	func_type
+	// 	line_guard
+	// 	loop_rec
 
 	return ctx
 }
@@ -49,46 +49,77 @@ func NewAnnotatorSet() *AnnotatorSet {
 //----------
 
 func (annset *AnnotatorSet) AnnotateAstFile(astFile *ast.File, filename string, files *Files) error {
+	_, err := annset.AnnotateAstFile2(astFile, filename, files)
+	return err
+}
+
+// Returns the info needed to skip annotating the same file in a later run (see SetAnnotatedFileInfo).
+func (annset *AnnotatorSet) AnnotateAstFile2(astFile *ast.File, filename string, files *Files) (*AnnotatedFileInfo, error) {
 
 	afd, err := annset.annotatorFileData(filename, files)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ann := NewAnnotator(annset.FSet, files.NodeAnnType)
+	ann := NewAnnotator(annset.FSet, files.NodeAnnOpt)
 	ann.debugPkgName = annset.debugPkgName
 	ann.debugVarPrefix = annset.debugVarPrefix
 	ann.fileIndex = afd.FileIndex
+	if info, ok := files.TypesInfo(filename); ok {
+		ann.typesInfo = info
+	}
 
 	typ := files.annTypes[filename]
 	ann.AnnotateAstFile(astFile, typ)
 
 	// n debug stmts inserted
-	afd.DebugLen = ann.debugIndex
+	info := &AnnotatedFileInfo{DebugLen: ann.debugIndex, Offsets: ann.DebugOffsets()}
 
 	// insert imports if debug stmts were inserted
 	if ann.builtDebugLineStmt {
+		info.DebugLines = true
+
 		annset.insertImportDebug(astFile)
 
 		// insert in all files to ensure inner init function runs
 		annset.insertImport(astFile, "_", GodebugconfigPkgPath)
 
 		// insert exit in main
-		ok := annset.insertDebugExitInFunction(astFile, "main")
-		if !annset.InsertedExitIn.Main {
-			annset.InsertedExitIn.Main = ok
-		}
+		info.ExitInMain = annset.insertDebugExitInFunction(astFile, "main")
 
 		// insert exit in testmain
-		ok = annset.insertDebugExitInFunction(astFile, "TestMain")
-		if !annset.InsertedExitIn.TestMain {
-			annset.InsertedExitIn.TestMain = ok
+		info.ExitInTestMain = annset.insertDebugExitInFunction(astFile, "TestMain")
+	}
+
+	if err := annset.SetAnnotatedFileInfo(astFile, filename, files, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// Sets the annotation results of a file without annotating it (ex: annotated in a previous run). The astFile is the original (only the pkg name is used).
+func (annset *AnnotatorSet) SetAnnotatedFileInfo(astFile *ast.File, filename string, files *Files, info *AnnotatedFileInfo) error {
+	afd, err := annset.annotatorFileData(filename, files)
+	if err != nil {
+		return err
+	}
+
+	annset.afds.Lock()
+	defer annset.afds.Unlock()
+
+	afd.DebugLen = info.DebugLen
+	afd.Offsets = info.Offsets
+	if info.DebugLines {
+		if info.ExitInMain {
+			annset.InsertedExitIn.Main = true
+		}
+		if info.ExitInTestMain {
+			annset.InsertedExitIn.TestMain = true
 		}
 
 		// keep test files package names in case of need to build testmain files
 		annset.keepTestPackage(filename, astFile)
 	}
-
 	return nil
 }
 
@@ -195,12 +226,13 @@ func (annset *AnnotatorSet) buildConfigContentEntries() string {
 			panic(fmt.Sprintf("file index doesn't fit map len: %v vs %v", afd.FileIndex, len(annset.afds.m)))
 		}
 
-		s := fmt.Sprintf("&debug.AnnotatorFileData{%v,%v,%q,%v,[]byte(%q)}",
+		s := fmt.Sprintf("&debug.AnnotatorFileData{%v,%v,%q,%v,[]byte(%q),%#v}",
 			afd.FileIndex,
 			afd.DebugLen,
 			afd.Filename,
 			afd.FileSize,
 			string(afd.FileHash),
+			afd.Offsets,
 		)
 		u = append(u, s+",")
 	}
@@ -253,6 +285,16 @@ func (annset *AnnotatorSet) keepTestPackage(filename string, astFile *ast.File)
 
 //----------
 
+type AnnotatedFileInfo struct {
+	DebugLen       int   // n debug stmts inserted
+	Offsets        []int // debug stmts offsets (see Annotator.DebugOffsets)
+	DebugLines     bool  // debug stmts were inserted
+	ExitInMain     bool
+	ExitInTestMain bool
+}
+
+//----------
+
 type TestMainSrc struct {
 	Dir string
 	Src string
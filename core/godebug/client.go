@@ -18,7 +18,8 @@ type Client struct {
 	waitg    sync.WaitGroup
 }
 
-func NewClient(ctx context.Context, network, addr string) (*Client, error) {
+// If trace is not nil, the bytes received from the server are written to it.
+func NewClient(ctx context.Context, network, addr string, trace io.Writer) (*Client, error) {
 	client := &Client{
 		Messages: make(chan interface{}, 128),
 	}
@@ -40,12 +41,30 @@ func NewClient(ctx context.Context, network, addr string) (*Client, error) {
 	client.waitg.Add(1)
 	go func() {
 		defer client.waitg.Done()
-		client.receiveLoop()
+		rd := io.Reader(client.Conn)
+		if trace != nil {
+			rd = io.TeeReader(rd, trace)
+		}
+		client.receiveLoop(rd)
 	}()
 
 	return client, nil
 }
 
+// Sends the msgs of a trace file (no connection). The messages channel is closed at the end of the file.
+func NewReplayClient(ctx context.Context, rd io.ReadCloser) *Client {
+	client := &Client{
+		Messages: make(chan interface{}, 128),
+	}
+	client.waitg.Add(1)
+	go func() {
+		defer client.waitg.Done()
+		defer rd.Close()
+		client.replayLoop(ctx, rd)
+	}()
+	return client
+}
+
 func (client *Client) Wait() {
 	client.waitg.Wait()
 }
@@ -78,10 +97,10 @@ func (client *Client) connect(ctx context.Context, network, addr string) error {
 	return ctxutil.Retry(ctx, sleep, "connect", fn, lateFn)
 }
 
-func (client *Client) receiveLoop() {
+func (client *Client) receiveLoop(rd io.Reader) {
 	defer close(client.Messages)
 	for {
-		msg, err := debug.DecodeMessage(client.Conn)
+		msg, err := debug.DecodeMessage(rd)
 		if err != nil {
 			// unable to read (server was probably closed)
 			if operr, ok := err.(*net.OpError); ok {
@@ -101,3 +120,27 @@ func (client *Client) receiveLoop() {
 		client.Messages <- msg
 	}
 }
+
+func (client *Client) replayLoop(ctx context.Context, rd io.Reader) {
+	defer close(client.Messages)
+	for {
+		msg, err := debug.DecodeMessage(rd)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			msg = fmt.Errorf("replay: %w", err)
+		}
+
+		// the reader is not closed on ctx cancel, don't block on a full channel
+		select {
+		case client.Messages <- msg:
+		case <-ctx.Done():
+			return
+		}
+
+		if err != nil {
+			return // a truncated trace can't be decoded further
+		}
+	}
+}
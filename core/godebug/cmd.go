@@ -14,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 
@@ -32,21 +33,21 @@ type Cmd struct {
 	tmpDir       string
 	tmpBuiltFile string // file built and exec'd
 
-	//FixedTmpDir    bool // re-use tmp dir to allow caching
-	//FixedTmpDirPid int
-	//noTmpCleanup   bool
+	work *workCache // persistent tmp dir (nil if not used)
 
 	env       []string // set at start
 	annset    *AnnotatorSet
 	noModules bool // go.mod's
 
-	NoPreBuild bool // useful for tests
+	NoPreBuild  bool // useful for tests
+	NoWorkCache bool // new tmp dir on each run (useful for tests)
 
 	start struct {
 		network   string
 		address   string
 		cancel    context.CancelFunc
 		serverCmd *osutil.Cmd // the annotated program
+		trace     *traceRecorder
 	}
 
 	flags struct {
@@ -55,6 +56,8 @@ type Cmd struct {
 			test    bool
 			build   bool
 			connect bool
+			replay  bool
+			clean   bool
 		}
 		verbose     bool
 		filenames   []string
@@ -68,6 +71,8 @@ type Cmd struct {
 		syncSend    bool
 		otherArgs   []string
 		testRunArgs []string
+		trace       string // filename to record the received msgs
+		typed       bool   // type check the program to annotate
 	}
 }
 
@@ -78,9 +83,6 @@ func NewCmd() *Cmd {
 		Stderr: os.Stderr,
 	}
 
-	//cmd.FixedTmpDir = true
-	//cmd.FixedTmpDirPid = 2
-
 	return cmd
 }
 
@@ -107,11 +109,20 @@ func (cmd *Cmd) Start(ctx context.Context, args []string) (done bool, _ error) {
 	if err := cmd.start2(ctx); err != nil {
 		return true, err
 	}
-	done = cmd.flags.mode.build // just building, wait() should not be called
+	// just building/cleaning, wait() should not be called
+	done = cmd.flags.mode.build || cmd.flags.mode.clean
 	return done, nil
 }
 
 func (cmd *Cmd) start2(ctx context.Context) error {
+	// no build/server, msgs come from the trace file
+	if cmd.flags.mode.replay {
+		return cmd.startReplay(ctx)
+	}
+	if cmd.flags.mode.clean {
+		return cmd.cleanWorkCache()
+	}
+
 	cmd.noModules = cmd.detectNoModules()
 	if cmd.flags.verbose {
 		cmd.Printf("nomodules=%v\n", cmd.noModules)
@@ -162,6 +173,7 @@ func (cmd *Cmd) initAndAnnotate(ctx context.Context) error {
 	// "files" not in cmd.* to allow early GC
 	files := NewFiles(cmd.annset.FSet, cmd.noModules, cmd.Stderr)
 	files.Dir = cmd.Dir
+	files.Typed = cmd.flags.typed
 
 	files.Add(cmd.flags.files...)
 	files.Add(cmd.flags.dirs...)
@@ -245,13 +257,13 @@ func (cmd *Cmd) initAndAnnotate2(ctx context.Context, files *Files) error {
 	// copy
 	for filename := range files.copyFilenames {
 		dst := cmd.tmpDirBasedFilename(filename)
-		if err := mkdirAllCopyFileSync(filename, dst); err != nil {
+		if err := cmd.copyWorkFile(filename, dst); err != nil {
 			return err
 		}
 	}
 	for filename := range files.modFilenames {
 		dst := cmd.tmpDirBasedFilename(filename)
-		if err := mkdirAllCopyFileSync(filename, dst); err != nil {
+		if err := cmd.copyWorkFile(filename, dst); err != nil {
 			return err
 		}
 	}
@@ -284,6 +296,13 @@ func (cmd *Cmd) initAndAnnotate2(ctx context.Context, files *Files) error {
 		}
 	}
 
+	// files from previous runs that could break the build
+	if cmd.work != nil {
+		if err := cmd.work.removeStale(); err != nil {
+			return err
+		}
+	}
+
 	return cmd.doBuild(ctx)
 }
 
@@ -382,8 +401,19 @@ func (cmd *Cmd) startServerClient2(ctx context.Context) error {
 		cmd.start.serverCmd = c
 	}
 
+	// record msgs
+	var trace io.Writer
+	if cmd.flags.trace != "" {
+		tr, err := newTraceRecorder(cmd.absFilename(cmd.flags.trace))
+		if err != nil {
+			return err
+		}
+		cmd.start.trace = tr
+		trace = tr
+	}
+
 	// start client (blocks until connected)
-	client, err := NewClient(ctx, cmd.start.network, cmd.start.address)
+	client, err := NewClient(ctx, cmd.start.network, cmd.start.address, trace)
 	if err != nil {
 		return err
 	}
@@ -391,6 +421,17 @@ func (cmd *Cmd) startServerClient2(ctx context.Context) error {
 	return nil
 }
 
+func (cmd *Cmd) startReplay(ctx context.Context) error {
+	f, err := os.Open(cmd.absFilename(cmd.flags.trace))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	cmd.start.cancel = cancel
+	cmd.Client = NewReplayClient(ctx, f)
+	return nil
+}
+
 func (cmd *Cmd) Wait() error {
 	defer cmd.start.cancel() // ensure resources are cleared
 	var err error
@@ -400,23 +441,53 @@ func (cmd *Cmd) Wait() error {
 	if cmd.Client != nil { // might be nil if server failed to start
 		cmd.Client.Wait()
 	}
+	if cmd.start.trace != nil {
+		if err2 := cmd.start.trace.Close(); err2 != nil {
+			cmd.Printf("trace err: %v\n", err2)
+		} else {
+			cmd.Printf("trace: %v\n", cmd.start.trace.f.Name())
+		}
+		cmd.start.trace = nil
+	}
 	return err
 }
 
 //------------
 
 func (cmd *Cmd) RequestFileSetPositions() error {
-	msg := &debug.ReqFilesDataMsg{}
-	encoded, err := debug.EncodeMessage(msg)
-	if err != nil {
-		return err
-	}
-	_, err = cmd.Client.Conn.Write(encoded)
-	return err
+	return cmd.sendMsgToServer(&debug.ReqFilesDataMsg{})
 }
 
 func (cmd *Cmd) RequestStart() error {
-	msg := &debug.ReqStartMsg{}
+	return cmd.sendMsgToServer(&debug.ReqStartMsg{})
+}
+
+func (cmd *Cmd) SetBreakpoints(bps []*debug.Breakpoint) error {
+	return cmd.sendMsgToServer(&debug.SetBreakpointsMsg{Breakpoints: bps})
+}
+
+// Continues the paused goroutine (all if goid is zero). Step pauses the goroutine again at the next annotation.
+func (cmd *Cmd) Continue(goid int64, step bool) error {
+	return cmd.sendMsgToServer(&debug.ContinueMsg{Goid: goid, Step: step})
+}
+
+// The reply (debug.ValueMsg) arrives with the other server msgs.
+func (cmd *Cmd) RequestValue(req *debug.ReqValueMsg) error {
+	if cmd.flags.mode.replay {
+		return fmt.Errorf("values can't be inspected in replay mode")
+	}
+	return cmd.sendMsgToServer(req)
+}
+
+func (cmd *Cmd) IsReplay() bool {
+	return cmd.flags.mode.replay
+}
+
+func (cmd *Cmd) sendMsgToServer(msg interface{}) error {
+	// replay mode has no server
+	if cmd.flags.mode.replay {
+		return nil
+	}
 	encoded, err := debug.EncodeMessage(msg)
 	if err != nil {
 		return err
@@ -427,6 +498,13 @@ func (cmd *Cmd) RequestStart() error {
 
 //------------
 
+func (cmd *Cmd) absFilename(filename string) string {
+	if !filepath.IsAbs(filename) {
+		return filepath.Join(cmd.Dir, filename)
+	}
+	return filename
+}
+
 func (cmd *Cmd) tmpDirBasedFilename(filename string) string {
 	// remove volume name
 	v := filepath.VolumeName(filename)
@@ -522,10 +600,11 @@ func (cmd *Cmd) Cleanup() {
 		}
 	}
 
-	if cmd.flags.work {
+	if cmd.work != nil {
+		// don't cleanup work dir
+		cmd.work.unlock()
+	} else if cmd.flags.work {
 		// don't cleanup work dir
-		//} else if cmd.noTmpCleanup {
-		//	// don't cleanup work dir
 	} else if cmd.tmpDir != "" {
 		if err := os.RemoveAll(cmd.tmpDir); err != nil {
 			cmd.Printf("cleanup err: %v\n", err)
@@ -612,10 +691,22 @@ func (cmd *Cmd) startCmd(ctx context.Context, dir string, args, env []string, cb
 //------------
 
 func (cmd *Cmd) annotateFiles(ctx context.Context, files *Files) error {
+	// sorted to have the same file indexes on each run (work cache keys)
+	filenames := []string{}
+	for filename := range files.annFilenames {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		if _, err := cmd.annset.annotatorFileData(filename, files); err != nil {
+			return err
+		}
+	}
+
 	var wg sync.WaitGroup
 	var err1 error
 	flow := make(chan struct{}, 5) // max concurrent
-	for filename := range files.annFilenames {
+	for _, filename := range filenames {
 		// early stop
 		if err := ctx.Err(); err != nil {
 			return err
@@ -645,6 +736,12 @@ func (cmd *Cmd) annotateFile(ctx context.Context, files *Files, filename string)
 	if err != nil {
 		return err
 	}
+
+	// types info can depend on other files, always annotate
+	if cmd.work != nil && !files.Typed {
+		return cmd.annotateFileWithWorkCache(ctx, files, filename, astFile, dst)
+	}
+
 	if err := cmd.annset.AnnotateAstFile(astFile, filename, files); err != nil {
 		return err
 	}
@@ -660,6 +757,41 @@ func (cmd *Cmd) annotateFile(ctx context.Context, files *Files, filename string)
 	return nil
 }
 
+func (cmd *Cmd) annotateFileWithWorkCache(ctx context.Context, files *Files, filename string, astFile *ast.File, dst string) error {
+	afd, err := cmd.annset.annotatorFileData(filename, files)
+	if err != nil {
+		return err
+	}
+	key := cmd.work.annKey(afd.FileHash, afd.FileIndex, files.annTypes[filename], cmd.annset.debugPkgName)
+
+	// annotated in a previous run
+	if e, ok := cmd.work.getAnnEntry(key); ok {
+		if err := cmd.annset.SetAnnotatedFileInfo(astFile, filename, files, e.Info); err != nil {
+			return err
+		}
+		return cmd.work.writeFile(dst, e.Src)
+	}
+
+	info, err := cmd.annset.AnnotateAstFile2(astFile, filename, files)
+	if err != nil {
+		return err
+	}
+
+	// early stop
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	src, err := cmd.astFileBytes(astFile)
+	if err != nil {
+		return err
+	}
+	if err := cmd.work.setAnnEntry(key, &workCacheEntry{Info: info, Src: src}); err != nil {
+		return err
+	}
+	return cmd.work.writeFile(dst, src)
+}
+
 //------------
 
 func (cmd *Cmd) setupTmpDir() (string, error) {
@@ -667,29 +799,84 @@ func (cmd *Cmd) setupTmpDir() (string, error) {
 	if cmd.noModules {
 		d = "editor_godebug_gopath_work"
 	}
-	//if cmd.FixedTmpDir {
-	//	// use a fixed directory to allow "go build" to use the cache
-	//	// there is only one godebug session per editor, so ok to use pid
-	//	cmd.noTmpCleanup = true
-	//	pid := os.Getpid()
-	//	if cmd.FixedTmpDirPid != 0 {
-	//		pid = cmd.FixedTmpDirPid
-	//	}
-	//	d += fmt.Sprintf("_pid%v", pid)
-	//	tmpDir := filepath.Join(os.TempDir(), d)
-	//	return tmpDir, nil
-	//}
+
+	// use a fixed directory (per working dir) to allow "go build" to use the cache
+	m := &cmd.flags.mode
+	if !cmd.NoWorkCache && (m.run || m.test || m.build) {
+		h := sourceHash([]byte(cmd.Dir))
+		d2 := d + fmt.Sprintf("_%x", h[:8])
+		// concurrent sessions in the same dir (ex: "run" and "test") use other work dirs, or a tmp dir if all are in use
+		for i := 0; i < 4; i++ {
+			d3 := d2
+			if i > 0 {
+				d3 += fmt.Sprintf("_%d", i)
+			}
+			wc := newWorkCache(filepath.Join(workCacheRootDir(), d3))
+			if err := wc.lock(); err != nil {
+				if cmd.flags.verbose {
+					cmd.Printf("%v\n", err)
+				}
+				continue
+			}
+			cmd.work = wc
+			return cmd.work.dir, nil
+		}
+	}
+
 	return ioutil.TempDir(os.TempDir(), d)
 }
 
+func (cmd *Cmd) cleanWorkCache() error {
+	dir := workCacheRootDir()
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	cmd.Printf("clean: %v\n", dir)
+	return nil
+}
+
 //------------
 
 func (cmd *Cmd) mkdirAllWriteAstFile(filename string, astFile *ast.File) error {
+	src, err := cmd.astFileBytes(astFile)
+	if err != nil {
+		return err
+	}
+	return cmd.writeWorkFile(filename, src)
+}
+
+func (cmd *Cmd) astFileBytes(astFile *ast.File) ([]byte, error) {
 	buf := &bytes.Buffer{}
 	if err := goutil.PrintAstFile(buf, cmd.annset.FSet, astFile); err != nil {
-		return err
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Files written to the tmp dir are kept by the work cache (if used).
+func (cmd *Cmd) writeWorkFile(filename string, src []byte) error {
+	if cmd.work != nil {
+		return cmd.work.writeFile(filename, src)
+	}
+	return mkdirAllWriteFile(filename, src)
+}
+
+func (cmd *Cmd) copyWorkFile(src, dst string) error {
+	if cmd.work != nil {
+		return cmd.work.copyFile(src, dst)
+	}
+	return mkdirAllCopyFileSync(src, dst)
+}
+
+// The work cache can have the go.mod from a previous run ("go mod init" fails if it exists).
+func (cmd *Cmd) goModInit(ctx context.Context, dir, modPath string) error {
+	if cmd.work != nil {
+		filename := filepath.Join(dir, "go.mod")
+		if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+			return err
+		}
 	}
-	return mkdirAllWriteFile(filename, buf.Bytes())
+	return goutil.GoModInit(ctx, dir, modPath, cmd.env)
 }
 
 //------------
@@ -699,14 +886,14 @@ func (cmd *Cmd) writeGoDebugConfigFilesToTmpDir(ctx context.Context, files *File
 	filename := files.GodebugconfigPkgFilename("config.go")
 	src := cmd.annset.ConfigContent(cmd.start.network, cmd.start.address)
 	filenameAtTmp := cmd.tmpDirBasedFilename(filename)
-	if err := mkdirAllWriteFile(filenameAtTmp, []byte(src)); err != nil {
+	if err := cmd.writeWorkFile(filenameAtTmp, []byte(src)); err != nil {
 		return err
 	}
 	// godebugconfig pkg: go.mod
 	if !cmd.noModules {
 		dir := files.GodebugconfigPkgFilename("")
 		dirAtTmp := cmd.tmpDirBasedFilename(dir)
-		if err := goutil.GoModInit(ctx, dirAtTmp, GodebugconfigPkgPath, cmd.env); err != nil {
+		if err := cmd.goModInit(ctx, dirAtTmp, GodebugconfigPkgPath); err != nil {
 			return err
 		}
 	}
@@ -714,7 +901,7 @@ func (cmd *Cmd) writeGoDebugConfigFilesToTmpDir(ctx context.Context, files *File
 	for _, fp := range DebugFilePacks() {
 		filename := files.DebugPkgFilename(fp.Name)
 		filenameAtTmp := cmd.tmpDirBasedFilename(filename)
-		if err := mkdirAllWriteFile(filenameAtTmp, []byte(fp.Data)); err != nil {
+		if err := cmd.writeWorkFile(filenameAtTmp, []byte(fp.Data)); err != nil {
 			return err
 		}
 	}
@@ -722,7 +909,7 @@ func (cmd *Cmd) writeGoDebugConfigFilesToTmpDir(ctx context.Context, files *File
 	if !cmd.noModules {
 		dir := files.DebugPkgFilename("")
 		dirAtTmp := cmd.tmpDirBasedFilename(dir)
-		if err := goutil.GoModInit(ctx, dirAtTmp, DebugPkgPath, cmd.env); err != nil {
+		if err := cmd.goModInit(ctx, dirAtTmp, DebugPkgPath); err != nil {
 			return err
 		}
 	}
@@ -735,7 +922,7 @@ func (cmd *Cmd) writeTestMainFilesToTmpDir() error {
 		name := fmt.Sprintf("godebug_testmain%v_test.go", i)
 		filename := filepath.Join(tms.Dir, name)
 		filenameAtTmp := cmd.tmpDirBasedFilename(filename)
-		return mkdirAllWriteFile(filenameAtTmp, []byte(tms.Src))
+		return cmd.writeWorkFile(filenameAtTmp, []byte(tms.Src))
 	}
 	return nil
 }
@@ -758,6 +945,12 @@ func (cmd *Cmd) parseArgs(args []string) error {
 		case "connect":
 			cmd.flags.mode.connect = true
 			return cmd.parseConnectArgs(name, args[1:])
+		case "replay":
+			cmd.flags.mode.replay = true
+			return cmd.parseReplayArgs(name, args[1:])
+		case "clean":
+			cmd.flags.mode.clean = true
+			return cmd.parseCleanArgs(name, args[1:])
 		}
 	}
 	fmt.Fprint(cmd.Stderr, cmdUsage())
@@ -770,10 +963,12 @@ func (cmd *Cmd) parseRunArgs(name string, args []string) error {
 	cmd.dirsFlag(f)
 	cmd.filesFlag(f)
 	cmd.workFlag(f)
+	cmd.typedFlag(f)
 	cmd.verboseFlag(f)
 	cmd.toolExecFlag(f)
 	cmd.syncSendFlag(f)
 	cmd.envFlag(f)
+	cmd.traceFlag(f)
 
 	if err := f.Parse(args); err != nil {
 		return err
@@ -790,10 +985,12 @@ func (cmd *Cmd) parseTestArgs(name string, args []string) error {
 	cmd.dirsFlag(f)
 	cmd.filesFlag(f)
 	cmd.workFlag(f)
+	cmd.typedFlag(f)
 	cmd.verboseFlag(f)
 	cmd.toolExecFlag(f)
 	cmd.syncSendFlag(f)
 	cmd.envFlag(f)
+	cmd.traceFlag(f)
 	run := f.String("run", "", "run test")
 	verboseTests := f.Bool("v", false, "verbose tests")
 
@@ -823,6 +1020,7 @@ func (cmd *Cmd) parseBuildArgs(name string, args []string) error {
 	cmd.dirsFlag(f)
 	cmd.filesFlag(f)
 	cmd.workFlag(f)
+	cmd.typedFlag(f)
 	cmd.verboseFlag(f)
 	cmd.syncSendFlag(f)
 	cmd.envFlag(f)
@@ -844,6 +1042,7 @@ func (cmd *Cmd) parseConnectArgs(name string, args []string) error {
 	f.SetOutput(cmd.Stderr)
 	addr := f.String("addr", "", "address to connect to, built into the binary")
 	cmd.toolExecFlag(f)
+	cmd.traceFlag(f)
 
 	if err := f.Parse(args); err != nil {
 		return err
@@ -854,6 +1053,28 @@ func (cmd *Cmd) parseConnectArgs(name string, args []string) error {
 	return nil
 }
 
+func (cmd *Cmd) parseReplayArgs(name string, args []string) error {
+	f := flag.NewFlagSet(name, flag.ContinueOnError)
+	f.SetOutput(cmd.Stderr)
+
+	if err := f.Parse(args); err != nil {
+		return err
+	}
+
+	if f.NArg() != 1 {
+		return fmt.Errorf("expecting one trace filename")
+	}
+	cmd.flags.trace = f.Arg(0)
+
+	return nil
+}
+
+func (cmd *Cmd) parseCleanArgs(name string, args []string) error {
+	f := flag.NewFlagSet(name, flag.ContinueOnError)
+	f.SetOutput(cmd.Stderr)
+	return f.Parse(args)
+}
+
 //------------
 
 func (cmd *Cmd) filenamesAndOtherArgs(fs *flag.FlagSet) {
@@ -889,6 +1110,12 @@ func (cmd *Cmd) syncSendFlag(fs *flag.FlagSet) {
 func (cmd *Cmd) toolExecFlag(fs *flag.FlagSet) {
 	fs.StringVar(&cmd.flags.toolExec, "toolexec", "", "execute cmd, useful to run a tool with the output file (ex: wine outputfilename)")
 }
+func (cmd *Cmd) typedFlag(fs *flag.FlagSet) {
+	fs.BoolVar(&cmd.flags.typed, "typed", false, "type check the program to annotate (slower, solves multi-value calls args and big constants)")
+}
+func (cmd *Cmd) traceFlag(fs *flag.FlagSet) {
+	fs.StringVar(&cmd.flags.trace, "trace", "", "record the debug msgs to a trace `file` (see replay command)")
+}
 func (cmd *Cmd) dirsFlag(fs *flag.FlagSet) {
 	fn := func(s string) error {
 		cmd.flags.dirs = splitCommaList(s)
@@ -972,6 +1199,8 @@ The commands are:
 	test		test packages compiled with godebug data
 	build 	build binary with godebug data (allows remote debug)
 	connect	connect to a binary built with godebug data (allows remote debug)
+	replay	load a trace file recorded with the -trace flag
+	clean	remove the work dirs kept to speed up the builds
 Env variables:
 	GODEBUG_BUILD_FLAGS	comma separated flags for build
 Examples:
@@ -984,6 +1213,9 @@ Examples:
 	GoDebug test -run mytest
 	GoDebug build -addr=:8008 main.go
 	GoDebug connect -addr=:8008
+	GoDebug run -trace=trace1.gdt main.go
+	GoDebug replay trace1.gdt
+	GoDebug run -typed main.go
 	GoDebug run -env=GODEBUG_BUILD_FLAGS=-tags=xproto main.go
 `
 }
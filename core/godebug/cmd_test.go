@@ -1455,8 +1455,7 @@ func doCmd3(ctx context.Context, t *testing.T, dir string, args []string) ([]str
 
 	cmd.Dir = dir
 	cmd.NoPreBuild = true
-	//cmd.FixedTmpDir = true
-	//cmd.FixedTmpDirPid = 1
+	cmd.NoWorkCache = true
 
 	// log and get output (pid, build, work dir, warnings...)
 	obuf := &bytes.Buffer{}
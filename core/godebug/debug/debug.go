@@ -1,13 +1,18 @@
 package debug
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"runtime"
+	"strconv"
 	"sync"
+	"time"
 )
 
 var server *Server
 var startServerMu sync.Mutex
+var startTime = time.Now()
 
 // Called by the generated config.
 func StartServer() {
@@ -46,7 +51,34 @@ func ExitServer() {
 
 // Auto-inserted at annotations. Not to be used.
 func Line(fileIndex, debugIndex, offset int, item Item) {
-	hotStartServer()
+	sendLineMsg(newLineMsg(fileIndex, debugIndex, offset, item))
+}
+
+func newLineMsg(fileIndex, debugIndex, offset int, item Item) *LineMsg {
 	lmsg := &LineMsg{FileIndex: fileIndex, DebugIndex: debugIndex, Offset: offset, Item: item}
+	lmsg.Goid = goid()
+	lmsg.Time = time.Since(startTime)
+	return lmsg
+}
+
+func sendLineMsg(lmsg *LineMsg) {
+	hotStartServer()
 	server.Send(lmsg)
 }
+
+//----------
+
+// Current goroutine id, parsed from the stack header ("goroutine 123 [running]:"). Returns 0 if not found.
+func goid() int64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
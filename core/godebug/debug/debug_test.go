@@ -0,0 +1,15 @@
+package debug
+
+import "testing"
+
+func TestGoid(t *testing.T) {
+	id := goid()
+	if id <= 0 {
+		t.Fatalf("bad goid: %v", id)
+	}
+	c := make(chan int64)
+	go func() { c <- goid() }()
+	if id2 := <-c; id2 <= 0 || id2 == id {
+		t.Fatalf("bad goid: %v, %v", id, id2)
+	}
+}
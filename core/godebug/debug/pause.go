@@ -0,0 +1,203 @@
+package debug
+
+import (
+	"regexp"
+	"sync"
+	"sync/atomic"
+)
+
+// Pauses the goroutines that reach a breakpoint (or are stepping) until the client sends a continue msg.
+type pauser struct {
+	active int32 // atomic: fast check for having breakpoints or steps
+
+	mu     sync.Mutex
+	bps    map[bpKey][]*breakpoint
+	steps  map[int64]bool      // [goid] pause at the next line msg
+	paused map[int64]chan bool // [goid] continue (true: step)
+}
+
+type bpKey struct {
+	fileIndex int
+	offset    int
+}
+
+type breakpoint struct {
+	cond *regexp.Regexp // can be nil
+}
+
+func (p *pauser) init() {
+	p.bps = map[bpKey][]*breakpoint{}
+	p.steps = map[int64]bool{}
+	p.paused = map[int64]chan bool{}
+}
+
+//----------
+
+func (p *pauser) setBreakpoints(u []*Breakpoint) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.bps = map[bpKey][]*breakpoint{}
+	for _, b := range u {
+		bp := &breakpoint{}
+		if b.Cond != "" {
+			re, err := regexp.Compile(b.Cond)
+			if err != nil {
+				logger.Printf("breakpoint cond: %v", err)
+				continue
+			}
+			bp.cond = re
+		}
+		k := bpKey{b.FileIndex, b.Offset}
+		p.bps[k] = append(p.bps[k], bp)
+	}
+	p.updateActive()
+}
+
+func (p *pauser) updateActive() {
+	v := int32(0)
+	if len(p.bps) > 0 || len(p.steps) > 0 {
+		v = 1
+	}
+	atomic.StoreInt32(&p.active, v)
+}
+
+//----------
+
+// Blocks the calling goroutine (the one that sent the line msg) if it should pause. The sendPaused func is called before blocking. Returns true if it paused.
+func (p *pauser) pauseIfNeeded(lmsg *LineMsg, sendPaused func()) bool {
+	if atomic.LoadInt32(&p.active) == 0 {
+		return false
+	}
+
+	p.mu.Lock()
+	if !p.shouldPause(lmsg) {
+		p.mu.Unlock()
+		return false
+	}
+	delete(p.steps, lmsg.Goid)
+	p.updateActive()
+	ch := make(chan bool, 1)
+	p.paused[lmsg.Goid] = ch
+	p.mu.Unlock()
+
+	sendPaused()
+
+	if step := <-ch; step {
+		p.mu.Lock()
+		p.steps[lmsg.Goid] = true
+		p.updateActive()
+		p.mu.Unlock()
+	}
+	return true
+}
+
+// Not locked
+func (p *pauser) shouldPause(lmsg *LineMsg) bool {
+	if p.steps[lmsg.Goid] {
+		return true
+	}
+	for _, bp := range p.bps[bpKey{lmsg.FileIndex, lmsg.Offset}] {
+		if bp.cond == nil || itemValuesMatch(lmsg.Item, bp.cond) {
+			return true
+		}
+	}
+	return false
+}
+
+// Continues the paused goroutine (all if goid is zero). The cb func (can be nil) is called before each goroutine continues.
+func (p *pauser) cont(goid int64, step bool, cb func(int64)) {
+	p.mu.Lock()
+	chs := map[int64]chan bool{}
+	for id, ch := range p.paused {
+		if goid == 0 || id == goid {
+			chs[id] = ch
+			delete(p.paused, id)
+		}
+	}
+	p.mu.Unlock()
+
+	// not locked: cb could block (ex: sending a msg)
+	for id, ch := range chs {
+		if cb != nil {
+			cb(id)
+		}
+		ch <- step
+	}
+}
+
+// Clears the breakpoints and continues all goroutines (ex: client disconnected).
+func (p *pauser) reset() {
+	p.mu.Lock()
+	p.bps = map[bpKey][]*breakpoint{}
+	p.steps = map[int64]bool{}
+	p.updateActive()
+	p.mu.Unlock()
+	p.cont(0, false, nil)
+}
+
+//----------
+
+// Matches the regexp against the values of the item.
+func itemValuesMatch(item Item, re *regexp.Regexp) bool {
+	match := false
+	walkItemValues(item, func(iv *ItemValue) bool {
+		match = re.MatchString(iv.Str)
+		return !match
+	})
+	return match
+}
+
+// Calls fn for each value of the item until it returns false.
+func walkItemValues(item Item, fn func(*ItemValue) bool) bool {
+	w := func(u ...Item) bool {
+		for _, v := range u {
+			if !walkItemValues(v, fn) {
+				return false
+			}
+		}
+		return true
+	}
+	wl := func(l *ItemList) bool {
+		if l == nil {
+			return true
+		}
+		return w(l.List...)
+	}
+	switch t := item.(type) {
+	case *ItemValue:
+		return fn(t)
+	case *ItemList:
+		return wl(t)
+	case *ItemList2:
+		return w(t.List...)
+	case *ItemAssign:
+		return wl(t.Lhs) && wl(t.Rhs)
+	case *ItemSend:
+		return w(t.Chan, t.Value)
+	case *ItemCall:
+		return wl(t.Args) && w(t.Result)
+	case *ItemCallEnter:
+		return wl(t.Args)
+	case *ItemIndex:
+		return w(t.Result, t.Expr, t.Index)
+	case *ItemIndex2:
+		return w(t.Result, t.Expr, t.Low, t.High, t.Max)
+	case *ItemKeyValue:
+		return w(t.Key, t.Value)
+	case *ItemSelector:
+		return w(t.X, t.Sel)
+	case *ItemTypeAssert:
+		return w(t.X, t.Type)
+	case *ItemBinary:
+		return w(t.Result, t.X, t.Y)
+	case *ItemUnary:
+		return w(t.Result, t.X)
+	case *ItemUnaryEnter:
+		return w(t.X)
+	case *ItemParen:
+		return w(t.X)
+	case *ItemLiteral:
+		return wl(t.Fields)
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package debug
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauserBreakpoint(t *testing.T) {
+	p := &pauser{}
+	p.init()
+	p.setBreakpoints([]*Breakpoint{
+		{FileIndex: 1, Offset: 10},
+		{FileIndex: 1, Offset: 20, Cond: "^ab"},
+	})
+
+	paused := make(chan int64, 4)
+	done := make(chan bool, 4)
+	run := func(lmsg *LineMsg) {
+		go func() {
+			p.pauseIfNeeded(lmsg, func() { paused <- lmsg.Goid })
+			done <- true
+		}()
+	}
+	noPause := func(lmsg *LineMsg) {
+		t.Helper()
+		run(lmsg)
+		select {
+		case <-done:
+		case <-paused:
+			t.Fatalf("paused: %+v", lmsg)
+		}
+	}
+
+	noPause(&LineMsg{FileIndex: 1, Offset: 11, Goid: 1})
+	noPause(&LineMsg{FileIndex: 1, Offset: 20, Goid: 1, Item: IVs("cab")})
+
+	run(&LineMsg{FileIndex: 1, Offset: 20, Goid: 2, Item: IA(IL(IVs("a")), IL(IVs("abc")))})
+	if id := <-paused; id != 2 {
+		t.Fatal(id)
+	}
+	select {
+	case <-done:
+		t.Fatal("not paused")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// step: pauses at the next line msg of the same goroutine
+	p.cont(2, true, nil)
+	<-done
+	noPause(&LineMsg{FileIndex: 1, Offset: 30, Goid: 3})
+	run(&LineMsg{FileIndex: 1, Offset: 30, Goid: 2})
+	if id := <-paused; id != 2 {
+		t.Fatal(id)
+	}
+
+	// reset continues all
+	p.reset()
+	<-done
+	noPause(&LineMsg{FileIndex: 1, Offset: 10, Goid: 2})
+}
+
+func TestPauserContCallbackNotLocked(t *testing.T) {
+	p := &pauser{}
+	p.init()
+	p.setBreakpoints([]*Breakpoint{{FileIndex: 1, Offset: 10}})
+
+	paused := make(chan bool, 1)
+	done := make(chan bool, 1)
+	go func() {
+		p.pauseIfNeeded(&LineMsg{FileIndex: 1, Offset: 10, Goid: 1}, func() { paused <- true })
+		done <- true
+	}()
+	<-paused
+
+	// the callback can use the pauser (would deadlock if called with the lock held)
+	p.cont(0, false, func(int64) { p.setBreakpoints(nil) })
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("not continued")
+	}
+}
@@ -0,0 +1,92 @@
+package debug
+
+import "sync"
+
+// Counters of the "annotateevery" debug lines.
+var samples struct {
+	sync.Mutex
+	m map[sampleKey]int
+}
+
+type sampleKey struct {
+	fileIndex   int
+	sampleIndex int
+}
+
+// Auto-inserted at annotations. Not to be used.
+// Returns true every nth call of the annotated line (starting with the first).
+func Sample(n, fileIndex, sampleIndex int) bool {
+	samples.Lock()
+	defer samples.Unlock()
+	if samples.m == nil {
+		samples.m = map[sampleKey]int{}
+	}
+	k := sampleKey{fileIndex, sampleIndex}
+	c := samples.m[k]
+	samples.m[k] = c + 1
+	return c%n == 0
+}
+
+//----------
+
+// Auto-inserted at annotations ("annotatefirst", "annotatelast"). Not to be used.
+// Sends only the line msgs of the first/last iterations of a loop. The msgs of the last iterations are kept until the loop is done.
+type Loop struct {
+	mu          sync.Mutex
+	first, last int
+	iter        int          // current iteration, -1 before the first
+	kept        [][]*LineMsg // msgs of the last iterations
+	done        bool
+}
+
+func NewLoop(first, last int) *Loop {
+	return &Loop{first: first, last: last, iter: -1}
+}
+
+// Called at the start of each iteration.
+func (l *Loop) Iter() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.iter++
+	if l.last > 0 && l.iter >= l.first {
+		if len(l.kept) == l.last {
+			copy(l.kept, l.kept[1:])
+			l.kept = l.kept[:len(l.kept)-1]
+		}
+		l.kept = append(l.kept, nil)
+	}
+}
+
+func (l *Loop) Line(fileIndex, debugIndex, offset int, item Item) {
+	l.mu.Lock()
+	if !l.done && l.iter >= l.first { // (iter<0 is before the loop)
+		if l.last > 0 {
+			k := len(l.kept) - 1
+			lmsg := newLineMsg(fileIndex, debugIndex, offset, item)
+			l.kept[k] = append(l.kept[k], lmsg)
+		}
+		l.mu.Unlock()
+		return
+	}
+	l.mu.Unlock()
+	Line(fileIndex, debugIndex, offset, item)
+}
+
+// Sends the kept msgs. Msgs of later lines are sent directly (ex: closures running after the loop).
+func (l *Loop) Done() {
+	l.mu.Lock()
+	if l.done {
+		l.mu.Unlock()
+		return
+	}
+	l.done = true
+	kept := l.kept
+	l.kept = nil
+	l.mu.Unlock()
+
+	for _, u := range kept {
+		for _, lmsg := range u {
+			sendLineMsg(lmsg)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package debug
+
+import "testing"
+
+func TestSample(t *testing.T) {
+	// counters are global, start clean (ex: "-count=2")
+	samples.Lock()
+	samples.m = nil
+	samples.Unlock()
+
+	u := []bool{}
+	for i := 0; i < 7; i++ {
+		u = append(u, Sample(3, 100, 0))
+	}
+	w := []bool{true, false, false, true, false, false, true}
+	for i := range w {
+		if u[i] != w[i] {
+			t.Fatalf("%v: %v", i, u)
+		}
+	}
+	// other line has its own counter
+	if !Sample(3, 100, 1) {
+		t.Fatal("expecting first call sampled")
+	}
+}
+
+func TestLoopLast(t *testing.T) {
+	l := NewLoop(1, 2)
+	for i := 0; i < 5; i++ {
+		l.Iter()
+		if i == 0 {
+			continue // first iteration msgs would be sent directly
+		}
+		l.Line(0, 0, i, IVs("a"))
+		l.Line(0, 0, i, IVs("b"))
+	}
+	if len(l.kept) != 2 {
+		t.Fatal(len(l.kept))
+	}
+	for i, u := range l.kept {
+		if len(u) != 2 || u[0].Offset != 3+i {
+			t.Fatalf("%v: %+v", i, u)
+		}
+	}
+}
@@ -34,6 +34,7 @@ type Server struct {
 		cconn *CConn
 	}
 	sendReady sync.RWMutex
+	pause     pauser
 }
 
 func NewServer() (*Server, error) {
@@ -45,6 +46,7 @@ func NewServer() (*Server, error) {
 	}
 
 	srv := &Server{ln: ln}
+	srv.pause.init()
 	srv.sendReady.Lock() // not ready to send (no client yet)
 
 	// accept connections
@@ -74,6 +76,9 @@ func (srv *Server) Close() {
 	}
 	srv.client.Unlock()
 
+	// don't leave goroutines paused
+	srv.pause.reset()
+
 	logger.Println("server closed")
 }
 
@@ -103,6 +108,8 @@ func (srv *Server) acceptClientsLoop() {
 		srv.client.Lock()
 		if srv.client.cconn != nil {
 			srv.client.cconn.Close() // close previous connection
+			// breakpoints belong to the previous client
+			srv.pause.reset()
 		}
 		srv.client.cconn = NewCCon(srv, conn)
 		srv.client.Unlock()
@@ -112,6 +119,20 @@ func (srv *Server) acceptClientsLoop() {
 //----------
 
 func (srv *Server) Send(v *LineMsg) {
+	paused := srv.pause.pauseIfNeeded(v, func() {
+		// values can be inspected while the goroutine is paused
+		values.addPaused(v.Goid, v.Item)
+		srv.send(v)
+		srv.send(&PausedMsg{Goid: v.Goid, FileIndex: v.FileIndex, Offset: v.Offset})
+	})
+	if paused {
+		values.removePaused(v.Goid)
+	} else {
+		srv.send(v)
+	}
+}
+
+func (srv *Server) send(v interface{}) {
 	// locks if client is not ready to send
 	srv.sendReady.RLock()
 	defer srv.sendReady.RUnlock()
@@ -126,7 +147,8 @@ type CConn struct {
 	srv          *Server
 	conn         net.Conn
 	rwait, swait sync.WaitGroup
-	sendch       chan *LineMsg // sending loop channel
+	sendch       chan interface{} // sending loop channel
+	closing      chan struct{}    // unblocks senders on close
 	reqStart     struct {
 		sync.Mutex
 		start   chan struct{}
@@ -138,12 +160,13 @@ type CConn struct {
 func NewCCon(srv *Server, conn net.Conn) *CConn {
 	cconn := &CConn{srv: srv, conn: conn}
 	cconn.reqStart.start = make(chan struct{})
+	cconn.closing = make(chan struct{})
 
 	qsize := chunkSendQSize
 	if SyncSend {
 		qsize = 0
 	}
-	cconn.sendch = make(chan *LineMsg, qsize)
+	cconn.sendch = make(chan interface{}, qsize)
 
 	// receive messages
 	cconn.rwait.Add(1)
@@ -171,19 +194,25 @@ func (cconn *CConn) Close() {
 	cconn.reqStart.closed = true
 	cconn.reqStart.Unlock()
 
-	// close send msgs: can't close receive msgs first (closes client)
+	// stop receiving msgs without closing the conn (the send loop still needs it). The receive loop can call Send, so it must end before the send channel is closed.
+	close(cconn.closing)
+	_ = cconn.conn.SetReadDeadline(time.Now())
+	cconn.rwait.Wait()
+
+	// close send msgs
 	close(cconn.reqStart.start) // ok even if it didn't start
 	close(cconn.sendch)
 	cconn.swait.Wait()
 
-	// close receive msgs
 	_ = cconn.conn.Close()
-	cconn.rwait.Wait()
 }
 
 //----------
 
 func (cconn *CConn) receiveMsgsLoop() {
+	// client disconnected: don't leave goroutines paused
+	defer cconn.srv.pause.reset()
+
 	for {
 		msg, err := DecodeMessage(cconn.conn)
 		if err != nil {
@@ -220,6 +249,18 @@ func (cconn *CConn) receiveMsgsLoop() {
 				cconn.srv.sendReady.Unlock()
 			}
 			cconn.reqStart.Unlock()
+		case *SetBreakpointsMsg:
+			logger.Print("set breakpoints")
+			cconn.srv.pause.setBreakpoints(t.Breakpoints)
+		case *ContinueMsg:
+			logger.Print("continue")
+			cconn.srv.pause.cont(t.Goid, t.Step, func(goid int64) {
+				// sent before the goroutine continues to keep the msgs order
+				cconn.Send(&ContinuedMsg{Goid: goid})
+			})
+		case *ReqValueMsg:
+			logger.Print("req value")
+			cconn.Send(inspectValue(t))
 		default:
 			// always print if there is a new msg type
 			log.Printf("todo: unexpected msg type: %T", t)
@@ -274,7 +315,16 @@ loop1:
 			if !ok {
 				break loop1
 			}
-			msgs = append(msgs, v)
+			lmsg, ok := v.(*LineMsg)
+			if !ok {
+				// other msgs (ex: paused) are sent now, after the pending line msgs
+				sendMsgs()
+				if err := cconn.send2(v); err != nil {
+					log.Println(err)
+				}
+				continue
+			}
+			msgs = append(msgs, lmsg)
 			if len(msgs) >= chunkSendNowNMsgs {
 				sendMsgs()
 			} else if !scheduled {
@@ -311,6 +361,9 @@ func (cconn *CConn) send2(v interface{}) error {
 
 //----------
 
-func (cconn *CConn) Send(v *LineMsg) {
-	cconn.sendch <- v
+func (cconn *CConn) Send(v interface{}) {
+	select {
+	case cconn.sendch <- v:
+	case <-cconn.closing: // msg is dropped
+	}
 }
@@ -0,0 +1,54 @@
+package debug
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestServerClientDisconnectContinues(t *testing.T) {
+	srv := &Server{}
+	srv.pause.init()
+	srv.pause.setBreakpoints([]*Breakpoint{{FileIndex: 1, Offset: 10}})
+
+	paused := make(chan bool, 1)
+	done := make(chan bool, 1)
+	go func() {
+		lmsg := &LineMsg{FileIndex: 1, Offset: 10, Goid: 1}
+		srv.pause.pauseIfNeeded(lmsg, func() { paused <- true })
+		done <- true
+	}()
+	<-paused
+
+	c1, c2 := net.Pipe()
+	cconn := NewCCon(srv, c1)
+	_ = c2.Close() // client disconnects
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("paused goroutine not continued")
+	}
+
+	cconn.Close()
+}
+
+func TestServerCConnCloseWithReceiveLoop(t *testing.T) {
+	srv := &Server{}
+	srv.pause.init()
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	cconn := NewCCon(srv, c1)
+
+	// closes while the receive loop is reading
+	closed := make(chan bool)
+	go func() {
+		cconn.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("close blocked")
+	}
+}
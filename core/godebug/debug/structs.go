@@ -2,6 +2,7 @@ package debug
 
 import (
 	"fmt"
+	"time"
 )
 
 func init() {
@@ -14,6 +15,12 @@ func init() {
 	reg(&ReqStartMsg{})
 	reg(&LineMsg{})
 	reg([]*LineMsg{})
+	reg(&SetBreakpointsMsg{})
+	reg(&ContinueMsg{})
+	reg(&PausedMsg{})
+	reg(&ContinuedMsg{})
+	reg(&ReqValueMsg{})
+	reg(&ValueMsg{})
 
 	reg(&ItemValue{})
 	reg(&ItemList{})
@@ -43,6 +50,58 @@ func init() {
 type ReqFilesDataMsg struct{}
 type ReqStartMsg struct{}
 
+// Replaces the server breakpoints.
+type SetBreakpointsMsg struct {
+	Breakpoints []*Breakpoint
+}
+
+type Breakpoint struct {
+	FileIndex int
+	Offset    int    // annotation offset
+	Cond      string // regexp matched against the item values (optional)
+}
+
+// Continues the paused goroutine (all if goid is zero). Step pauses the goroutine again at the next line msg.
+type ContinueMsg struct {
+	Goid int64
+	Step bool
+}
+
+// Sent after the line msg that paused the goroutine.
+type PausedMsg struct {
+	Goid      int64
+	FileIndex int
+	Offset    int
+}
+
+type ContinuedMsg struct {
+	Goid int64
+}
+
+// Requests the tree of a registered value (ItemValue.Id).
+type ReqValueMsg struct {
+	Id      int // request id, returned in the reply
+	ValueId int
+	Path    []int // children indexes
+	Depth   int   // levels of children
+}
+
+type ValueMsg struct {
+	Id   int // request id
+	Node *ValueNode
+	Err  string
+}
+
+type ValueNode struct {
+	Name      string // field name, map key, or slice index
+	Type      string
+	Str       string // short value
+	Len       int    // -1 if not applicable
+	Kids      []*ValueNode
+	More      bool // has children that were not sent (depth reached)
+	Truncated bool // not all children were sent (max children)
+}
+
 //----------
 
 type LineMsg struct {
@@ -50,6 +109,8 @@ type LineMsg struct {
 	DebugIndex int
 	Offset     int
 	Item       Item
+	Goid       int64         // goroutine id
+	Time       time.Duration // since the program start (monotonic)
 }
 
 type FilesDataMsg struct {
@@ -62,6 +123,7 @@ type AnnotatorFileData struct {
 	Filename  string
 	FileSize  int
 	FileHash  []byte
+	Offsets   []int // debug stmts offsets, sorted (allows breakpoints on lines that didn't run yet)
 }
 
 //----------
@@ -70,6 +132,8 @@ type Item interface {
 }
 type ItemValue struct {
 	Str string
+	Id  int // inspectable value id (zero if not inspectable or not paused)
+	v   V   // not encoded, registered if the goroutine pauses
 }
 type ItemList struct { // separated by ","
 	List []Item
@@ -146,7 +210,7 @@ type V interface{}
 
 // ItemValue
 func IV(v V) Item {
-	return &ItemValue{Str: stringifyV(v)}
+	return &ItemValue{Str: stringifyV(v), v: v}
 }
 
 // ItemValue: raw string
@@ -0,0 +1,211 @@
+package debug
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Values of composite types (pointers, structs, maps, slices, ...) can be inspected as a tree by the client, but only while the goroutine that sent them is paused (reading values that the program is changing can crash it, ex: concurrent map read and write). The values are registered when the goroutine pauses and removed when it continues. Note that other goroutines (not paused) could still be changing the values.
+
+var values valueRegistry
+
+type valueRegistry struct {
+	mu    sync.Mutex
+	last  int                 // last id given (ids start at 1)
+	m     map[int]interface{} // [id]
+	goids map[int64][]int     // [goid] ids
+}
+
+// Sets the ids of the inspectable values of the item. Should be called when the goroutine is paused.
+func (vr *valueRegistry) addPaused(goid int64, item Item) {
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+	if vr.m == nil {
+		vr.m = map[int]interface{}{}
+		vr.goids = map[int64][]int{}
+	}
+	walkItemValues(item, func(iv *ItemValue) bool {
+		if iv.v != nil && inspectable(reflect.ValueOf(iv.v)) {
+			vr.last++
+			iv.Id = vr.last
+			vr.m[iv.Id] = iv.v
+			vr.goids[goid] = append(vr.goids[goid], iv.Id)
+		}
+		return true
+	})
+}
+
+// Should be called when the goroutine continues.
+func (vr *valueRegistry) removePaused(goid int64) {
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+	for _, id := range vr.goids[goid] {
+		delete(vr.m, id)
+	}
+	delete(vr.goids, goid)
+}
+
+func (vr *valueRegistry) get(id int) (interface{}, bool) {
+	vr.mu.Lock()
+	defer vr.mu.Unlock()
+	v, ok := vr.m[id]
+	return v, ok
+}
+
+//----------
+
+// Inspects the value with the id from the registry.
+func inspectValue(req *ReqValueMsg) *ValueMsg {
+	msg := &ValueMsg{Id: req.Id}
+	v, ok := values.get(req.ValueId)
+	if !ok {
+		msg.Err = "value not available (goroutine not paused)"
+		return msg
+	}
+	node, err := ValueTree(v, req.Path, req.Depth)
+	if err != nil {
+		msg.Err = err.Error()
+		return msg
+	}
+	msg.Node = node
+	return msg
+}
+
+// Builds the tree of the value found at the path (children indexes) with the given depth of children.
+func ValueTree(v interface{}, path []int, depth int) (_ *ValueNode, err error) {
+	// ex: nil map of a value changed by another goroutine (a concurrent map read and write is not recoverable)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	rv := reflect.ValueOf(v)
+	name := ""
+	for _, k := range path {
+		kids, _ := valueKids(rv)
+		if k < 0 || k >= len(kids) {
+			return nil, fmt.Errorf("bad path index: %v", k)
+		}
+		name, rv = kids[k].name, kids[k].v
+	}
+	return valueNode(name, rv, depth), nil
+}
+
+func valueNode(name string, v reflect.Value, depth int) *ValueNode {
+	node := &ValueNode{Name: name, Len: -1}
+	if !v.IsValid() {
+		node.Str = "nil"
+		return node
+	}
+	node.Type = v.Type().String()
+	node.Str = valueStr(v)
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String, reflect.Chan:
+		node.Len = v.Len()
+	}
+	if !inspectable(v) {
+		return node
+	}
+	if depth <= 0 {
+		node.More = true
+		return node
+	}
+	kids, truncated := valueKids(v)
+	for _, kid := range kids {
+		node.Kids = append(node.Kids, valueNode(kid.name, kid.v, depth-1))
+	}
+	node.Truncated = truncated
+	return node
+}
+
+//----------
+
+const valueMaxKids = 100
+
+type valueKid struct {
+	name string
+	v    reflect.Value
+}
+
+// Children of the value (pointers and interfaces are followed). Returns true if the children were truncated.
+func valueKids(v reflect.Value) ([]*valueKid, bool) {
+	v, ok := valueElem(v)
+	if !ok {
+		return nil, false
+	}
+	u := []*valueKid{}
+	switch v.Kind() {
+	case reflect.Struct:
+		vt := v.Type()
+		for i := 0; i < vt.NumField(); i++ {
+			u = append(u, &valueKid{vt.Field(i).Name, v.Field(i)})
+		}
+	case reflect.Map:
+		// sorted to have the same paths in later requests
+		for _, k := range v.MapKeys() {
+			u = append(u, &valueKid{valueStr(k), v.MapIndex(k)})
+		}
+		sort.Slice(u, func(a, b int) bool {
+			return u[a].name < u[b].name
+		})
+	case reflect.Slice, reflect.Array:
+		n := v.Len()
+		if n > valueMaxKids {
+			n = valueMaxKids
+		}
+		for i := 0; i < n; i++ {
+			u = append(u, &valueKid{"[" + strconv.Itoa(i) + "]", v.Index(i)})
+		}
+		return u, n < v.Len()
+	}
+	if len(u) > valueMaxKids {
+		return u[:valueMaxKids], true
+	}
+	return u, false
+}
+
+func inspectable(v reflect.Value) bool {
+	v, ok := valueElem(v)
+	if !ok {
+		return false
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return v.NumField() > 0
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return v.Len() > 0
+	}
+	return false
+}
+
+// Follows pointers and interfaces. Returns false if nil (or a cycle of pointers).
+func valueElem(v reflect.Value) (reflect.Value, bool) {
+	for i := 0; v.IsValid(); i++ {
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if v.IsNil() || i >= 10 {
+				return v, false
+			}
+			v = v.Elem()
+		default:
+			return v, true
+		}
+	}
+	return v, false
+}
+
+func valueStr(v reflect.Value) string {
+	p := NewPrint(80, 1)
+	if v.CanInterface() {
+		return string(p.Do(v.Interface()))
+	}
+	// unexported field: can't use the value methods (ex: String())
+	ctx := &Ctx{}
+	ctx = ctx.WithInInterface(0)
+	p.doValue(ctx, v, 0)
+	return string(p.Out)
+}
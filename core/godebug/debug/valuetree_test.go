@@ -0,0 +1,66 @@
+package debug
+
+import (
+	"testing"
+)
+
+func TestValueTree(t *testing.T) {
+	type St2 struct {
+		c []int
+	}
+	type St1 struct {
+		A int
+		b *St2
+		M map[string]int
+	}
+	v := &St1{A: 1, b: &St2{c: []int{5, 6}}, M: map[string]int{"y": 2, "x": 1}}
+
+	node, err := ValueTree(v, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(node.Kids) != 3 || node.Kids[0].Name != "A" || node.Kids[0].Str != "1" || !node.Kids[1].More {
+		t.Fatalf("%+v", node)
+	}
+
+	// b.c
+	node, err = ValueTree(v, []int{1, 0}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Name != "c" || node.Len != 2 || len(node.Kids) != 2 || node.Kids[1].Name != "[1]" || node.Kids[1].Str != "6" {
+		t.Fatalf("%+v", node)
+	}
+
+	// sorted map keys
+	node, err = ValueTree(v, []int{2}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Kids[0].Name != `"x"` || node.Kids[0].Str != "1" {
+		t.Fatalf("%+v", node.Kids[0])
+	}
+
+	if _, err := ValueTree(v, []int{3}, 1); err == nil {
+		t.Fatal("expecting error")
+	}
+}
+
+func TestValueRegistry(t *testing.T) {
+	vr := &valueRegistry{}
+	iv1 := IV(1).(*ItemValue)
+	iv2 := IV([]int{1}).(*ItemValue)
+	vr.addPaused(7, IL(iv1, IA(IL(iv2), IL(IVs("a")))))
+	if iv1.Id != 0 || iv2.Id != 1 {
+		t.Fatal(iv1.Id, iv2.Id)
+	}
+	if v, ok := vr.get(iv2.Id); !ok || v.([]int)[0] != 1 {
+		t.Fatal(v, ok)
+	}
+
+	// continued
+	vr.removePaused(7)
+	if _, ok := vr.get(iv2.Id); ok {
+		t.Fatal("expecting value not available")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io"
 	"io/ioutil"
 	"os"
@@ -36,7 +37,10 @@ type Files struct {
 	modMissings     map[string]struct{}       // go.mod's to be created
 	annTypes        map[string]AnnotationType // [filename]
 	annFileData     map[string]*AnnFileData   // [filename] hash/filesize
-	nodeAnnTypes    map[ast.Node]AnnotationType
+	nodeAnnOpts     map[ast.Node]*AnnotationOpt
+	typesInfo       map[string]*types.Info // [filename] typed mode
+
+	Typed bool // type check the program (types info for the annotator)
 
 	fset      *token.FileSet
 	noModules bool
@@ -59,7 +63,8 @@ func NewFiles(fset *token.FileSet, noModules bool, stderr io.Writer) *Files {
 	files.modMissings = map[string]struct{}{}
 	files.annTypes = map[string]AnnotationType{}
 	files.annFileData = map[string]*AnnFileData{}
-	files.nodeAnnTypes = map[ast.Node]AnnotationType{}
+	files.nodeAnnOpts = map[ast.Node]*AnnotationOpt{}
+	files.typesInfo = map[string]*types.Info{}
 	files.cache.fullAstFile = map[string]*ast.File{}
 	files.cache.srcs = map[string][]byte{}
 	return files
@@ -128,6 +133,7 @@ func (files *Files) Do(ctx context.Context, filenames []string, tests bool, env
 	}
 
 	files.populateProgFilenamesMap(pkgs)
+	files.populateTypesInfoMap(pkgs)
 	if err := files.addCommentedFiles(ctx); err != nil {
 		return err
 	}
@@ -185,6 +191,28 @@ func (files *Files) verboseMap(cmd *Cmd, m map[string]struct{}) {
 
 //----------
 
+func (files *Files) populateTypesInfoMap(pkgs []*packages.Package) {
+	if !files.Typed {
+		return
+	}
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		if pkg.TypesInfo == nil {
+			return
+		}
+		for _, astFile := range pkg.Syntax {
+			filename := files.fset.File(astFile.Package).Name()
+			if _, ok := files.typesInfo[filename]; !ok {
+				files.typesInfo[filename] = pkg.TypesInfo
+			}
+		}
+	})
+}
+
+func (files *Files) TypesInfo(filename string) (*types.Info, bool) {
+	info, ok := files.typesInfo[filename]
+	return info, ok
+}
+
 func (files *Files) populateProgFilenamesMap(pkgs []*packages.Package) {
 	// There is no "std" module for the std library, "replace" won't work
 	goRoot := os.Getenv("GOROOT")
@@ -268,16 +296,24 @@ func (files *Files) addCommentedFile2(filename string, astFile *ast.File) error
 	// map for the annotator to know nodes annotations
 	mn := annOptCommentsNodesMap(files.fset, astFile, opts)
 	for n, opt := range mn {
-		files.nodeAnnTypes[n] = opt.Type
 		// handle annotateimport now that the node is known
-		if opt.Type == AnnotationTypeImport {
+		switch opt.Type {
+		case AnnotationTypeImport:
 			err := files.handleAnnTypeImport(n, opt)
 			if err != nil {
 				err = positionError(err, files.fset, opt.Comment.Pos())
 				//return err
 				files.warnErr(err)
 			}
+		case AnnotationTypeFirst, AnnotationTypeLast:
+			if _, ok := annOptLoopStmt(n); !ok {
+				err := fmt.Errorf("not at a loop stmt")
+				err = positionError(err, files.fset, opt.Comment.Pos())
+				files.warnErr(err)
+				continue
+			}
 		}
+		files.nodeAnnOpts[n] = opt
 	}
 	return nil
 }
@@ -323,6 +359,9 @@ func (files *Files) handleAnnOpt(filename string, opt *AnnotationOpt) (bool, err
 			return false, err
 		}
 		return true, nil
+	case AnnotationTypeEvery, AnnotationTypeFirst, AnnotationTypeLast, AnnotationTypeIf:
+		// handled by the annotator at the node
+		return true, nil
 	case AnnotationTypeModule:
 		dir := filepath.Dir(filename)
 		if opt.Opt != "" {
@@ -647,13 +686,18 @@ func (files *Files) pkgPathDir(pkgPath string) (string, error) {
 //----------
 
 func (files *Files) NodeAnnType(n ast.Node) AnnotationType {
-	at, ok := files.nodeAnnTypes[n]
+	opt, ok := files.nodeAnnOpts[n]
 	if ok {
-		return at
+		return opt.Type
 	}
 	return AnnotationTypeNone
 }
 
+func (files *Files) NodeAnnOpt(n ast.Node) (*AnnotationOpt, bool) {
+	opt, ok := files.nodeAnnOpts[n]
+	return opt, ok
+}
+
 //----------
 
 func (files *Files) progPackages(ctx context.Context, filenames []string, tests bool, env []string) ([]*packages.Package, error) {
@@ -666,6 +710,10 @@ func (files *Files) progPackages(ctx context.Context, filenames []string, tests
 		//packages.NeedSyntax |
 		packages.NeedTypes |
 		0
+	if files.Typed {
+		// type check from the source (slower)
+		loadMode |= packages.NeedSyntax | packages.NeedTypesInfo
+	}
 	pkgs, err := ProgramPackages(ctx, files.fset, loadMode, files.Dir, filenames, tests, env, files.parseFileFn(), files.stderr)
 
 	// programpackages parses files concurrently, on ctx cancel it concats useless repeated errors, get just one ctx error
@@ -787,6 +835,12 @@ const (
 	AnnotationTypeImport  // annotates set of files (importspec)
 	AnnotationTypePackage // annotates set of files
 	AnnotationTypeModule  // annotates set of packages
+
+	// stmt debug lines only (not a set of files)
+	AnnotationTypeEvery // sample every nth execution
+	AnnotationTypeFirst // first k loop iterations
+	AnnotationTypeLast  // last k loop iterations
+	AnnotationTypeIf    // when the expression holds
 )
 
 func AnnotationTypeInString(s string) (AnnotationType, string, error) {
@@ -820,6 +874,14 @@ func AnnotationTypeInString(s string) (AnnotationType, string, error) {
 		at = AnnotationTypeImport
 	case "annotatemodule":
 		at = AnnotationTypeModule
+	case "annotateevery":
+		at = AnnotationTypeEvery
+	case "annotatefirst":
+		at = AnnotationTypeFirst
+	case "annotatelast":
+		at = AnnotationTypeLast
+	case "annotateif":
+		at = AnnotationTypeIf
 	default:
 		err := fmt.Errorf("godebug: unexpected annotate type: %q", s2)
 		return AnnotationTypeNone, "", err
@@ -831,11 +893,24 @@ func AnnotationTypeInString(s string) (AnnotationType, string, error) {
 		case AnnotationTypeFile:
 		case AnnotationTypePackage:
 		case AnnotationTypeModule:
+		case AnnotationTypeEvery, AnnotationTypeFirst, AnnotationTypeLast, AnnotationTypeIf:
 		default:
 			return at, opt, fmt.Errorf("godebug: unexpected annotate string: %v", opt)
 		}
 	}
 
+	// ensure early error on the required opt
+	switch at {
+	case AnnotationTypeEvery, AnnotationTypeFirst, AnnotationTypeLast:
+		if n, err := strconv.Atoi(opt); err != nil || n < 1 {
+			return at, opt, fmt.Errorf("godebug: expecting positive integer: %q", opt)
+		}
+	case AnnotationTypeIf:
+		if _, err := parser.ParseExpr(opt); err != nil {
+			return at, opt, fmt.Errorf("godebug: bad expression: %q: %v", opt, err)
+		}
+	}
+
 	return at, opt, nil
 }
 
@@ -855,6 +930,26 @@ func AnnotationOptInComment(c *ast.Comment, fset *token.FileSet) (*AnnotationOpt
 	return &AnnotationOpt{typ, opt, c}, nil
 }
 
+// Integer opt of the every/first/last annotations (already validated).
+func (opt *AnnotationOpt) intOpt() int {
+	n, _ := strconv.Atoi(opt.Opt)
+	return n
+}
+
+// Loop stmt (for/range) at the node, including inside a labeled stmt.
+func annOptLoopStmt(n ast.Node) (ast.Stmt, bool) {
+	if ls, ok := n.(*ast.LabeledStmt); ok {
+		n = ls.Stmt
+	}
+	switch t := n.(type) {
+	case *ast.ForStmt:
+		return t, true
+	case *ast.RangeStmt:
+		return t, true
+	}
+	return nil, false
+}
+
 //----------
 
 func ProgramPackages(
@@ -0,0 +1,60 @@
+package godebug
+
+import "github.com/jmigpin/editor/core/godebug/debug"
+
+// Calls fn for the item and its sub items, in pre-order (source order).
+func WalkItems(item debug.Item, fn func(debug.Item)) {
+	w := func(u ...debug.Item) {
+		for _, v := range u {
+			WalkItems(v, fn)
+		}
+	}
+	wl := func(l *debug.ItemList) {
+		if l != nil {
+			fn(l)
+			w(l.List...)
+		}
+	}
+	if item == nil {
+		return
+	}
+	if l, ok := item.(*debug.ItemList); ok {
+		wl(l)
+		return
+	}
+	fn(item)
+	switch t := item.(type) {
+	case *debug.ItemList2:
+		w(t.List...)
+	case *debug.ItemAssign:
+		wl(t.Lhs)
+		wl(t.Rhs)
+	case *debug.ItemSend:
+		w(t.Chan, t.Value)
+	case *debug.ItemCall:
+		wl(t.Args)
+		w(t.Result)
+	case *debug.ItemCallEnter:
+		wl(t.Args)
+	case *debug.ItemIndex:
+		w(t.Expr, t.Index, t.Result)
+	case *debug.ItemIndex2:
+		w(t.Expr, t.Low, t.High, t.Max, t.Result)
+	case *debug.ItemKeyValue:
+		w(t.Key, t.Value)
+	case *debug.ItemSelector:
+		w(t.X, t.Sel)
+	case *debug.ItemTypeAssert:
+		w(t.X, t.Type)
+	case *debug.ItemBinary:
+		w(t.X, t.Y, t.Result)
+	case *debug.ItemUnary:
+		w(t.X, t.Result)
+	case *debug.ItemUnaryEnter:
+		w(t.X)
+	case *debug.ItemParen:
+		w(t.X)
+	case *debug.ItemLiteral:
+		wl(t.Fields)
+	}
+}
@@ -19,7 +19,7 @@ func SetupGoMods(ctx context.Context, cmd *Cmd, files *Files) error {
 		}
 		// create go.mod file at tmp
 		dirAtTmp := cmd.tmpDirBasedFilename(dir)
-		if err := goutil.GoModInit(ctx, dirAtTmp, pkgPath, cmd.env); err != nil {
+		if err := cmd.goModInit(ctx, dirAtTmp, pkgPath); err != nil {
 			return err
 		}
 	}
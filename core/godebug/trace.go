@@ -0,0 +1,36 @@
+package godebug
+
+import (
+	"os"
+)
+
+// A trace file is the sequence of encoded msgs sent by the server (same format as the connection: debug.EncodeMessage). Starts with the debug.FilesDataMsg, followed by the line msgs.
+
+// Records the bytes received from the server. Write errors don't interfere with the connection, they are reported on close.
+type traceRecorder struct {
+	f   *os.File
+	err error
+}
+
+func newTraceRecorder(filename string) (*traceRecorder, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &traceRecorder{f: f}, nil
+}
+
+func (tr *traceRecorder) Write(p []byte) (int, error) {
+	if tr.err == nil {
+		_, tr.err = tr.f.Write(p)
+	}
+	return len(p), nil
+}
+
+func (tr *traceRecorder) Close() error {
+	err := tr.f.Close()
+	if tr.err != nil {
+		return tr.err
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package godebug
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestTraceReplay(t *testing.T) {
+	tf := newTmpFiles(t)
+	defer tf.RemoveAll()
+	filename := filepath.Join(tf.Dir, "trace1.gdt")
+
+	// record
+	tr, err := newTraceRecorder(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := []interface{}{
+		&debug.FilesDataMsg{},
+		&debug.LineMsg{FileIndex: 1, DebugIndex: 2, Offset: 3, Item: debug.IV(4)},
+		[]*debug.LineMsg{{FileIndex: 1, DebugIndex: 3}, {FileIndex: 1, DebugIndex: 4}},
+	}
+	for _, msg := range msgs {
+		b, err := debug.EncodeMessage(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tr.Write(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tr.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// replay
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewReplayClient(context.Background(), f)
+	got := []interface{}{}
+	for msg := range client.Messages {
+		got = append(got, msg)
+	}
+	client.Wait()
+	if len(got) != len(msgs) {
+		t.Fatalf("%v", got)
+	}
+	if _, ok := got[0].(*debug.FilesDataMsg); !ok {
+		t.Fatalf("%T", got[0])
+	}
+	lm, ok := got[1].(*debug.LineMsg)
+	if !ok || lm.DebugIndex != 2 || lm.Offset != 3 || StringifyItem(lm.Item) != "4" {
+		t.Fatalf("%#v", got[1])
+	}
+	if lms, ok := got[2].([]*debug.LineMsg); !ok || len(lms) != 2 || lms[1].DebugIndex != 4 {
+		t.Fatalf("%#v", got[2])
+	}
+}
+
+func TestTraceReplayTruncated(t *testing.T) {
+	tf := newTmpFiles(t)
+	defer tf.RemoveAll()
+	filename := filepath.Join(tf.Dir, "trace1.gdt")
+
+	b, err := debug.EncodeMessage(&debug.FilesDataMsg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filename, b[:len(b)-1], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewReplayClient(context.Background(), f)
+	got := []interface{}{}
+	for msg := range client.Messages {
+		got = append(got, msg)
+	}
+	if len(got) != 1 {
+		t.Fatalf("%v", got)
+	}
+	if _, ok := got[0].(error); !ok {
+		t.Fatalf("%T", got[0])
+	}
+}
@@ -0,0 +1,162 @@
+package godebug
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+// Watch expressions: the debug items don't carry the source text, so the original source is parsed to know which expression produced each value. Values are obtained from the lhs of an assignment (ItemAssign) and from selectors (ItemSelector). Not concurrent safe.
+type WatchSrc struct {
+	astFile *ast.File
+	tf      *token.File
+	stmts   map[int]*watchStmt // [offset] memoized, nil if no stmt
+}
+
+// Exprs strings of the stmt at an offset, in the same order as the item values.
+type watchStmt struct {
+	lhs  []string // assignment lhs (if the offset is at the end of the stmt)
+	sels []string // see stmtSelectorExprs
+}
+
+func NewWatchSrc(filename string, src []byte) (*WatchSrc, error) {
+	fset := token.NewFileSet()
+	astFile, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	ws := &WatchSrc{astFile: astFile, stmts: map[int]*watchStmt{}}
+	ws.tf = fset.File(astFile.Package)
+	return ws, nil
+}
+
+// Returns the values of the expr found in the item of the line msg at the offset.
+func (ws *WatchSrc) Values(expr string, offset int, item debug.Item) []string {
+	st := ws.stmt(offset)
+	if st == nil {
+		return nil
+	}
+
+	u := []string{}
+
+	// assignment lhs
+	if len(st.lhs) > 0 {
+		if ia, ok := item.(*debug.ItemAssign); ok && ia.Lhs != nil && len(ia.Lhs.List) == len(st.lhs) {
+			for i, s := range st.lhs {
+				if s == expr {
+					u = append(u, StringifyItemFull(ia.Lhs.List[i]))
+				}
+			}
+		}
+	}
+
+	// selectors: same visiting order in the src and in the item; ambiguous if the counts don't match (ex: call with selector fun has its own debug step)
+	if len(st.sels) > 0 {
+		isels := itemSelectors(item)
+		if len(st.sels) == len(isels) {
+			for i, s := range st.sels {
+				if s == expr {
+					u = append(u, StringifyItemFull(isels[i].Sel))
+				}
+			}
+		}
+	}
+
+	return u
+}
+
+// Memoized: called for every msg of every watch.
+func (ws *WatchSrc) stmt(offset int) *watchStmt {
+	if st, ok := ws.stmts[offset]; ok {
+		return st
+	}
+	st := ws.stmt2(offset)
+	ws.stmts[offset] = st
+	return st
+}
+
+func (ws *WatchSrc) stmt2(offset int) *watchStmt {
+	if offset < 0 || offset > ws.tf.Size() {
+		return nil
+	}
+	pos := ws.tf.Pos(offset)
+	stmt := ws.lineStmt(pos)
+	if stmt == nil {
+		return nil
+	}
+	st := &watchStmt{}
+	// offset is at the end of the assign stmt
+	if as, ok := stmt.(*ast.AssignStmt); ok && as.End() == pos {
+		for _, e := range as.Lhs {
+			st.lhs = append(st.lhs, types.ExprString(e))
+		}
+	}
+	for _, se := range stmtSelectorExprs(stmt) {
+		st.sels = append(st.sels, types.ExprString(se))
+	}
+	return st
+}
+
+// Innermost stmt containing the position (block stmts excluded).
+func (ws *WatchSrc) lineStmt(pos token.Pos) ast.Stmt {
+	var stmt ast.Stmt
+	ast.Inspect(ws.astFile, func(n ast.Node) bool {
+		if n == nil || pos < n.Pos() || pos > n.End() {
+			return false
+		}
+		if s, ok := n.(ast.Stmt); ok {
+			if _, ok := s.(*ast.BlockStmt); !ok {
+				stmt = s
+			}
+		}
+		return true
+	})
+	return stmt
+}
+
+//----------
+
+// Parses the expr to have the same string format as the src expressions.
+func WatchExprString(expr string) (string, error) {
+	e, err := parser.ParseExpr(expr)
+	if err != nil {
+		return "", err
+	}
+	return types.ExprString(e), nil
+}
+
+//----------
+
+// Selectors that get an ItemSelector, in pre-order. Doesn't enter blocks and func literals (have their own debug steps).
+func stmtSelectorExprs(stmt ast.Stmt) []*ast.SelectorExpr {
+	u := []*ast.SelectorExpr{}
+	callFuns := map[ast.Expr]bool{}
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.BlockStmt, *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			callFuns[t.Fun] = true
+		case *ast.SelectorExpr:
+			if !callFuns[t] && !isSelectorIdents(t) {
+				u = append(u, t)
+			}
+		}
+		return true
+	})
+	return u
+}
+
+// ItemSelectors in pre-order.
+func itemSelectors(item debug.Item) []*debug.ItemSelector {
+	u := []*debug.ItemSelector{}
+	WalkItems(item, func(item debug.Item) {
+		if t, ok := item.(*debug.ItemSelector); ok {
+			u = append(u, t)
+		}
+	})
+	return u
+}
@@ -0,0 +1,61 @@
+package godebug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug/debug"
+)
+
+func TestWatchSrc(t *testing.T) {
+	src := `package p1
+func f() {
+	a, b := 1, 2
+	b = g().c.d
+}
+`
+	ws, err := NewWatchSrc("a.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := func(s string) debug.Item { return &debug.ItemValue{Str: s} }
+	il := func(u ...debug.Item) *debug.ItemList { return &debug.ItemList{List: u} }
+
+	// a, b := 1, 2
+	offset := strings.Index(src, "2\n") + 1 // end of stmt
+	item := debug.IA(il(iv("1"), iv("2")), il(iv("1"), iv("2")))
+	for _, c := range []struct{ expr, res string }{
+		{"a", "[1]"},
+		{"b", "[2]"},
+		{"c", "[]"},
+	} {
+		u := ws.Values(c.expr, offset, item)
+		if s := "[" + strings.Join(u, ",") + "]"; s != c.res {
+			t.Fatalf("%v: %v", c.expr, s)
+		}
+	}
+
+	// b = g().c.d
+	offset = strings.Index(src, ".d\n") + 2
+	sel1 := debug.ISel(&debug.ItemCall{Name: "g"}, iv("{3}"))
+	sel2 := debug.ISel(sel1, iv("3"))
+	item = debug.IA(il(iv("3")), il(sel2))
+	expr, err := WatchExprString("g( ).c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := ws.Values(expr, offset, item); len(u) != 1 || u[0] != "{3}" {
+		t.Fatal(u)
+	}
+	if u := ws.Values("g().c.d", offset, item); len(u) != 1 || u[0] != "3" {
+		t.Fatal(u)
+	}
+	if u := ws.Values("b", offset, item); len(u) != 1 || u[0] != "3" {
+		t.Fatal(u)
+	}
+
+	// memoized by offset
+	if len(ws.stmts) != 2 {
+		t.Fatal(len(ws.stmts))
+	}
+}
@@ -0,0 +1,193 @@
+package godebug
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jmigpin/editor/util/osutil"
+)
+
+// Persistent work dir: re-used across runs (same filenames and content) such that "go build" can use its cache. The annotated files are kept in a content-addressed cache (keyed by the source hash and the annotation options) to avoid re-annotating and rewriting unchanged files.
+type workCache struct {
+	baseDir  string
+	dir      string // work dir (files to build)
+	annDir   string // annotated files cache
+	lockf    *os.File
+	mu       sync.Mutex
+	written  map[string]struct{} // work files written (or kept) in this run
+	usedKeys map[string]struct{} // cache entries used in this run
+}
+
+func newWorkCache(baseDir string) *workCache {
+	wc := &workCache{
+		baseDir:  baseDir,
+		dir:      filepath.Join(baseDir, "work"),
+		annDir:   filepath.Join(baseDir, "annotated"),
+		written:  map[string]struct{}{},
+		usedKeys: map[string]struct{}{},
+	}
+	return wc
+}
+
+// Base directory of all the persistent work dirs.
+func workCacheRootDir() string {
+	d, err := os.UserCacheDir()
+	if err != nil {
+		d = os.TempDir()
+	}
+	return filepath.Join(d, "editor_godebug")
+}
+
+//----------
+
+// Exclusive lock on the base dir for the duration of a session. Concurrent sessions in the same dir would otherwise remove each other files (see removeStale).
+func (wc *workCache) lock() error {
+	if err := os.MkdirAll(wc.baseDir, 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(wc.baseDir, "lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	if err := osutil.TryLockFile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("work dir in use: %v: %w", wc.baseDir, err)
+	}
+	wc.lockf = f
+	return nil
+}
+
+func (wc *workCache) unlock() {
+	if wc.lockf != nil {
+		wc.lockf.Close()
+		wc.lockf = nil
+	}
+}
+
+//----------
+
+func (wc *workCache) keep(filename string) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.written[filename] = struct{}{}
+}
+
+// Doesn't write if the file already has the content.
+func (wc *workCache) writeFile(filename string, src []byte) error {
+	wc.keep(filename)
+	b, err := ioutil.ReadFile(filename)
+	if err == nil && bytes.Equal(b, src) {
+		return nil
+	}
+	return mkdirAllWriteFile(filename, src)
+}
+
+func (wc *workCache) copyFile(src, dst string) error {
+	wc.keep(dst)
+	return mkdirAllCopyFileSync(src, dst)
+}
+
+//----------
+
+func (wc *workCache) annKey(u ...interface{}) string {
+	h := sha1.New()
+	fmt.Fprint(h, workCacheVersion)
+	for _, v := range u {
+		fmt.Fprintf(h, "\x00%v", v)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func (wc *workCache) annEntryFilename(key string) string {
+	return filepath.Join(wc.annDir, key+".json")
+}
+
+func (wc *workCache) getAnnEntry(key string) (*workCacheEntry, bool) {
+	b, err := ioutil.ReadFile(wc.annEntryFilename(key))
+	if err != nil {
+		return nil, false
+	}
+	e := &workCacheEntry{}
+	if err := json.Unmarshal(b, e); err != nil || e.Info == nil {
+		return nil, false
+	}
+	wc.useKey(key)
+	return e, true
+}
+
+func (wc *workCache) setAnnEntry(key string, e *workCacheEntry) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	wc.useKey(key)
+	return mkdirAllWriteFile(wc.annEntryFilename(key), b)
+}
+
+func (wc *workCache) useKey(key string) {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+	wc.usedKeys[key] = struct{}{}
+}
+
+//----------
+
+// Removes the files from previous runs that were not written in this run (ex: a file that is not annotated anymore, or a testmain file), and the cache entries not used.
+func (wc *workCache) removeStale() error {
+	wc.mu.Lock()
+	defer wc.mu.Unlock()
+
+	// only go files, others (ex: go.sum) are managed by the go tool
+	err := filepath.Walk(wc.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == wc.dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		if _, ok := wc.written[path]; !ok {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fis, err := ioutil.ReadDir(wc.annDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, fi := range fis {
+		key := strings.TrimSuffix(fi.Name(), ".json")
+		if _, ok := wc.usedKeys[key]; !ok {
+			if err := os.Remove(filepath.Join(wc.annDir, fi.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//----------
+
+type workCacheEntry struct {
+	Info *AnnotatedFileInfo
+	Src  []byte // annotated src
+}
+
+// Changing the annotator output requires a new version to invalidate the cache.
+const workCacheVersion = "2"
@@ -0,0 +1,97 @@
+package godebug
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWorkCache(t *testing.T) {
+	tf := newTmpFiles(t)
+	defer tf.RemoveAll()
+
+	// first run
+	wc := newWorkCache(tf.Dir)
+	f1 := filepath.Join(wc.dir, "a", "f1.go")
+	f2 := filepath.Join(wc.dir, "a", "f2.go")
+	if err := wc.writeFile(f1, []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wc.writeFile(f2, []byte("2")); err != nil {
+		t.Fatal(err)
+	}
+	key := wc.annKey("hash", 0)
+	e := &workCacheEntry{Info: &AnnotatedFileInfo{DebugLen: 3}, Src: []byte("src")}
+	if err := wc.setAnnEntry(key, e); err != nil {
+		t.Fatal(err)
+	}
+	if err := wc.removeStale(); err != nil {
+		t.Fatal(err)
+	}
+	fi1, err := os.Stat(f1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// second run: f1 unchanged, f2 not written
+	time.Sleep(10 * time.Millisecond)
+	wc = newWorkCache(tf.Dir)
+	e2, ok := wc.getAnnEntry(key)
+	if !ok || e2.Info.DebugLen != 3 || string(e2.Src) != "src" {
+		t.Fatalf("%v %+v", ok, e2)
+	}
+	if _, ok := wc.getAnnEntry(wc.annKey("hash", 1)); ok {
+		t.Fatal("expecting no entry")
+	}
+	if err := wc.writeFile(f1, []byte("1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := wc.removeStale(); err != nil {
+		t.Fatal(err)
+	}
+	fi2, err := os.Stat(f1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi2.ModTime().Equal(fi1.ModTime()) {
+		t.Fatal("unchanged file was rewritten")
+	}
+	if _, err := os.Stat(f2); !os.IsNotExist(err) {
+		t.Fatalf("stale file not removed: %v", err)
+	}
+
+	// third run: entry not used
+	wc = newWorkCache(tf.Dir)
+	if err := wc.removeStale(); err != nil {
+		t.Fatal(err)
+	}
+	fis, err := ioutil.ReadDir(wc.annDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fis) != 0 {
+		t.Fatalf("unused entries not removed: %v", len(fis))
+	}
+}
+
+func TestWorkCacheLock(t *testing.T) {
+	tf := newTmpFiles(t)
+	defer tf.RemoveAll()
+
+	wc1 := newWorkCache(tf.Dir)
+	if err := wc1.lock(); err != nil {
+		t.Fatal(err)
+	}
+	// concurrent session
+	wc2 := newWorkCache(tf.Dir)
+	if err := wc2.lock(); err == nil {
+		t.Fatal("expecting lock error")
+	}
+	wc1.unlock()
+	if err := wc2.lock(); err != nil {
+		t.Fatal(err)
+	}
+	wc2.unlock()
+}
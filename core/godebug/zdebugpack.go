@@ -8,10 +8,13 @@ type FilePack struct {
 }
 
 func DebugFilePacks() []*FilePack {
-	return []*FilePack{{"debug.go", "package debug\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\t\"sync\"\n)\n\nvar server *Server\nvar startServerMu sync.Mutex\n\n// Called by the generated config.\nfunc StartServer() {\n\thotStartServer()\n}\n\nfunc hotStartServer() {\n\tif server == nil {\n\t\tstartServerMu.Lock()\n\t\tif server == nil {\n\t\t\tstartServer()\n\t\t}\n\t\tstartServerMu.Unlock()\n\t}\n}\n\nfunc startServer() {\n\tsrv, err := NewServer()\n\tif err != nil {\n\t\tfmt.Printf(\"error: godebug/debug: start server: %v\\n\", err)\n\t\tos.Exit(1)\n\t}\n\tserver = srv\n}\n\n//----------\n\n// Auto-inserted at main for a clean exit. Not to be used.\nfunc ExitServer() {\n\tif server != nil {\n\t\tserver.Close()\n\t}\n}\n\n//----------\n\n// Auto-inserted at annotations. Not to be used.\nfunc Line(fileIndex, debugIndex, offset int, item Item) {\n\thotStartServer()\n\tlmsg := &LineMsg{FileIndex: fileIndex, DebugIndex: debugIndex, Offset: offset, Item: item}\n\tserver.Send(lmsg)\n}\n"},
+	return []*FilePack{{"debug.go", "package debug\n\nimport (\n\t\"bytes\"\n\t\"fmt\"\n\t\"os\"\n\t\"runtime\"\n\t\"strconv\"\n\t\"sync\"\n\t\"time\"\n)\n\nvar server *Server\nvar startServerMu sync.Mutex\nvar startTime = time.Now()\n\n// Called by the generated config.\nfunc StartServer() {\n\thotStartServer()\n}\n\nfunc hotStartServer() {\n\tif server == nil {\n\t\tstartServerMu.Lock()\n\t\tif server == nil {\n\t\t\tstartServer()\n\t\t}\n\t\tstartServerMu.Unlock()\n\t}\n}\n\nfunc startServer() {\n\tsrv, err := NewServer()\n\tif err != nil {\n\t\tfmt.Printf(\"error: godebug/debug: start server: %v\\n\", err)\n\t\tos.Exit(1)\n\t}\n\tserver = srv\n}\n\n//----------\n\n// Auto-inserted at main for a clean exit. Not to be used.\nfunc ExitServer() {\n\tif server != nil {\n\t\tserver.Close()\n\t}\n}\n\n//----------\n\n// Auto-inserted at annotations. Not to be used.\nfunc Line(fileIndex, debugIndex, offset int, item Item) {\n\tsendLineMsg(newLineMsg(fileIndex, debugIndex, offset, item))\n}\n\nfunc newLineMsg(fileIndex, debugIndex, offset int, item Item) *LineMsg {\n\tlmsg := &LineMsg{FileIndex: fileIndex, DebugIndex: debugIndex, Offset: offset, Item: item}\n\tlmsg.Goid = goid()\n\tlmsg.Time = time.Since(startTime)\n\treturn lmsg\n}\n\nfunc sendLineMsg(lmsg *LineMsg) {\n\thotStartServer()\n\tserver.Send(lmsg)\n}\n\n//----------\n\n// Current goroutine id, parsed from the stack header (\"goroutine 123 [running]:\"). Returns 0 if not found.\nfunc goid() int64 {\n\tvar buf [64]byte\n\tb := buf[:runtime.Stack(buf[:], false)]\n\tb = bytes.TrimPrefix(b, []byte(\"goroutine \"))\n\tif i := bytes.IndexByte(b, ' '); i >= 0 {\n\t\tb = b[:i]\n\t}\n\tid, err := strconv.ParseInt(string(b), 10, 64)\n\tif err != nil {\n\t\treturn 0\n\t}\n\treturn id\n}\n"},
 		{"encode.go", "package debug\n\nimport (\n\t\"bytes\"\n\t\"encoding/binary\"\n\t\"encoding/gob\"\n\t\"io\"\n)\n\nfunc RegisterStructure(v interface{}) {\n\tgob.Register(v)\n}\n\n//----------\n\nfunc EncodeMessage(msg interface{}) ([]byte, error) {\n\t// message buffer\n\tvar bbuf bytes.Buffer\n\n\t// reserve space to encode v size\n\tsizeBuf := make([]byte, 4)\n\tif _, err := bbuf.Write(sizeBuf[:]); err != nil {\n\t\treturn nil, err\n\t}\n\n\t// encode v\n\tenc := gob.NewEncoder(&bbuf)\n\tif err := enc.Encode(&msg); err != nil { // decoder uses &interface{}\n\t\treturn nil, err\n\t}\n\n\t// get bytes\n\tbuf := bbuf.Bytes()\n\n\t// encode v size at buffer start\n\tl := uint32(len(buf) - len(sizeBuf))\n\tbinary.BigEndian.PutUint32(buf, l)\n\n\treturn buf, nil\n}\n\nfunc DecodeMessage(rd io.Reader) (interface{}, error) {\n\t// read size\n\tsizeBuf := make([]byte, 4)\n\tif _, err := io.ReadFull(rd, sizeBuf); err != nil {\n\t\treturn nil, err\n\t}\n\tl := int(binary.BigEndian.Uint32(sizeBuf))\n\n\t// read msg\n\tmsgBuf := make([]byte, l)\n\tif _, err := io.ReadFull(rd, msgBuf); err != nil {\n\t\treturn nil, err\n\t}\n\n\t// decode msg\n\tbuf := bytes.NewBuffer(msgBuf)\n\tdec := gob.NewDecoder(buf)\n\tvar msg interface{}\n\tif err := dec.Decode(&msg); err != nil {\n\t\treturn nil, err\n\t}\n\n\treturn msg, nil\n}\n\n//----------\n\n// TODO: document why this simplified version doesn't work (hangs)\n\n//func EncodeMessage(msg interface{}) ([]byte, error) {\n//\tvar buf bytes.Buffer\n//\tenc := gob.NewEncoder(&buf)\n//\tif err := enc.Encode(&msg); err != nil {\n//\t\treturn nil, err\n//\t}\n//\treturn buf.Bytes(), nil\n//}\n\n//func DecodeMessage(reader io.Reader) (interface{}, error) {\n//\tdec := gob.NewDecoder(reader)\n//\tvar msg interface{}\n//\tif err := dec.Decode(&msg); err != nil {\n//\t\treturn nil, err\n//\t}\n//\treturn msg, nil\n//}\n\n//----------\n"},
 		{"limitedwriter.go", "package debug\n\nimport (\n\t\"bytes\"\n\t\"fmt\"\n)\n\ntype LimitedWriter struct {\n\tsize int\n\tbuf  bytes.Buffer\n}\n\nfunc NewLimitedWriter(size int) *LimitedWriter {\n\treturn &LimitedWriter{size: size}\n}\n\nfunc (w *LimitedWriter) Write(p []byte) (n int, err error) {\n\tif w.size < len(p) {\n\t\tp = p[:w.size]\n\t\terr = LimitReachedErr\n\t}\n\tn, err2 := w.buf.Write(p)\n\tif err2 != nil {\n\t\treturn n, err2\n\t}\n\tw.size -= n\n\treturn n, err\n}\n\nfunc (w *LimitedWriter) Bytes() []byte {\n\treturn w.buf.Bytes()\n}\n\nvar LimitReachedErr = fmt.Errorf(\"limit reached\")\n"},
-		{"server.go", "package debug\n\nimport (\n\t\"io\"\n\t\"io/ioutil\"\n\t\"log\"\n\t\"net\"\n\t\"sync\"\n\t\"time\"\n)\n\n// Vars populated at init by godebugconfig pkg (generated at compile).\nvar AnnotatorFilesData []*AnnotatorFileData // all debug data\nvar ServerNetwork string\nvar ServerAddress string\nvar SyncSend bool // don't send in chunks (usefull to get msgs before crash)\n\n//----------\n\n//var logger = log.New(os.Stdout, \"debug: \", 0)\nvar logger = log.New(ioutil.Discard, \"debug: \", 0)\n\nconst chunkSendRate = 15       // per second\nconst chunkSendNowNMsgs = 2048 // don't wait for send rate, send now (memory)\nconst chunkSendQSize = 512     // msgs queueing to be sent\n\n//----------\n\ntype Server struct {\n\tln     net.Listener\n\tlnwait sync.WaitGroup\n\tclient struct {\n\t\tsync.RWMutex\n\t\tcconn *CConn\n\t}\n\tsendReady sync.RWMutex\n}\n\nfunc NewServer() (*Server, error) {\n\t// start listening\n\tlogger.Print(\"listen\")\n\tln, err := net.Listen(ServerNetwork, ServerAddress)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\n\tsrv := &Server{ln: ln}\n\tsrv.sendReady.Lock() // not ready to send (no client yet)\n\n\t// accept connections\n\tsrv.lnwait.Add(1)\n\tgo func() {\n\t\tdefer srv.lnwait.Done()\n\t\tsrv.acceptClientsLoop()\n\t}()\n\n\treturn srv, nil\n}\n\n//----------\n\nfunc (srv *Server) Close() {\n\t// close listener\n\tlogger.Println(\"closing server\")\n\t_ = srv.ln.Close()\n\tsrv.lnwait.Wait()\n\n\t// close client\n\tlogger.Println(\"closing client\")\n\tsrv.client.Lock()\n\tif srv.client.cconn != nil {\n\t\tsrv.client.cconn.Close()\n\t\tsrv.client.cconn = nil\n\t}\n\tsrv.client.Unlock()\n\n\tlogger.Println(\"server closed\")\n}\n\n//----------\n\nfunc (srv *Server) acceptClientsLoop() {\n\tfor {\n\t\t// accept client\n\t\tlogger.Println(\"waiting for client\")\n\t\tconn, err := srv.ln.Accept()\n\t\tif err != nil {\n\t\t\tlogger.Printf(\"accept error: (%T) %v \", err, err)\n\n\t\t\t// unable to accept (ex: server was closed)\n\t\t\tif operr, ok := err.(*net.OpError); ok {\n\t\t\t\tif operr.Op == \"accept\" {\n\t\t\t\t\tlogger.Println(\"end accept client loop\")\n\t\t\t\t\treturn\n\t\t\t\t}\n\t\t\t}\n\n\t\t\tcontinue\n\t\t}\n\t\tlogger.Println(\"got client\")\n\n\t\t// start client\n\t\tsrv.client.Lock()\n\t\tif srv.client.cconn != nil {\n\t\t\tsrv.client.cconn.Close() // close previous connection\n\t\t}\n\t\tsrv.client.cconn = NewCCon(srv, conn)\n\t\tsrv.client.Unlock()\n\t}\n}\n\n//----------\n\nfunc (srv *Server) Send(v *LineMsg) {\n\t// locks if client is not ready to send\n\tsrv.sendReady.RLock()\n\tdefer srv.sendReady.RUnlock()\n\n\tsrv.client.cconn.Send(v)\n}\n\n//----------\n\n// Client connection.\ntype CConn struct {\n\tsrv          *Server\n\tconn         net.Conn\n\trwait, swait sync.WaitGroup\n\tsendch       chan *LineMsg // sending loop channel\n\treqStart     struct {\n\t\tsync.Mutex\n\t\tstart   chan struct{}\n\t\tstarted bool\n\t\tclosed  bool\n\t}\n}\n\nfunc NewCCon(srv *Server, conn net.Conn) *CConn {\n\tcconn := &CConn{srv: srv, conn: conn}\n\tcconn.reqStart.start = make(chan struct{})\n\n\tqsize := chunkSendQSize\n\tif SyncSend {\n\t\tqsize = 0\n\t}\n\tcconn.sendch = make(chan *LineMsg, qsize)\n\n\t// receive messages\n\tcconn.rwait.Add(1)\n\tgo func() {\n\t\tdefer cconn.rwait.Done()\n\t\tcconn.receiveMsgsLoop()\n\t}()\n\n\t// send msgs\n\tcconn.swait.Add(1)\n\tgo func() {\n\t\tdefer cconn.swait.Done()\n\t\tcconn.sendMsgsLoop()\n\t}()\n\n\treturn cconn\n}\n\nfunc (cconn *CConn) Close() {\n\tcconn.reqStart.Lock()\n\tif cconn.reqStart.started {\n\t\t// not sendready anymore\n\t\tcconn.srv.sendReady.Lock()\n\t}\n\tcconn.reqStart.closed = true\n\tcconn.reqStart.Unlock()\n\n\t// close send msgs: can't close receive msgs first (closes client)\n\tclose(cconn.reqStart.start) // ok even if it didn't start\n\tclose(cconn.sendch)\n\tcconn.swait.Wait()\n\n\t// close receive msgs\n\t_ = cconn.conn.Close()\n\tcconn.rwait.Wait()\n}\n\n//----------\n\nfunc (cconn *CConn) receiveMsgsLoop() {\n\tfor {\n\t\tmsg, err := DecodeMessage(cconn.conn)\n\t\tif err != nil {\n\t\t\t// unable to read (server was probably closed)\n\t\t\tif operr, ok := err.(*net.OpError); ok {\n\t\t\t\tif operr.Op == \"read\" {\n\t\t\t\t\tbreak\n\t\t\t\t}\n\t\t\t}\n\t\t\t// connection ended gracefully by the client\n\t\t\tif err == io.EOF {\n\t\t\t\tbreak\n\t\t\t}\n\n\t\t\t// always print if the error reaches here\n\t\t\tlog.Print(err)\n\t\t\treturn\n\t\t}\n\n\t\t// handle msg\n\t\tswitch t := msg.(type) {\n\t\tcase *ReqFilesDataMsg:\n\t\t\tlogger.Print(\"sending files data\")\n\t\t\tmsg := &FilesDataMsg{Data: AnnotatorFilesData}\n\t\t\tif err := cconn.send2(msg); err != nil {\n\t\t\t\tlog.Println(err)\n\t\t\t}\n\t\tcase *ReqStartMsg:\n\t\t\tlogger.Print(\"reqstart\")\n\t\t\tcconn.reqStart.Lock()\n\t\t\tif !cconn.reqStart.started && !cconn.reqStart.closed {\n\t\t\t\tcconn.reqStart.start <- struct{}{}\n\t\t\t\tcconn.reqStart.started = true\n\t\t\t\tcconn.srv.sendReady.Unlock()\n\t\t\t}\n\t\t\tcconn.reqStart.Unlock()\n\t\tdefault:\n\t\t\t// always print if there is a new msg type\n\t\t\tlog.Printf(\"todo: unexpected msg type: %T\", t)\n\t\t}\n\t}\n}\n\n//----------\n\nfunc (cconn *CConn) sendMsgsLoop() {\n\t// wait for reqstart, or the client won't have the index data\n\t_, ok := <-cconn.reqStart.start\n\tif !ok {\n\t\treturn\n\t}\n\n\tif SyncSend {\n\t\tcconn.syncSendLoop()\n\t} else {\n\t\tcconn.chunkSendLoop()\n\t}\n}\n\nfunc (cconn *CConn) syncSendLoop() {\n\tfor {\n\t\tv, ok := <-cconn.sendch\n\t\tif !ok {\n\t\t\tbreak\n\t\t}\n\t\tif err := cconn.send2(v); err != nil {\n\t\t\tlog.Println(err)\n\t\t}\n\t}\n}\n\nfunc (cconn *CConn) chunkSendLoop() {\n\tscheduled := false\n\ttimeToSend := make(chan bool)\n\tmsgs := []*LineMsg{}\n\tsendMsgs := func() {\n\t\tif len(msgs) > 0 {\n\t\t\tif err := cconn.send2(msgs); err != nil {\n\t\t\t\tlog.Println(err)\n\t\t\t}\n\t\t\tmsgs = nil\n\t\t}\n\t}\nloop1:\n\tfor {\n\t\tselect {\n\t\tcase v, ok := <-cconn.sendch:\n\t\t\tif !ok {\n\t\t\t\tbreak loop1\n\t\t\t}\n\t\t\tmsgs = append(msgs, v)\n\t\t\tif len(msgs) >= chunkSendNowNMsgs {\n\t\t\t\tsendMsgs()\n\t\t\t} else if !scheduled {\n\t\t\t\tscheduled = true\n\t\t\t\tgo func() {\n\t\t\t\t\td := time.Second / time.Duration(chunkSendRate)\n\t\t\t\t\ttime.Sleep(d)\n\t\t\t\t\ttimeToSend <- true\n\t\t\t\t}()\n\t\t\t}\n\t\tcase <-timeToSend:\n\t\t\tscheduled = false\n\t\t\tsendMsgs()\n\t\t}\n\t}\n\t// send last messages if any\n\tsendMsgs()\n}\n\nfunc (cconn *CConn) send2(v interface{}) error {\n\tencoded, err := EncodeMessage(v)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\tn, err := cconn.conn.Write(encoded)\n\tif err != nil {\n\t\treturn err\n\t}\n\tif n != len(encoded) {\n\t\tlogger.Printf(\"n!=len(encoded): %v %v\\n\", n, len(encoded))\n\t}\n\treturn nil\n}\n\n//----------\n\nfunc (cconn *CConn) Send(v *LineMsg) {\n\tcconn.sendch <- v\n}\n"},
+		{"pause.go", "package debug\n\nimport (\n\t\"regexp\"\n\t\"sync\"\n\t\"sync/atomic\"\n)\n\n// Pauses the goroutines that reach a breakpoint (or are stepping) until the client sends a continue msg.\ntype pauser struct {\n\tactive int32 // atomic: fast check for having breakpoints or steps\n\n\tmu     sync.Mutex\n\tbps    map[bpKey][]*breakpoint\n\tsteps  map[int64]bool      // [goid] pause at the next line msg\n\tpaused map[int64]chan bool // [goid] continue (true: step)\n}\n\ntype bpKey struct {\n\tfileIndex int\n\toffset    int\n}\n\ntype breakpoint struct {\n\tcond *regexp.Regexp // can be nil\n}\n\nfunc (p *pauser) init() {\n\tp.bps = map[bpKey][]*breakpoint{}\n\tp.steps = map[int64]bool{}\n\tp.paused = map[int64]chan bool{}\n}\n\n//----------\n\nfunc (p *pauser) setBreakpoints(u []*Breakpoint) {\n\tp.mu.Lock()\n\tdefer p.mu.Unlock()\n\tp.bps = map[bpKey][]*breakpoint{}\n\tfor _, b := range u {\n\t\tbp := &breakpoint{}\n\t\tif b.Cond != \"\" {\n\t\t\tre, err := regexp.Compile(b.Cond)\n\t\t\tif err != nil {\n\t\t\t\tlogger.Printf(\"breakpoint cond: %v\", err)\n\t\t\t\tcontinue\n\t\t\t}\n\t\t\tbp.cond = re\n\t\t}\n\t\tk := bpKey{b.FileIndex, b.Offset}\n\t\tp.bps[k] = append(p.bps[k], bp)\n\t}\n\tp.updateActive()\n}\n\nfunc (p *pauser) updateActive() {\n\tv := int32(0)\n\tif len(p.bps) > 0 || len(p.steps) > 0 {\n\t\tv = 1\n\t}\n\tatomic.StoreInt32(&p.active, v)\n}\n\n//----------\n\n// Blocks the calling goroutine (the one that sent the line msg) if it should pause. The sendPaused func is called before blocking. Returns true if it paused.\nfunc (p *pauser) pauseIfNeeded(lmsg *LineMsg, sendPaused func()) bool {\n\tif atomic.LoadInt32(&p.active) == 0 {\n\t\treturn false\n\t}\n\n\tp.mu.Lock()\n\tif !p.shouldPause(lmsg) {\n\t\tp.mu.Unlock()\n\t\treturn false\n\t}\n\tdelete(p.steps, lmsg.Goid)\n\tp.updateActive()\n\tch := make(chan bool, 1)\n\tp.paused[lmsg.Goid] = ch\n\tp.mu.Unlock()\n\n\tsendPaused()\n\n\tif step := <-ch; step {\n\t\tp.mu.Lock()\n\t\tp.steps[lmsg.Goid] = true\n\t\tp.updateActive()\n\t\tp.mu.Unlock()\n\t}\n\treturn true\n}\n\n// Not locked\nfunc (p *pauser) shouldPause(lmsg *LineMsg) bool {\n\tif p.steps[lmsg.Goid] {\n\t\treturn true\n\t}\n\tfor _, bp := range p.bps[bpKey{lmsg.FileIndex, lmsg.Offset}] {\n\t\tif bp.cond == nil || itemValuesMatch(lmsg.Item, bp.cond) {\n\t\t\treturn true\n\t\t}\n\t}\n\treturn false\n}\n\n// Continues the paused goroutine (all if goid is zero). The cb func (can be nil) is called before each goroutine continues.\nfunc (p *pauser) cont(goid int64, step bool, cb func(int64)) {\n\tp.mu.Lock()\n\tchs := map[int64]chan bool{}\n\tfor id, ch := range p.paused {\n\t\tif goid == 0 || id == goid {\n\t\t\tchs[id] = ch\n\t\t\tdelete(p.paused, id)\n\t\t}\n\t}\n\tp.mu.Unlock()\n\n\t// not locked: cb could block (ex: sending a msg)\n\tfor id, ch := range chs {\n\t\tif cb != nil {\n\t\t\tcb(id)\n\t\t}\n\t\tch <- step\n\t}\n}\n\n// Clears the breakpoints and continues all goroutines (ex: client disconnected).\nfunc (p *pauser) reset() {\n\tp.mu.Lock()\n\tp.bps = map[bpKey][]*breakpoint{}\n\tp.steps = map[int64]bool{}\n\tp.updateActive()\n\tp.mu.Unlock()\n\tp.cont(0, false, nil)\n}\n\n//----------\n\n// Matches the regexp against the values of the item.\nfunc itemValuesMatch(item Item, re *regexp.Regexp) bool {\n\tmatch := false\n\twalkItemValues(item, func(iv *ItemValue) bool {\n\t\tmatch = re.MatchString(iv.Str)\n\t\treturn !match\n\t})\n\treturn match\n}\n\n// Calls fn for each value of the item until it returns false.\nfunc walkItemValues(item Item, fn func(*ItemValue) bool) bool {\n\tw := func(u ...Item) bool {\n\t\tfor _, v := range u {\n\t\t\tif !walkItemValues(v, fn) {\n\t\t\t\treturn false\n\t\t\t}\n\t\t}\n\t\treturn true\n\t}\n\twl := func(l *ItemList) bool {\n\t\tif l == nil {\n\t\t\treturn true\n\t\t}\n\t\treturn w(l.List...)\n\t}\n\tswitch t := item.(type) {\n\tcase *ItemValue:\n\t\treturn fn(t)\n\tcase *ItemList:\n\t\treturn wl(t)\n\tcase *ItemList2:\n\t\treturn w(t.List...)\n\tcase *ItemAssign:\n\t\treturn wl(t.Lhs) && wl(t.Rhs)\n\tcase *ItemSend:\n\t\treturn w(t.Chan, t.Value)\n\tcase *ItemCall:\n\t\treturn wl(t.Args) && w(t.Result)\n\tcase *ItemCallEnter:\n\t\treturn wl(t.Args)\n\tcase *ItemIndex:\n\t\treturn w(t.Result, t.Expr, t.Index)\n\tcase *ItemIndex2:\n\t\treturn w(t.Result, t.Expr, t.Low, t.High, t.Max)\n\tcase *ItemKeyValue:\n\t\treturn w(t.Key, t.Value)\n\tcase *ItemSelector:\n\t\treturn w(t.X, t.Sel)\n\tcase *ItemTypeAssert:\n\t\treturn w(t.X, t.Type)\n\tcase *ItemBinary:\n\t\treturn w(t.Result, t.X, t.Y)\n\tcase *ItemUnary:\n\t\treturn w(t.Result, t.X)\n\tcase *ItemUnaryEnter:\n\t\treturn w(t.X)\n\tcase *ItemParen:\n\t\treturn w(t.X)\n\tcase *ItemLiteral:\n\t\treturn wl(t.Fields)\n\t}\n\treturn true\n}\n"},
+		{"sample.go", "package debug\n\nimport \"sync\"\n\n// Counters of the \"annotateevery\" debug lines.\nvar samples struct {\n\tsync.Mutex\n\tm map[sampleKey]int\n}\n\ntype sampleKey struct {\n\tfileIndex   int\n\tsampleIndex int\n}\n\n// Auto-inserted at annotations. Not to be used.\n// Returns true every nth call of the annotated line (starting with the first).\nfunc Sample(n, fileIndex, sampleIndex int) bool {\n\tsamples.Lock()\n\tdefer samples.Unlock()\n\tif samples.m == nil {\n\t\tsamples.m = map[sampleKey]int{}\n\t}\n\tk := sampleKey{fileIndex, sampleIndex}\n\tc := samples.m[k]\n\tsamples.m[k] = c + 1\n\treturn c%n == 0\n}\n\n//----------\n\n// Auto-inserted at annotations (\"annotatefirst\", \"annotatelast\"). Not to be used.\n// Sends only the line msgs of the first/last iterations of a loop. The msgs of the last iterations are kept until the loop is done.\ntype Loop struct {\n\tmu          sync.Mutex\n\tfirst, last int\n\titer        int          // current iteration, -1 before the first\n\tkept        [][]*LineMsg // msgs of the last iterations\n\tdone        bool\n}\n\nfunc NewLoop(first, last int) *Loop {\n\treturn &Loop{first: first, last: last, iter: -1}\n}\n\n// Called at the start of each iteration.\nfunc (l *Loop) Iter() {\n\tl.mu.Lock()\n\tdefer l.mu.Unlock()\n\tl.iter++\n\tif l.last > 0 && l.iter >= l.first {\n\t\tif len(l.kept) == l.last {\n\t\t\tcopy(l.kept, l.kept[1:])\n\t\t\tl.kept = l.kept[:len(l.kept)-1]\n\t\t}\n\t\tl.kept = append(l.kept, nil)\n\t}\n}\n\nfunc (l *Loop) Line(fileIndex, debugIndex, offset int, item Item) {\n\tl.mu.Lock()\n\tif !l.done && l.iter >= l.first { // (iter<0 is before the loop)\n\t\tif l.last > 0 {\n\t\t\tk := len(l.kept) - 1\n\t\t\tlmsg := newLineMsg(fileIndex, debugIndex, offset, item)\n\t\t\tl.kept[k] = append(l.kept[k], lmsg)\n\t\t}\n\t\tl.mu.Unlock()\n\t\treturn\n\t}\n\tl.mu.Unlock()\n\tLine(fileIndex, debugIndex, offset, item)\n}\n\n// Sends the kept msgs. Msgs of later lines are sent directly (ex: closures running after the loop).\nfunc (l *Loop) Done() {\n\tl.mu.Lock()\n\tif l.done {\n\t\tl.mu.Unlock()\n\t\treturn\n\t}\n\tl.done = true\n\tkept := l.kept\n\tl.kept = nil\n\tl.mu.Unlock()\n\n\tfor _, u := range kept {\n\t\tfor _, lmsg := range u {\n\t\t\tsendLineMsg(lmsg)\n\t\t}\n\t}\n}\n"},
+		{"server.go", "package debug\n\nimport (\n\t\"io\"\n\t\"io/ioutil\"\n\t\"log\"\n\t\"net\"\n\t\"sync\"\n\t\"time\"\n)\n\n// Vars populated at init by godebugconfig pkg (generated at compile).\nvar AnnotatorFilesData []*AnnotatorFileData // all debug data\nvar ServerNetwork string\nvar ServerAddress string\nvar SyncSend bool // don't send in chunks (usefull to get msgs before crash)\n\n//----------\n\n//var logger = log.New(os.Stdout, \"debug: \", 0)\nvar logger = log.New(ioutil.Discard, \"debug: \", 0)\n\nconst chunkSendRate = 15       // per second\nconst chunkSendNowNMsgs = 2048 // don't wait for send rate, send now (memory)\nconst chunkSendQSize = 512     // msgs queueing to be sent\n\n//----------\n\ntype Server struct {\n\tln     net.Listener\n\tlnwait sync.WaitGroup\n\tclient struct {\n\t\tsync.RWMutex\n\t\tcconn *CConn\n\t}\n\tsendReady sync.RWMutex\n\tpause     pauser\n}\n\nfunc NewServer() (*Server, error) {\n\t// start listening\n\tlogger.Print(\"listen\")\n\tln, err := net.Listen(ServerNetwork, ServerAddress)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n\n\tsrv := &Server{ln: ln}\n\tsrv.pause.init()\n\tsrv.sendReady.Lock() // not ready to send (no client yet)\n\n\t// accept connections\n\tsrv.lnwait.Add(1)\n\tgo func() {\n\t\tdefer srv.lnwait.Done()\n\t\tsrv.acceptClientsLoop()\n\t}()\n\n\treturn srv, nil\n}\n\n//----------\n\nfunc (srv *Server) Close() {\n\t// close listener\n\tlogger.Println(\"closing server\")\n\t_ = srv.ln.Close()\n\tsrv.lnwait.Wait()\n\n\t// close client\n\tlogger.Println(\"closing client\")\n\tsrv.client.Lock()\n\tif srv.client.cconn != nil {\n\t\tsrv.client.cconn.Close()\n\t\tsrv.client.cconn = nil\n\t}\n\tsrv.client.Unlock()\n\n\t// don't leave goroutines paused\n\tsrv.pause.reset()\n\n\tlogger.Println(\"server closed\")\n}\n\n//----------\n\nfunc (srv *Server) acceptClientsLoop() {\n\tfor {\n\t\t// accept client\n\t\tlogger.Println(\"waiting for client\")\n\t\tconn, err := srv.ln.Accept()\n\t\tif err != nil {\n\t\t\tlogger.Printf(\"accept error: (%T) %v \", err, err)\n\n\t\t\t// unable to accept (ex: server was closed)\n\t\t\tif operr, ok := err.(*net.OpError); ok {\n\t\t\t\tif operr.Op == \"accept\" {\n\t\t\t\t\tlogger.Println(\"end accept client loop\")\n\t\t\t\t\treturn\n\t\t\t\t}\n\t\t\t}\n\n\t\t\tcontinue\n\t\t}\n\t\tlogger.Println(\"got client\")\n\n\t\t// start client\n\t\tsrv.client.Lock()\n\t\tif srv.client.cconn != nil {\n\t\t\tsrv.client.cconn.Close() // close previous connection\n\t\t\t// breakpoints belong to the previous client\n\t\t\tsrv.pause.reset()\n\t\t}\n\t\tsrv.client.cconn = NewCCon(srv, conn)\n\t\tsrv.client.Unlock()\n\t}\n}\n\n//----------\n\nfunc (srv *Server) Send(v *LineMsg) {\n\tpaused := srv.pause.pauseIfNeeded(v, func() {\n\t\t// values can be inspected while the goroutine is paused\n\t\tvalues.addPaused(v.Goid, v.Item)\n\t\tsrv.send(v)\n\t\tsrv.send(&PausedMsg{Goid: v.Goid, FileIndex: v.FileIndex, Offset: v.Offset})\n\t})\n\tif paused {\n\t\tvalues.removePaused(v.Goid)\n\t} else {\n\t\tsrv.send(v)\n\t}\n}\n\nfunc (srv *Server) send(v interface{}) {\n\t// locks if client is not ready to send\n\tsrv.sendReady.RLock()\n\tdefer srv.sendReady.RUnlock()\n\n\tsrv.client.cconn.Send(v)\n}\n\n//----------\n\n// Client connection.\ntype CConn struct {\n\tsrv          *Server\n\tconn         net.Conn\n\trwait, swait sync.WaitGroup\n\tsendch       chan interface{} // sending loop channel\n\tclosing      chan struct{}    // unblocks senders on close\n\treqStart     struct {\n\t\tsync.Mutex\n\t\tstart   chan struct{}\n\t\tstarted bool\n\t\tclosed  bool\n\t}\n}\n\nfunc NewCCon(srv *Server, conn net.Conn) *CConn {\n\tcconn := &CConn{srv: srv, conn: conn}\n\tcconn.reqStart.start = make(chan struct{})\n\tcconn.closing = make(chan struct{})\n\n\tqsize := chunkSendQSize\n\tif SyncSend {\n\t\tqsize = 0\n\t}\n\tcconn.sendch = make(chan interface{}, qsize)\n\n\t// receive messages\n\tcconn.rwait.Add(1)\n\tgo func() {\n\t\tdefer cconn.rwait.Done()\n\t\tcconn.receiveMsgsLoop()\n\t}()\n\n\t// send msgs\n\tcconn.swait.Add(1)\n\tgo func() {\n\t\tdefer cconn.swait.Done()\n\t\tcconn.sendMsgsLoop()\n\t}()\n\n\treturn cconn\n}\n\nfunc (cconn *CConn) Close() {\n\tcconn.reqStart.Lock()\n\tif cconn.reqStart.started {\n\t\t// not sendready anymore\n\t\tcconn.srv.sendReady.Lock()\n\t}\n\tcconn.reqStart.closed = true\n\tcconn.reqStart.Unlock()\n\n\t// stop receiving msgs without closing the conn (the send loop still needs it). The receive loop can call Send, so it must end before the send channel is closed.\n\tclose(cconn.closing)\n\t_ = cconn.conn.SetReadDeadline(time.Now())\n\tcconn.rwait.Wait()\n\n\t// close send msgs\n\tclose(cconn.reqStart.start) // ok even if it didn't start\n\tclose(cconn.sendch)\n\tcconn.swait.Wait()\n\n\t_ = cconn.conn.Close()\n}\n\n//----------\n\nfunc (cconn *CConn) receiveMsgsLoop() {\n\t// client disconnected: don't leave goroutines paused\n\tdefer cconn.srv.pause.reset()\n\n\tfor {\n\t\tmsg, err := DecodeMessage(cconn.conn)\n\t\tif err != nil {\n\t\t\t// unable to read (server was probably closed)\n\t\t\tif operr, ok := err.(*net.OpError); ok {\n\t\t\t\tif operr.Op == \"read\" {\n\t\t\t\t\tbreak\n\t\t\t\t}\n\t\t\t}\n\t\t\t// connection ended gracefully by the client\n\t\t\tif err == io.EOF {\n\t\t\t\tbreak\n\t\t\t}\n\n\t\t\t// always print if the error reaches here\n\t\t\tlog.Print(err)\n\t\t\treturn\n\t\t}\n\n\t\t// handle msg\n\t\tswitch t := msg.(type) {\n\t\tcase *ReqFilesDataMsg:\n\t\t\tlogger.Print(\"sending files data\")\n\t\t\tmsg := &FilesDataMsg{Data: AnnotatorFilesData}\n\t\t\tif err := cconn.send2(msg); err != nil {\n\t\t\t\tlog.Println(err)\n\t\t\t}\n\t\tcase *ReqStartMsg:\n\t\t\tlogger.Print(\"reqstart\")\n\t\t\tcconn.reqStart.Lock()\n\t\t\tif !cconn.reqStart.started && !cconn.reqStart.closed {\n\t\t\t\tcconn.reqStart.start <- struct{}{}\n\t\t\t\tcconn.reqStart.started = true\n\t\t\t\tcconn.srv.sendReady.Unlock()\n\t\t\t}\n\t\t\tcconn.reqStart.Unlock()\n\t\tcase *SetBreakpointsMsg:\n\t\t\tlogger.Print(\"set breakpoints\")\n\t\t\tcconn.srv.pause.setBreakpoints(t.Breakpoints)\n\t\tcase *ContinueMsg:\n\t\t\tlogger.Print(\"continue\")\n\t\t\tcconn.srv.pause.cont(t.Goid, t.Step, func(goid int64) {\n\t\t\t\t// sent before the goroutine continues to keep the msgs order\n\t\t\t\tcconn.Send(&ContinuedMsg{Goid: goid})\n\t\t\t})\n\t\tcase *ReqValueMsg:\n\t\t\tlogger.Print(\"req value\")\n\t\t\tcconn.Send(inspectValue(t))\n\t\tdefault:\n\t\t\t// always print if there is a new msg type\n\t\t\tlog.Printf(\"todo: unexpected msg type: %T\", t)\n\t\t}\n\t}\n}\n\n//----------\n\nfunc (cconn *CConn) sendMsgsLoop() {\n\t// wait for reqstart, or the client won't have the index data\n\t_, ok := <-cconn.reqStart.start\n\tif !ok {\n\t\treturn\n\t}\n\n\tif SyncSend {\n\t\tcconn.syncSendLoop()\n\t} else {\n\t\tcconn.chunkSendLoop()\n\t}\n}\n\nfunc (cconn *CConn) syncSendLoop() {\n\tfor {\n\t\tv, ok := <-cconn.sendch\n\t\tif !ok {\n\t\t\tbreak\n\t\t}\n\t\tif err := cconn.send2(v); err != nil {\n\t\t\tlog.Println(err)\n\t\t}\n\t}\n}\n\nfunc (cconn *CConn) chunkSendLoop() {\n\tscheduled := false\n\ttimeToSend := make(chan bool)\n\tmsgs := []*LineMsg{}\n\tsendMsgs := func() {\n\t\tif len(msgs) > 0 {\n\t\t\tif err := cconn.send2(msgs); err != nil {\n\t\t\t\tlog.Println(err)\n\t\t\t}\n\t\t\tmsgs = nil\n\t\t}\n\t}\nloop1:\n\tfor {\n\t\tselect {\n\t\tcase v, ok := <-cconn.sendch:\n\t\t\tif !ok {\n\t\t\t\tbreak loop1\n\t\t\t}\n\t\t\tlmsg, ok := v.(*LineMsg)\n\t\t\tif !ok {\n\t\t\t\t// other msgs (ex: paused) are sent now, after the pending line msgs\n\t\t\t\tsendMsgs()\n\t\t\t\tif err := cconn.send2(v); err != nil {\n\t\t\t\t\tlog.Println(err)\n\t\t\t\t}\n\t\t\t\tcontinue\n\t\t\t}\n\t\t\tmsgs = append(msgs, lmsg)\n\t\t\tif len(msgs) >= chunkSendNowNMsgs {\n\t\t\t\tsendMsgs()\n\t\t\t} else if !scheduled {\n\t\t\t\tscheduled = true\n\t\t\t\tgo func() {\n\t\t\t\t\td := time.Second / time.Duration(chunkSendRate)\n\t\t\t\t\ttime.Sleep(d)\n\t\t\t\t\ttimeToSend <- true\n\t\t\t\t}()\n\t\t\t}\n\t\tcase <-timeToSend:\n\t\t\tscheduled = false\n\t\t\tsendMsgs()\n\t\t}\n\t}\n\t// send last messages if any\n\tsendMsgs()\n}\n\nfunc (cconn *CConn) send2(v interface{}) error {\n\tencoded, err := EncodeMessage(v)\n\tif err != nil {\n\t\tpanic(err)\n\t}\n\tn, err := cconn.conn.Write(encoded)\n\tif err != nil {\n\t\treturn err\n\t}\n\tif n != len(encoded) {\n\t\tlogger.Printf(\"n!=len(encoded): %v %v\\n\", n, len(encoded))\n\t}\n\treturn nil\n}\n\n//----------\n\nfunc (cconn *CConn) Send(v interface{}) {\n\tselect {\n\tcase cconn.sendch <- v:\n\tcase <-cconn.closing: // msg is dropped\n\t}\n}\n"},
 		{"stringifyv.go", "package debug\n\nimport (\n\t\"fmt\"\n\t\"reflect\"\n\t\"strconv\"\n)\n\nfunc stringifyV(v V) string {\n\t//return stringifyV1(v)\n\treturn stringifyV2(v)\n}\n\n//----------\n\nfunc stringifyV1(v V) string {\n\t// Note: rune is an alias for int32, can't \"case rune:\"\n\tconst max = 150\n\tqFmt := limitFormat(max, \"%q\")\n\tstr := \"\"\n\tswitch t := v.(type) {\n\tcase nil:\n\t\treturn \"nil\"\n\tcase error:\n\t\tstr = ReducedSprintf(max, qFmt, t)\n\tcase string:\n\t\tstr = ReducedSprintf(max, qFmt, t)\n\tcase []string:\n\t\tstr = quotedStrings(max, t)\n\tcase fmt.Stringer:\n\t\tstr = ReducedSprintf(max, qFmt, t)\n\tcase []byte:\n\t\tstr = ReducedSprintf(max, qFmt, t)\n\tcase float32:\n\t\tstr = strconv.FormatFloat(float64(t), 'f', -1, 32)\n\tcase float64:\n\t\tstr = strconv.FormatFloat(t, 'f', -1, 64)\n\tdefault:\n\t\tu := limitFormat(max, \"%v\")\n\t\tstr = ReducedSprintf(max, u, v) // ex: bool\n\t}\n\treturn str\n}\n\n//----------\n\nfunc ReducedSprintf(max int, format string, a ...interface{}) string {\n\tw := NewLimitedWriter(max)\n\t_, err := fmt.Fprintf(w, format, a...)\n\ts := string(w.Bytes())\n\tif err == LimitReachedErr {\n\t\ts += \"...\"\n\t\t// close quote if present\n\t\tconst q = '\"'\n\t\tif rune(s[0]) == q {\n\t\t\ts += string(q)\n\t\t}\n\t}\n\treturn s\n}\n\nfunc quotedStrings(max int, a []string) string {\n\tw := NewLimitedWriter(max)\n\tsp := \"\"\n\tlimited := 0\n\tuFmt := limitFormat(max, \"%s%q\")\n\tfor i, s := range a {\n\t\tif i > 0 {\n\t\t\tsp = \" \"\n\t\t}\n\t\tn, err := fmt.Fprintf(w, uFmt, sp, s)\n\t\tif err != nil {\n\t\t\tif err == LimitReachedErr {\n\t\t\t\tlimited = n\n\t\t\t}\n\t\t\tbreak\n\t\t}\n\t}\n\ts := string(w.Bytes())\n\tif limited > 0 {\n\t\ts += \"...\"\n\t\tif limited >= 2 { // 1=space, 2=quote\n\t\t\ts += `\"` // close quote\n\t\t}\n\t}\n\treturn \"[\" + s + \"]\"\n}\n\nfunc limitFormat(max int, s string) string {\n\t// not working: attempt to speedup by using max width (performance)\n\t//s = strings.ReplaceAll(s, \"%\", fmt.Sprintf(\"%%.%d\", max))\n\treturn s\n}\n\n//----------\n//----------\n//----------\n\nfunc stringifyV2(v interface{}) string {\n\tp := NewPrint(150, 3)\n\treturn string(p.Do(v))\n}\n\n//----------\n\ntype Print struct {\n\tMax int // not a strict max, it helps decide to reduce ouput\n\tOut []byte\n\n\tmaxPtrDepth int\n}\n\nfunc NewPrint(max, maxPtrDepth int) *Print {\n\treturn &Print{Max: max, maxPtrDepth: maxPtrDepth}\n}\n\nfunc (p *Print) Do(v interface{}) []byte {\n\tctx := &Ctx{}\n\tctx = ctx.WithInInterface(0)\n\tp.do(ctx, v, 0)\n\treturn p.Out\n}\n\nfunc (p *Print) do(ctx *Ctx, v interface{}, depth int) {\n\tswitch t := v.(type) {\n\tcase nil:\n\t\tp.appendStr(\"nil\")\n\tcase bool,\n\t\tint, int8, int16, int32, int64,\n\t\tuint, uint8, uint16, uint32, uint64,\n\t\tcomplex64, complex128:\n\t\ts := fmt.Sprintf(\"%v\", t)\n\t\tp.appendStr(s)\n\tcase float32:\n\t\ts := strconv.FormatFloat(float64(t), 'f', -1, 32)\n\t\tp.appendStr(s)\n\tcase float64:\n\t\ts := strconv.FormatFloat(t, 'f', -1, 64)\n\t\tp.appendStr(s)\n\tcase string:\n\t\tp.appendStrQuoted(p.limitStr(t))\n\tcase []byte:\n\t\tp.doBytes(t)\n\tcase uintptr:\n\t\tp.appendStr(fmt.Sprintf(\"%#x\", t))\n\tcase error:\n\t\tdefer p.catchPanic(ctx, t, \"Error\", depth)\n\t\ts := t.Error() // TODO: big output\n\t\tp.appendStrQuoted(p.limitStr(s))\n\tcase fmt.Stringer:\n\t\tdefer p.catchPanic(ctx, t, \"String\", depth)\n\t\ts := t.String() // TODO: big output\n\t\tp.appendStrQuoted(p.limitStr(s))\n\tdefault:\n\t\tp.doValue(ctx, reflect.ValueOf(v), depth)\n\t}\n}\n\nfunc (p *Print) doValue(ctx *Ctx, v reflect.Value, depth int) {\n\tswitch v.Kind() {\n\tcase reflect.Bool:\n\t\tp.do(ctx, v.Bool(), depth)\n\tcase reflect.String:\n\t\tp.do(ctx, v.String(), depth)\n\tcase reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:\n\t\tp.do(ctx, v.Int(), depth)\n\tcase reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:\n\t\tp.do(ctx, v.Uint(), depth)\n\tcase reflect.Float32,\n\t\treflect.Float64:\n\t\tp.do(ctx, v.Float(), depth)\n\tcase reflect.Complex64,\n\t\treflect.Complex128:\n\t\tp.do(ctx, v.Complex(), depth)\n\tcase reflect.Ptr:\n\t\tp.doPointer(ctx, v, depth)\n\tcase reflect.Struct:\n\t\tp.doStruct(ctx, v, depth)\n\tcase reflect.Map:\n\t\tp.doMap(ctx, v, depth)\n\tcase reflect.Slice, reflect.Array:\n\t\tp.doSlice(ctx, v, depth)\n\tcase reflect.Interface:\n\t\tp.doInterface(ctx, v, depth)\n\tcase reflect.Chan,\n\t\treflect.Func,\n\t\treflect.UnsafePointer:\n\t\tp.do(ctx, v.Pointer(), depth)\n\tcase reflect.Uintptr:\n\t\tp.do(ctx, uintptr(v.Uint()), depth)\n\tdefault:\n\t\ts := fmt.Sprintf(\"(todo:%v,%v)\", v.Kind(), v.Type().String())\n\t\tp.appendStr(s)\n\t}\n}\n\n//----------\n\nfunc (p *Print) doPointer(ctx *Ctx, v reflect.Value, depth int) {\n\tif v.IsNil() {\n\t\tp.do(ctx, nil, depth)\n\t\treturn\n\t}\n\tif depth >= p.maxPtrDepth {\n\t\t// can't call v.Pointer() directly or it will panic (cgo structs)\n\t\t// \"panic: can't call pointer on a non-pointer Value\"\n\t\ttname := v.Type().Name()\n\t\tif tname == \"\" {\n\t\t\tp.appendStr(\"0x?\")\n\t\t\treturn\n\t\t}\n\n\t\tp.do(ctx, v.Pointer(), depth)\n\t\treturn\n\t}\n\n\tp.appendStr(\"&\")\n\te := v.Elem()\n\n\t// type name if in interface ctx\n\tif ctx.ValueInInterface(depth) {\n\t\tswitch e.Kind() {\n\t\tcase reflect.Struct:\n\t\t\tp.appendStr(e.Type().Name())\n\t\tcase reflect.Ptr:\n\t\t\tctx = ctx.WithInInterface(depth + 1)\n\t\t}\n\t}\n\n\tp.doValue(ctx, e, depth+1)\n}\n\nfunc (p *Print) doStruct(ctx *Ctx, v reflect.Value, depth int) {\n\tp.appendStr(\"{\")\n\tdefer p.appendStr(\"}\")\n\tvt := v.Type()\n\tfor i := 0; i < vt.NumField(); i++ {\n\t\tf := v.Field(i)\n\t\tif i > 0 {\n\t\t\tp.appendStr(\" \")\n\t\t}\n\t\tif p.maxedOut() {\n\t\t\tp.appendStr(\"...\")\n\t\t\tbreak\n\t\t}\n\t\tp.doValue(ctx, f, depth+1)\n\t}\n}\n\nfunc (p *Print) doMap(ctx *Ctx, v reflect.Value, depth int) {\n\tp.appendStr(\"map[\")\n\tdefer p.appendStr(\"]\")\n\titer := v.MapRange()\n\tfor i := 0; iter.Next(); i++ {\n\t\tif i > 0 {\n\t\t\tp.appendStr(\" \")\n\t\t}\n\t\tif p.maxedOut() {\n\t\t\tp.appendStr(\"...\")\n\t\t\tbreak\n\t\t}\n\t\tp.doValue(ctx, iter.Key(), depth+1)\n\t\tp.appendStr(\":\")\n\t\tp.doValue(ctx, iter.Value(), depth+1)\n\t}\n}\n\nfunc (p *Print) doSlice(ctx *Ctx, v reflect.Value, depth int) {\n\tp.appendStr(\"[\")\n\tdefer p.appendStr(\"]\")\n\tfor i := 0; i < v.Len(); i++ {\n\t\tu := v.Index(i)\n\t\tif i > 0 {\n\t\t\tp.appendStr(\" \")\n\t\t}\n\t\tif p.maxedOut() {\n\t\t\tp.appendStr(\"...\")\n\t\t\tbreak\n\t\t}\n\t\tp.doValue(ctx, u, depth+1)\n\t}\n}\n\nfunc (p *Print) doInterface(ctx *Ctx, v reflect.Value, depth int) {\n\te := v.Elem()\n\tif !e.IsValid() {\n\t\tp.appendStr(\"nil\")\n\t\treturn\n\t}\n\n\tif e.Kind() == reflect.Struct {\n\t\tp.appendStr(e.Type().Name())\n\t}\n\n\tctx = ctx.WithInInterface(depth + 1)\n\tp.doValue(ctx, e, depth+1)\n}\n\nfunc (p *Print) doBytes(v []byte) {\n\tu := p.limitBytes(v)\n\tp.appendStr(\"[\")\n\tfor i, v := range u {\n\t\tif i > 0 {\n\t\t\tp.appendStr(\" \")\n\t\t}\n\t\tp.appendStr(strconv.FormatUint(uint64(v), 10))\n\t}\n\tsliced := len(v) != len(u)\n\tif sliced {\n\t\tp.appendStr(\" ...\")\n\t}\n\tp.appendStr(\"]\")\n}\n\n//----------\n\nfunc (p *Print) catchPanic(ctx *Ctx, v interface{}, method string, depth int) {\n\t// ref: fmt/print.go:540\n\tif err := recover(); err != nil {\n\t\t// example: nil value receiver\n\t\tu := reflect.ValueOf(v)\n\t\tif u.Kind() == reflect.Ptr && u.IsNil() {\n\t\t\tp.do(ctx, nil, depth)\n\t\t\treturn\n\t\t}\n\t\t// TODO: err ignored\n\t\ts := fmt.Sprintf(\"(PANIC:%v())\", method)\n\t\tp.appendStr(s)\n\t}\n}\n\n//----------\n\nfunc (p *Print) maxedOut() bool {\n\treturn p.Max-len(p.Out) <= 0\n}\n\nfunc (p *Print) currentMax() int {\n\tmax := p.Max - len(p.Out)\n\tif max < 0 {\n\t\tmax = 0\n\t}\n\treturn max\n}\n\n//----------\n\nfunc (p *Print) limitStr(s string) string {\n\tif len(s) > 0 {\n\t\tmax := p.currentMax()\n\t\tif len(s) > max {\n\t\t\treturn s[:max] + \"...\"\n\t\t}\n\t}\n\treturn s\n}\n\nfunc (p *Print) limitBytes(b []byte) []byte {\n\tif len(b) > 0 {\n\t\tmax := p.currentMax()\n\t\tif len(b) > max {\n\t\t\treturn b[:max]\n\t\t}\n\t}\n\treturn b\n}\n\n//----------\n\nfunc (p *Print) appendStrQuoted(s string) {\n\tp.appendStr(strconv.Quote(s))\n}\n\nfunc (p *Print) appendStr(s string) {\n\tp.Out = append(p.Out, []byte(s)...)\n}\nfunc (p *Print) appendBytes(s []byte) {\n\tp.Out = append(p.Out, s...)\n}\n\n//----------\n\ntype Ctx struct {\n\tParent *Ctx\n\t// name/value (short names to avoid usage, still exporting it)\n\tN string\n\tV interface{}\n}\n\nfunc (ctx *Ctx) WithValue(name string, value interface{}) *Ctx {\n\treturn &Ctx{ctx, name, value}\n}\n\nfunc (ctx *Ctx) Value(name string) (interface{}, *Ctx) {\n\tfor c := ctx; c != nil; c = c.Parent {\n\t\tif c.N == name {\n\t\t\treturn c.V, c\n\t\t}\n\t}\n\treturn nil, nil\n}\n\n//----------\n\nfunc (ctx *Ctx) ValueBool(name string) bool {\n\tv, _ := ctx.Value(name)\n\tif v == nil {\n\t\treturn false\n\t}\n\treturn v.(bool)\n}\n\nfunc (ctx *Ctx) ValueIntM1(name string) int {\n\tv, _ := ctx.Value(name)\n\tif v == nil {\n\t\treturn -1\n\t}\n\treturn v.(int)\n}\n\n//----------\n\nfunc (ctx *Ctx) WithInInterface(depth int) *Ctx {\n\treturn ctx.WithValue(\"in_interface_depth\", depth)\n}\nfunc (ctx *Ctx) ValueInInterface(depth int) bool {\n\treturn ctx.ValueIntM1(\"in_interface_depth\") == depth\n}\n\n//----------\n\n//func (ctx *Ctx) WithInStruct(depth int) *Ctx {\n//\treturn ctx.WithValue(\"in_struct_depth\", depth)\n//}\n//func (ctx *Ctx) ValueInStruct(depth int) bool {\n//\treturn ctx.ValueIntM1(\"in_struct_depth\") == depth\n//}\n"},
-		{"structs.go", "package debug\n\nimport (\n\t\"fmt\"\n)\n\nfunc init() {\n\t// register structs to be able to encode/decode from interface{}\n\n\treg := RegisterStructure\n\n\treg(&ReqFilesDataMsg{})\n\treg(&FilesDataMsg{})\n\treg(&ReqStartMsg{})\n\treg(&LineMsg{})\n\treg([]*LineMsg{})\n\n\treg(&ItemValue{})\n\treg(&ItemList{})\n\treg(&ItemList2{})\n\treg(&ItemAssign{})\n\treg(&ItemSend{})\n\treg(&ItemCall{})\n\treg(&ItemCallEnter{})\n\treg(&ItemIndex{})\n\treg(&ItemIndex2{})\n\treg(&ItemKeyValue{})\n\treg(&ItemSelector{})\n\treg(&ItemTypeAssert{})\n\treg(&ItemBinary{})\n\treg(&ItemUnary{})\n\treg(&ItemUnaryEnter{})\n\treg(&ItemParen{})\n\treg(&ItemLiteral{})\n\treg(&ItemBranch{})\n\treg(&ItemStep{})\n\treg(&ItemAnon{})\n\treg(&ItemLabel{})\n}\n\n//----------\n\ntype ReqFilesDataMsg struct{}\ntype ReqStartMsg struct{}\n\n//----------\n\ntype LineMsg struct {\n\tFileIndex  int\n\tDebugIndex int\n\tOffset     int\n\tItem       Item\n}\n\ntype FilesDataMsg struct {\n\tData []*AnnotatorFileData\n}\n\ntype AnnotatorFileData struct {\n\tFileIndex int\n\tDebugLen  int\n\tFilename  string\n\tFileSize  int\n\tFileHash  []byte\n}\n\n//----------\n\ntype Item interface {\n}\ntype ItemValue struct {\n\tStr string\n}\ntype ItemList struct { // separated by \",\"\n\tList []Item\n}\ntype ItemList2 struct { // separated by \";\"\n\tList []Item\n}\ntype ItemAssign struct {\n\tLhs, Rhs *ItemList\n}\ntype ItemSend struct {\n\tChan, Value Item\n}\ntype ItemCall struct {\n\tName   string\n\tArgs   *ItemList\n\tResult Item\n}\ntype ItemCallEnter struct {\n\tName string\n\tArgs *ItemList\n}\ntype ItemIndex struct {\n\tResult Item\n\tExpr   Item\n\tIndex  Item\n}\ntype ItemIndex2 struct {\n\tResult         Item\n\tExpr           Item\n\tLow, High, Max Item\n\tSlice3         bool // 2 colons present\n}\ntype ItemKeyValue struct {\n\tKey   Item\n\tValue Item\n}\ntype ItemSelector struct {\n\tX   Item\n\tSel Item\n}\ntype ItemTypeAssert struct {\n\tX    Item\n\tType Item\n}\ntype ItemBinary struct {\n\tResult Item\n\tOp     int\n\tX, Y   Item\n}\ntype ItemUnary struct {\n\tResult Item\n\tOp     int\n\tX      Item\n}\ntype ItemUnaryEnter struct {\n\tOp int\n\tX  Item\n}\ntype ItemParen struct {\n\tX Item\n}\ntype ItemLiteral struct {\n\tFields *ItemList\n}\ntype ItemBranch struct{}\ntype ItemStep struct{}\ntype ItemAnon struct{}\ntype ItemLabel struct{}\n\n//----------\n\ntype V interface{}\n\n// ItemValue\nfunc IV(v V) Item {\n\treturn &ItemValue{Str: stringifyV(v)}\n}\n\n// ItemValue: raw string\nfunc IVs(s string) Item {\n\treturn &ItemValue{Str: s}\n}\n\n// ItemValue: typeof\nfunc IVt(v V) Item {\n\treturn &ItemValue{Str: fmt.Sprintf(\"%T\", v)}\n}\n\n// ItemValue: len\nfunc IVl(v V) Item {\n\treturn &ItemValue{Str: fmt.Sprintf(\"%v=len()\", v)}\n}\n\n// ItemList (\",\" and \";\")\nfunc IL(u ...Item) *ItemList {\n\treturn &ItemList{List: u}\n}\nfunc IL2(u ...Item) Item {\n\treturn &ItemList2{List: u}\n}\n\n// ItemAssign\nfunc IA(lhs, rhs *ItemList) Item {\n\treturn &ItemAssign{Lhs: lhs, Rhs: rhs}\n}\n\n// ItemSend\nfunc IS(ch, value Item) Item {\n\treturn &ItemSend{Chan: ch, Value: value}\n}\n\n// ItemCall\nfunc IC(name string, result Item, args ...Item) Item {\n\treturn &ItemCall{Name: name, Result: result, Args: IL(args...)}\n}\n\n// ItemCall: enter\nfunc ICe(name string, args ...Item) Item {\n\treturn &ItemCallEnter{Name: name, Args: IL(args...)}\n}\n\n// ItemIndex\nfunc II(result, expr, index Item) Item {\n\treturn &ItemIndex{Result: result, Expr: expr, Index: index}\n}\nfunc II2(result, expr, low, high, max Item, slice3 bool) Item {\n\treturn &ItemIndex2{Result: result, Expr: expr, Low: low, High: high, Max: max, Slice3: slice3}\n}\n\n// ItemKeyValue\nfunc IKV(key, value Item) Item {\n\treturn &ItemKeyValue{Key: key, Value: value}\n}\n\n// ItemSelector\nfunc ISel(x, sel Item) Item {\n\treturn &ItemSelector{X: x, Sel: sel}\n}\n\n// ItemTypeAssert\nfunc ITA(x, t Item) Item {\n\treturn &ItemTypeAssert{X: x, Type: t}\n}\n\n// ItemBinary\nfunc IB(result Item, op int, x, y Item) Item {\n\treturn &ItemBinary{Result: result, Op: op, X: x, Y: y}\n}\n\n// ItemUnary\nfunc IU(result Item, op int, x Item) Item {\n\treturn &ItemUnary{Result: result, Op: op, X: x}\n}\n\n// ItemUnary: enter\nfunc IUe(op int, x Item) Item {\n\treturn &ItemUnaryEnter{Op: op, X: x}\n}\n\n// ItemParen\nfunc IP(x Item) Item {\n\treturn &ItemParen{X: x}\n}\n\n// ItemLiteral\nfunc ILit(fields ...Item) Item {\n\treturn &ItemLiteral{Fields: IL(fields...)}\n}\n\n// ItemBranch\nfunc IBr() Item {\n\treturn &ItemBranch{}\n}\n\n// ItemStep\nfunc ISt() Item {\n\treturn &ItemStep{}\n}\n\n// ItemAnon\nfunc IAn() Item {\n\treturn &ItemAnon{}\n}\n\n// ItemLabel\nfunc ILa() Item {\n\treturn &ItemLabel{}\n}\n"}}
+		{"structs.go", "package debug\n\nimport (\n\t\"fmt\"\n\t\"time\"\n)\n\nfunc init() {\n\t// register structs to be able to encode/decode from interface{}\n\n\treg := RegisterStructure\n\n\treg(&ReqFilesDataMsg{})\n\treg(&FilesDataMsg{})\n\treg(&ReqStartMsg{})\n\treg(&LineMsg{})\n\treg([]*LineMsg{})\n\treg(&SetBreakpointsMsg{})\n\treg(&ContinueMsg{})\n\treg(&PausedMsg{})\n\treg(&ContinuedMsg{})\n\treg(&ReqValueMsg{})\n\treg(&ValueMsg{})\n\n\treg(&ItemValue{})\n\treg(&ItemList{})\n\treg(&ItemList2{})\n\treg(&ItemAssign{})\n\treg(&ItemSend{})\n\treg(&ItemCall{})\n\treg(&ItemCallEnter{})\n\treg(&ItemIndex{})\n\treg(&ItemIndex2{})\n\treg(&ItemKeyValue{})\n\treg(&ItemSelector{})\n\treg(&ItemTypeAssert{})\n\treg(&ItemBinary{})\n\treg(&ItemUnary{})\n\treg(&ItemUnaryEnter{})\n\treg(&ItemParen{})\n\treg(&ItemLiteral{})\n\treg(&ItemBranch{})\n\treg(&ItemStep{})\n\treg(&ItemAnon{})\n\treg(&ItemLabel{})\n}\n\n//----------\n\ntype ReqFilesDataMsg struct{}\ntype ReqStartMsg struct{}\n\n// Replaces the server breakpoints.\ntype SetBreakpointsMsg struct {\n\tBreakpoints []*Breakpoint\n}\n\ntype Breakpoint struct {\n\tFileIndex int\n\tOffset    int    // annotation offset\n\tCond      string // regexp matched against the item values (optional)\n}\n\n// Continues the paused goroutine (all if goid is zero). Step pauses the goroutine again at the next line msg.\ntype ContinueMsg struct {\n\tGoid int64\n\tStep bool\n}\n\n// Sent after the line msg that paused the goroutine.\ntype PausedMsg struct {\n\tGoid      int64\n\tFileIndex int\n\tOffset    int\n}\n\ntype ContinuedMsg struct {\n\tGoid int64\n}\n\n// Requests the tree of a registered value (ItemValue.Id).\ntype ReqValueMsg struct {\n\tId      int // request id, returned in the reply\n\tValueId int\n\tPath    []int // children indexes\n\tDepth   int   // levels of children\n}\n\ntype ValueMsg struct {\n\tId   int // request id\n\tNode *ValueNode\n\tErr  string\n}\n\ntype ValueNode struct {\n\tName      string // field name, map key, or slice index\n\tType      string\n\tStr       string // short value\n\tLen       int    // -1 if not applicable\n\tKids      []*ValueNode\n\tMore      bool // has children that were not sent (depth reached)\n\tTruncated bool // not all children were sent (max children)\n}\n\n//----------\n\ntype LineMsg struct {\n\tFileIndex  int\n\tDebugIndex int\n\tOffset     int\n\tItem       Item\n\tGoid       int64         // goroutine id\n\tTime       time.Duration // since the program start (monotonic)\n}\n\ntype FilesDataMsg struct {\n\tData []*AnnotatorFileData\n}\n\ntype AnnotatorFileData struct {\n\tFileIndex int\n\tDebugLen  int\n\tFilename  string\n\tFileSize  int\n\tFileHash  []byte\n\tOffsets   []int // debug stmts offsets, sorted (allows breakpoints on lines that didn't run yet)\n}\n\n//----------\n\ntype Item interface {\n}\ntype ItemValue struct {\n\tStr string\n\tId  int // inspectable value id (zero if not inspectable or not paused)\n\tv   V   // not encoded, registered if the goroutine pauses\n}\ntype ItemList struct { // separated by \",\"\n\tList []Item\n}\ntype ItemList2 struct { // separated by \";\"\n\tList []Item\n}\ntype ItemAssign struct {\n\tLhs, Rhs *ItemList\n}\ntype ItemSend struct {\n\tChan, Value Item\n}\ntype ItemCall struct {\n\tName   string\n\tArgs   *ItemList\n\tResult Item\n}\ntype ItemCallEnter struct {\n\tName string\n\tArgs *ItemList\n}\ntype ItemIndex struct {\n\tResult Item\n\tExpr   Item\n\tIndex  Item\n}\ntype ItemIndex2 struct {\n\tResult         Item\n\tExpr           Item\n\tLow, High, Max Item\n\tSlice3         bool // 2 colons present\n}\ntype ItemKeyValue struct {\n\tKey   Item\n\tValue Item\n}\ntype ItemSelector struct {\n\tX   Item\n\tSel Item\n}\ntype ItemTypeAssert struct {\n\tX    Item\n\tType Item\n}\ntype ItemBinary struct {\n\tResult Item\n\tOp     int\n\tX, Y   Item\n}\ntype ItemUnary struct {\n\tResult Item\n\tOp     int\n\tX      Item\n}\ntype ItemUnaryEnter struct {\n\tOp int\n\tX  Item\n}\ntype ItemParen struct {\n\tX Item\n}\ntype ItemLiteral struct {\n\tFields *ItemList\n}\ntype ItemBranch struct{}\ntype ItemStep struct{}\ntype ItemAnon struct{}\ntype ItemLabel struct{}\n\n//----------\n\ntype V interface{}\n\n// ItemValue\nfunc IV(v V) Item {\n\treturn &ItemValue{Str: stringifyV(v), v: v}\n}\n\n// ItemValue: raw string\nfunc IVs(s string) Item {\n\treturn &ItemValue{Str: s}\n}\n\n// ItemValue: typeof\nfunc IVt(v V) Item {\n\treturn &ItemValue{Str: fmt.Sprintf(\"%T\", v)}\n}\n\n// ItemValue: len\nfunc IVl(v V) Item {\n\treturn &ItemValue{Str: fmt.Sprintf(\"%v=len()\", v)}\n}\n\n// ItemList (\",\" and \";\")\nfunc IL(u ...Item) *ItemList {\n\treturn &ItemList{List: u}\n}\nfunc IL2(u ...Item) Item {\n\treturn &ItemList2{List: u}\n}\n\n// ItemAssign\nfunc IA(lhs, rhs *ItemList) Item {\n\treturn &ItemAssign{Lhs: lhs, Rhs: rhs}\n}\n\n// ItemSend\nfunc IS(ch, value Item) Item {\n\treturn &ItemSend{Chan: ch, Value: value}\n}\n\n// ItemCall\nfunc IC(name string, result Item, args ...Item) Item {\n\treturn &ItemCall{Name: name, Result: result, Args: IL(args...)}\n}\n\n// ItemCall: enter\nfunc ICe(name string, args ...Item) Item {\n\treturn &ItemCallEnter{Name: name, Args: IL(args...)}\n}\n\n// ItemIndex\nfunc II(result, expr, index Item) Item {\n\treturn &ItemIndex{Result: result, Expr: expr, Index: index}\n}\nfunc II2(result, expr, low, high, max Item, slice3 bool) Item {\n\treturn &ItemIndex2{Result: result, Expr: expr, Low: low, High: high, Max: max, Slice3: slice3}\n}\n\n// ItemKeyValue\nfunc IKV(key, value Item) Item {\n\treturn &ItemKeyValue{Key: key, Value: value}\n}\n\n// ItemSelector\nfunc ISel(x, sel Item) Item {\n\treturn &ItemSelector{X: x, Sel: sel}\n}\n\n// ItemTypeAssert\nfunc ITA(x, t Item) Item {\n\treturn &ItemTypeAssert{X: x, Type: t}\n}\n\n// ItemBinary\nfunc IB(result Item, op int, x, y Item) Item {\n\treturn &ItemBinary{Result: result, Op: op, X: x, Y: y}\n}\n\n// ItemUnary\nfunc IU(result Item, op int, x Item) Item {\n\treturn &ItemUnary{Result: result, Op: op, X: x}\n}\n\n// ItemUnary: enter\nfunc IUe(op int, x Item) Item {\n\treturn &ItemUnaryEnter{Op: op, X: x}\n}\n\n// ItemParen\nfunc IP(x Item) Item {\n\treturn &ItemParen{X: x}\n}\n\n// ItemLiteral\nfunc ILit(fields ...Item) Item {\n\treturn &ItemLiteral{Fields: IL(fields...)}\n}\n\n// ItemBranch\nfunc IBr() Item {\n\treturn &ItemBranch{}\n}\n\n// ItemStep\nfunc ISt() Item {\n\treturn &ItemStep{}\n}\n\n// ItemAnon\nfunc IAn() Item {\n\treturn &ItemAnon{}\n}\n\n// ItemLabel\nfunc ILa() Item {\n\treturn &ItemLabel{}\n}\n"},
+		{"valuetree.go", "package debug\n\nimport (\n\t\"fmt\"\n\t\"reflect\"\n\t\"sort\"\n\t\"strconv\"\n\t\"sync\"\n)\n\n// Values of composite types (pointers, structs, maps, slices, ...) can be inspected as a tree by the client, but only while the goroutine that sent them is paused (reading values that the program is changing can crash it, ex: concurrent map read and write). The values are registered when the goroutine pauses and removed when it continues. Note that other goroutines (not paused) could still be changing the values.\n\nvar values valueRegistry\n\ntype valueRegistry struct {\n\tmu    sync.Mutex\n\tlast  int                 // last id given (ids start at 1)\n\tm     map[int]interface{} // [id]\n\tgoids map[int64][]int     // [goid] ids\n}\n\n// Sets the ids of the inspectable values of the item. Should be called when the goroutine is paused.\nfunc (vr *valueRegistry) addPaused(goid int64, item Item) {\n\tvr.mu.Lock()\n\tdefer vr.mu.Unlock()\n\tif vr.m == nil {\n\t\tvr.m = map[int]interface{}{}\n\t\tvr.goids = map[int64][]int{}\n\t}\n\twalkItemValues(item, func(iv *ItemValue) bool {\n\t\tif iv.v != nil && inspectable(reflect.ValueOf(iv.v)) {\n\t\t\tvr.last++\n\t\t\tiv.Id = vr.last\n\t\t\tvr.m[iv.Id] = iv.v\n\t\t\tvr.goids[goid] = append(vr.goids[goid], iv.Id)\n\t\t}\n\t\treturn true\n\t})\n}\n\n// Should be called when the goroutine continues.\nfunc (vr *valueRegistry) removePaused(goid int64) {\n\tvr.mu.Lock()\n\tdefer vr.mu.Unlock()\n\tfor _, id := range vr.goids[goid] {\n\t\tdelete(vr.m, id)\n\t}\n\tdelete(vr.goids, goid)\n}\n\nfunc (vr *valueRegistry) get(id int) (interface{}, bool) {\n\tvr.mu.Lock()\n\tdefer vr.mu.Unlock()\n\tv, ok := vr.m[id]\n\treturn v, ok\n}\n\n//----------\n\n// Inspects the value with the id from the registry.\nfunc inspectValue(req *ReqValueMsg) *ValueMsg {\n\tmsg := &ValueMsg{Id: req.Id}\n\tv, ok := values.get(req.ValueId)\n\tif !ok {\n\t\tmsg.Err = \"value not available (goroutine not paused)\"\n\t\treturn msg\n\t}\n\tnode, err := ValueTree(v, req.Path, req.Depth)\n\tif err != nil {\n\t\tmsg.Err = err.Error()\n\t\treturn msg\n\t}\n\tmsg.Node = node\n\treturn msg\n}\n\n// Builds the tree of the value found at the path (children indexes) with the given depth of children.\nfunc ValueTree(v interface{}, path []int, depth int) (_ *ValueNode, err error) {\n\t// ex: nil map of a value changed by another goroutine (a concurrent map read and write is not recoverable)\n\tdefer func() {\n\t\tif r := recover(); r != nil {\n\t\t\terr = fmt.Errorf(\"panic: %v\", r)\n\t\t}\n\t}()\n\n\trv := reflect.ValueOf(v)\n\tname := \"\"\n\tfor _, k := range path {\n\t\tkids, _ := valueKids(rv)\n\t\tif k < 0 || k >= len(kids) {\n\t\t\treturn nil, fmt.Errorf(\"bad path index: %v\", k)\n\t\t}\n\t\tname, rv = kids[k].name, kids[k].v\n\t}\n\treturn valueNode(name, rv, depth), nil\n}\n\nfunc valueNode(name string, v reflect.Value, depth int) *ValueNode {\n\tnode := &ValueNode{Name: name, Len: -1}\n\tif !v.IsValid() {\n\t\tnode.Str = \"nil\"\n\t\treturn node\n\t}\n\tnode.Type = v.Type().String()\n\tnode.Str = valueStr(v)\n\tswitch v.Kind() {\n\tcase reflect.Map, reflect.Slice, reflect.Array, reflect.String, reflect.Chan:\n\t\tnode.Len = v.Len()\n\t}\n\tif !inspectable(v) {\n\t\treturn node\n\t}\n\tif depth <= 0 {\n\t\tnode.More = true\n\t\treturn node\n\t}\n\tkids, truncated := valueKids(v)\n\tfor _, kid := range kids {\n\t\tnode.Kids = append(node.Kids, valueNode(kid.name, kid.v, depth-1))\n\t}\n\tnode.Truncated = truncated\n\treturn node\n}\n\n//----------\n\nconst valueMaxKids = 100\n\ntype valueKid struct {\n\tname string\n\tv    reflect.Value\n}\n\n// Children of the value (pointers and interfaces are followed). Returns true if the children were truncated.\nfunc valueKids(v reflect.Value) ([]*valueKid, bool) {\n\tv, ok := valueElem(v)\n\tif !ok {\n\t\treturn nil, false\n\t}\n\tu := []*valueKid{}\n\tswitch v.Kind() {\n\tcase reflect.Struct:\n\t\tvt := v.Type()\n\t\tfor i := 0; i < vt.NumField(); i++ {\n\t\t\tu = append(u, &valueKid{vt.Field(i).Name, v.Field(i)})\n\t\t}\n\tcase reflect.Map:\n\t\t// sorted to have the same paths in later requests\n\t\tfor _, k := range v.MapKeys() {\n\t\t\tu = append(u, &valueKid{valueStr(k), v.MapIndex(k)})\n\t\t}\n\t\tsort.Slice(u, func(a, b int) bool {\n\t\t\treturn u[a].name < u[b].name\n\t\t})\n\tcase reflect.Slice, reflect.Array:\n\t\tn := v.Len()\n\t\tif n > valueMaxKids {\n\t\t\tn = valueMaxKids\n\t\t}\n\t\tfor i := 0; i < n; i++ {\n\t\t\tu = append(u, &valueKid{\"[\" + strconv.Itoa(i) + \"]\", v.Index(i)})\n\t\t}\n\t\treturn u, n < v.Len()\n\t}\n\tif len(u) > valueMaxKids {\n\t\treturn u[:valueMaxKids], true\n\t}\n\treturn u, false\n}\n\nfunc inspectable(v reflect.Value) bool {\n\tv, ok := valueElem(v)\n\tif !ok {\n\t\treturn false\n\t}\n\tswitch v.Kind() {\n\tcase reflect.Struct:\n\t\treturn v.NumField() > 0\n\tcase reflect.Map, reflect.Slice, reflect.Array:\n\t\treturn v.Len() > 0\n\t}\n\treturn false\n}\n\n// Follows pointers and interfaces. Returns false if nil (or a cycle of pointers).\nfunc valueElem(v reflect.Value) (reflect.Value, bool) {\n\tfor i := 0; v.IsValid(); i++ {\n\t\tswitch v.Kind() {\n\t\tcase reflect.Ptr, reflect.Interface:\n\t\t\tif v.IsNil() || i >= 10 {\n\t\t\t\treturn v, false\n\t\t\t}\n\t\t\tv = v.Elem()\n\t\tdefault:\n\t\t\treturn v, true\n\t\t}\n\t}\n\treturn v, false\n}\n\nfunc valueStr(v reflect.Value) string {\n\tp := NewPrint(80, 1)\n\tif v.CanInterface() {\n\t\treturn string(p.Do(v.Interface()))\n\t}\n\t// unexported field: can't use the value methods (ex: String())\n\tctx := &Ctx{}\n\tctx = ctx.WithInInterface(0)\n\tp.doValue(ctx, v, 0)\n\treturn string(p.Out)\n}\n"}}
 }
@@ -0,0 +1,246 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/core/godebug/debug"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Breakpoints are kept by the manager to be used in the next sessions.
+type GDBreakpoints struct {
+	sync.Mutex
+	m map[GDBreakpoint]string // [breakpoint]cond
+}
+
+type GDBreakpoint struct {
+	Filename string
+	Offset   int // annotation offset
+}
+
+func NewGDBreakpoints() *GDBreakpoints {
+	return &GDBreakpoints{m: map[GDBreakpoint]string{}}
+}
+
+// Returns true if the breakpoint was added.
+func (bps *GDBreakpoints) toggle(bp GDBreakpoint) bool {
+	bps.Lock()
+	defer bps.Unlock()
+	if _, ok := bps.m[bp]; ok {
+		delete(bps.m, bp)
+		return false
+	}
+	bps.m[bp] = ""
+	return true
+}
+
+func (bps *GDBreakpoints) set(bp GDBreakpoint, cond string) error {
+	if _, err := regexp.Compile(cond); err != nil {
+		return err
+	}
+	bps.Lock()
+	defer bps.Unlock()
+	bps.m[bp] = cond
+	return nil
+}
+
+func (bps *GDBreakpoints) clear() {
+	bps.Lock()
+	defer bps.Unlock()
+	bps.m = map[GDBreakpoint]string{}
+}
+
+// Sorted by filename and offset.
+func (bps *GDBreakpoints) list() ([]GDBreakpoint, []string) {
+	bps.Lock()
+	defer bps.Unlock()
+	u := []GDBreakpoint{}
+	for bp := range bps.m {
+		u = append(u, bp)
+	}
+	sort.Slice(u, func(a, b int) bool {
+		if u[a].Filename != u[b].Filename {
+			return u[a].Filename < u[b].Filename
+		}
+		return u[a].Offset < u[b].Offset
+	})
+	conds := []string{}
+	for _, bp := range u {
+		conds = append(conds, bps.m[bp])
+	}
+	return u, conds
+}
+
+//----------
+
+// Should be called under UI goroutine (reads the erow cursor).
+func (gdm *GoDebugManager) ToggleCursorBreakpoint(erow *ERow) error {
+	gdm.inst.Lock()
+	defer gdm.inst.Unlock()
+	if gdm.inst.inst == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	return gdm.inst.inst.setCursorBreakpoint(erow, "", true)
+}
+
+// Should be called under UI goroutine (reads the erow cursor).
+func (gdm *GoDebugManager) SetCursorBreakpoint(erow *ERow, cond string) error {
+	gdm.inst.Lock()
+	defer gdm.inst.Unlock()
+	if gdm.inst.inst == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	return gdm.inst.inst.setCursorBreakpoint(erow, cond, false)
+}
+
+func (gdm *GoDebugManager) ClearBreakpoints() error {
+	gdm.bps.clear()
+	gdm.inst.Lock()
+	defer gdm.inst.Unlock()
+	if gdm.inst.inst != nil {
+		return gdm.inst.inst.sendBreakpoints()
+	}
+	return nil
+}
+
+// Should be called under UI goroutine.
+func (gdm *GoDebugManager) ListBreakpoints() {
+	bps, conds := gdm.bps.list()
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "breakpoints: %d\n", len(bps))
+	for i, bp := range bps {
+		fmt.Fprintf(buf, "%v", godebugFilePosString(gdm.ed, bp.Filename, bp.Offset))
+		if conds[i] != "" {
+			fmt.Fprintf(buf, ": cond=%q", conds[i])
+		}
+		fmt.Fprintf(buf, "\n")
+	}
+
+	erow, _ := ExistingERowOrNewBasic(gdm.ed, "+GoDebugBreakpoints")
+	erow.Row.TextArea.SetBytesClearPos(buf.Bytes())
+	erow.Flash()
+}
+
+// Continues the paused goroutine (all if goid is zero).
+func (gdm *GoDebugManager) Continue(goid int64, step bool) error {
+	gdm.inst.Lock()
+	defer gdm.inst.Unlock()
+	if gdm.inst.inst == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	cmd, ok := gdm.inst.inst.getCmd()
+	if !ok {
+		return fmt.Errorf("missing godebug cmd")
+	}
+	return cmd.Continue(goid, step)
+}
+
+//----------
+
+func (gdi *GoDebugInstance) toggleBreakpoint(filename string, annIndex int) {
+	msg, ok := gdi.di.annMsg(filename, annIndex)
+	if !ok {
+		return
+	}
+	bp := GDBreakpoint{Filename: gdi.di.filename(msg.dbgLineMsg.FileIndex), Offset: msg.dbgLineMsg.Offset}
+	if err := gdi.toggleBreakpoint2(bp); err != nil {
+		gdi.gdm.Printf("error: %v\n", err)
+	}
+}
+
+func (gdi *GoDebugInstance) toggleBreakpoint2(bp GDBreakpoint) error {
+	if gdi.gdm.bps.toggle(bp) {
+		gdi.gdm.Printf("breakpoint added\n")
+	} else {
+		gdi.gdm.Printf("breakpoint removed\n")
+	}
+	return gdi.sendBreakpoints()
+}
+
+// The breakpoint is resolved from the annotator file data (works on lines that didn't run yet).
+func (gdi *GoDebugInstance) setCursorBreakpoint(erow *ERow, cond string, toggle bool) error {
+	ta := erow.Row.TextArea
+	bp, err := gdi.di.lineBreakpoint(erow.Info.Name(), ta.RW(), ta.CursorIndex())
+	if err != nil {
+		return err
+	}
+	if toggle {
+		return gdi.toggleBreakpoint2(bp)
+	}
+	if err := gdi.gdm.bps.set(bp, cond); err != nil {
+		return err
+	}
+	return gdi.sendBreakpoints()
+}
+
+func (gdi *GoDebugInstance) sendBreakpoints() error {
+	cmd, ok := gdi.getCmd()
+	if !ok {
+		return nil // will be sent at start
+	}
+	return gdi.sendBreakpoints2(cmd)
+}
+
+func (gdi *GoDebugInstance) sendBreakpoints2(cmd *godebug.Cmd) error {
+	bps, conds := gdi.gdm.bps.list()
+	u := []*debug.Breakpoint{}
+	for i, bp := range bps {
+		findex, ok := gdi.di.FilesIndex(bp.Filename)
+		if !ok {
+			continue // not part of this session
+		}
+		u = append(u, &debug.Breakpoint{FileIndex: findex, Offset: bp.Offset, Cond: conds[i]})
+	}
+	return cmd.SetBreakpoints(u)
+}
+
+//----------
+
+func (gdi *GoDebugInstance) handlePausedMsg(msg *debug.PausedMsg) {
+	gdi.di.setPaused(msg)
+	filename := gdi.di.filename(msg.FileIndex)
+	gdi.ed.UI.RunOnUIGoRoutine(func() {
+		pos := godebugFilePosString(gdi.ed, filename, msg.Offset)
+		gdi.gdm.Printf("paused: goroutine %d: %v\n", msg.Goid, pos)
+		gdi.updateUI2()
+		gdi.showSelectedLine(gdi.ed.GoodRowPos())
+	})
+}
+
+func (gdi *GoDebugInstance) handleContinuedMsg(msg *debug.ContinuedMsg) {
+	gdi.di.setContinued(msg.Goid)
+}
+
+//----------
+
+// Breakpoint at the first debug stmt of the line of the index.
+func (di *GDDataIndex) lineBreakpoint(filename string, rd iorw.ReaderAt, index int) (GDBreakpoint, error) {
+	di.RLock()
+	defer di.RUnlock()
+	findex, ok := di.FilesIndex(filename)
+	if !ok {
+		return GDBreakpoint{}, fmt.Errorf("file not in the debug session: %v", filename)
+	}
+	if di.filesEdited[findex] {
+		return GDBreakpoint{}, fmt.Errorf("file edited: offsets don't match")
+	}
+	a, err := iorw.LineStartIndex(rd, index)
+	if err != nil {
+		return GDBreakpoint{}, err
+	}
+	b, _, err := iorw.LineEndIndex(rd, index)
+	if err != nil {
+		return GDBreakpoint{}, err
+	}
+	afd := di.afds[findex]
+	k := sort.SearchInts(afd.Offsets, a)
+	if k >= len(afd.Offsets) || afd.Offsets[k] > b {
+		return GDBreakpoint{}, fmt.Errorf("no debug step at line")
+	}
+	return GDBreakpoint{Filename: afd.Filename, Offset: afd.Offsets[k]}, nil
+}
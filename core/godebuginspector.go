@@ -0,0 +1,233 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/core/godebug/debug"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func (gdm *GoDebugManager) InspectorToggle(index int) {
+	gdm.inst.Lock()
+	defer gdm.inst.Unlock()
+	if gdm.inst.inst != nil {
+		gdm.inst.inst.insp.toggle(index)
+	}
+}
+
+//----------
+
+// Shows the values of a debug step as an expandable tree in the context floatbox. The children are requested to the running program when a node is expanded. Should be used under the UI goroutine.
+type GDInspector struct {
+	gdi   *GoDebugInstance
+	roots []*GDInspectNode
+	lines []*GDInspectNode // [line] rendered lines (nil if not a node)
+	str   string           // rendered content (detects other content in the floatbox)
+
+	reqs      map[int]*GDInspectNode // [reqId] waiting for children
+	lastReqId int
+}
+
+func NewGDInspector(gdi *GoDebugInstance) *GDInspector {
+	return &GDInspector{gdi: gdi, reqs: map[int]*GDInspectNode{}}
+}
+
+//----------
+
+func (insp *GDInspector) inspect(filename string, annIndex int) {
+	msg, ok := insp.gdi.di.annMsg(filename, annIndex)
+	if !ok {
+		return
+	}
+	insp.roots = nil
+	insp.reqs = map[int]*GDInspectNode{}
+	godebug.WalkItems(msg.dbgLineMsg.Item, func(item debug.Item) {
+		if iv, ok := item.(*debug.ItemValue); ok && iv.Id != 0 {
+			vn := &debug.ValueNode{Str: iv.Str, Len: -1, More: true}
+			insp.roots = append(insp.roots, &GDInspectNode{vnode: vn, valueId: iv.Id})
+		}
+	})
+
+	ed := insp.gdi.ed
+	ed.ifbw.Cancel() // cancel info floatbox run that could overwrite the content
+	cfb := ed.ifbw.ui()
+	if p, err := ed.UI.QueryPointer(); err == nil {
+		cfb.RefPoint = p
+	}
+	cfb.TextArea.ClearPos()
+	insp.render()
+	cfb.Show()
+}
+
+// Expands or collapses the node at the floatbox text index.
+func (insp *GDInspector) toggle(index int) bool {
+	if insp.str == "" {
+		return false
+	}
+	cfb := insp.gdi.ed.ifbw.ui()
+	b, err := iorw.ReadFastFull(cfb.TextArea.RW())
+	if err != nil || string(b) != insp.str {
+		return false // not showing the inspector
+	}
+	if index < 0 || index > len(insp.str) {
+		return false
+	}
+	line := strings.Count(insp.str[:index], "\n")
+	if line >= len(insp.lines) || insp.lines[line] == nil {
+		return false
+	}
+	node := insp.lines[line]
+
+	switch {
+	case node.expanded:
+		node.expanded = false
+	case node.kids != nil:
+		node.expanded = true
+	case node.vnode.More && !node.loading:
+		node.err = ""
+		if err := insp.request(node); err != nil {
+			node.err = err.Error()
+		} else {
+			node.loading = true
+		}
+	default:
+		return true // leaf
+	}
+	insp.render()
+	return true
+}
+
+func (insp *GDInspector) request(node *GDInspectNode) error {
+	cmd, ok := insp.gdi.getCmd()
+	if !ok {
+		return fmt.Errorf("missing godebug cmd")
+	}
+	insp.lastReqId++
+	req := &debug.ReqValueMsg{
+		Id:      insp.lastReqId,
+		ValueId: node.valueId,
+		Path:    node.path,
+		Depth:   1,
+	}
+	if err := cmd.RequestValue(req); err != nil {
+		return err
+	}
+	insp.reqs[req.Id] = node
+	return nil
+}
+
+func (insp *GDInspector) handleValueMsg(msg *debug.ValueMsg) {
+	node, ok := insp.reqs[msg.Id]
+	if !ok {
+		return // late reply (ex: other annotation inspected)
+	}
+	delete(insp.reqs, msg.Id)
+	node.loading = false
+	if msg.Err != "" {
+		node.err = msg.Err
+	} else {
+		node.setValueNode(msg.Node)
+		node.expanded = true
+	}
+
+	// update if still showing the inspector
+	cfb := insp.gdi.ed.ifbw.ui()
+	if b, err := iorw.ReadFastFull(cfb.TextArea.RW()); err == nil && string(b) == insp.str {
+		insp.render()
+	}
+}
+
+//----------
+
+func (insp *GDInspector) render() {
+	insp.lines = nil
+	sb := &strings.Builder{}
+	if len(insp.roots) == 0 {
+		insp.lines = append(insp.lines, nil)
+		sb.WriteString("godebug: no inspectable values")
+	}
+	var rec func(*GDInspectNode, int)
+	rec = func(node *GDInspectNode, depth int) {
+		if len(insp.lines) > 0 {
+			sb.WriteString("\n")
+		}
+		insp.lines = append(insp.lines, node)
+		sb.WriteString(strings.Repeat("  ", depth))
+		sb.WriteString(node.label())
+		if !node.expanded {
+			return
+		}
+		for _, kid := range node.kids {
+			rec(kid, depth+1)
+		}
+		if node.vnode.Truncated {
+			sb.WriteString("\n")
+			insp.lines = append(insp.lines, nil)
+			sb.WriteString(strings.Repeat("  ", depth+1) + "  ...")
+		}
+	}
+	for _, root := range insp.roots {
+		rec(root, 0)
+	}
+	insp.str = sb.String()
+
+	cfb := insp.gdi.ed.ifbw.ui()
+	cfb.SetStrClearHistory(insp.str)
+}
+
+//----------
+
+type GDInspectNode struct {
+	vnode    *debug.ValueNode
+	valueId  int   // registered value in the program
+	path     []int // children indexes from the registered value
+	kids     []*GDInspectNode
+	expanded bool
+	loading  bool
+	err      string
+}
+
+func (node *GDInspectNode) setValueNode(vn *debug.ValueNode) {
+	name := node.vnode.Name // the root node has no name
+	node.vnode = vn
+	node.vnode.Name = name
+	node.kids = []*GDInspectNode{}
+	for i, kvn := range vn.Kids {
+		path := append(append([]int{}, node.path...), i)
+		kid := &GDInspectNode{vnode: kvn, valueId: node.valueId, path: path}
+		node.kids = append(node.kids, kid)
+	}
+}
+
+func (node *GDInspectNode) label() string {
+	vn := node.vnode
+	sb := &strings.Builder{}
+	switch {
+	case node.expanded:
+		sb.WriteString("- ")
+	case len(node.kids) > 0 || vn.More:
+		sb.WriteString("+ ")
+	default:
+		sb.WriteString("  ")
+	}
+	if vn.Name != "" {
+		sb.WriteString(vn.Name + ": ")
+	}
+	sb.WriteString(vn.Str)
+	if vn.Type != "" {
+		if vn.Len >= 0 {
+			fmt.Fprintf(sb, " (%v, len=%d)", vn.Type, vn.Len)
+		} else {
+			fmt.Fprintf(sb, " (%v)", vn.Type)
+		}
+	}
+	if node.loading {
+		sb.WriteString(" (loading...)")
+	}
+	if node.err != "" {
+		fmt.Fprintf(sb, " (error: %v)", node.err)
+	}
+	return sb.String()
+}
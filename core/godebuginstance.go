@@ -27,8 +27,10 @@ import (
 const updatesPerSecond = 15
 
 type GoDebugManager struct {
-	ed   *Editor
-	inst struct {
+	ed      *Editor
+	bps     *GDBreakpoints
+	watches *GDWatches
+	inst    struct {
 		sync.Mutex
 		inst   *GoDebugInstance
 		cancel context.CancelFunc
@@ -36,7 +38,7 @@ type GoDebugManager struct {
 }
 
 func NewGoDebugManager(ed *Editor) *GoDebugManager {
-	gdm := &GoDebugManager{ed: ed}
+	gdm := &GoDebugManager{ed: ed, bps: NewGDBreakpoints(), watches: NewGDWatches()}
 	return gdm
 }
 
@@ -109,6 +111,26 @@ func (gdm *GoDebugManager) AnnotationFind(s string) error {
 	return gdm.inst.inst.annotationFind(s)
 }
 
+func (gdm *GoDebugManager) ListGoroutines() error {
+	gdm.inst.Lock()
+	defer gdm.inst.Unlock()
+	if gdm.inst.inst == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	gdm.inst.inst.listGoroutines()
+	return nil
+}
+
+// A goid of zero clears the filter.
+func (gdm *GoDebugManager) SetGoroutineFilter(goid int64) error {
+	gdm.inst.Lock()
+	defer gdm.inst.Unlock()
+	if gdm.inst.inst == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	return gdm.inst.inst.setGoroutineFilter(goid)
+}
+
 func (gdm *GoDebugManager) UpdateUIERowInfo(info *ERowInfo) {
 	gdm.inst.Lock()
 	defer gdm.inst.Unlock()
@@ -123,12 +145,18 @@ type GoDebugInstance struct {
 	ed           *Editor
 	gdm          *GoDebugManager
 	di           *GDDataIndex
+	insp         *GDInspector
 	erowExecWait sync.WaitGroup
+	cmd          struct {
+		sync.Mutex
+		cmd *godebug.Cmd // running cmd (nil if not started)
+	}
 }
 
 func startGoDebugInstance(ctx context.Context, ed *Editor, gdm *GoDebugManager, erow *ERow, args []string) (*GoDebugInstance, error) {
 	gdi := &GoDebugInstance{ed: ed, gdm: gdm}
 	gdi.di = NewGDDataIndex(ed)
+	gdi.insp = NewGDInspector(gdi)
 	if err := gdi.start2(ctx, erow, args); err != nil {
 		return nil, err
 	}
@@ -190,11 +218,26 @@ func (gdi *GoDebugInstance) runCmd(ctx context.Context, erow *ERow, args []strin
 		return nil
 	}
 
+	gdi.setCmd(cmd)
+	defer gdi.setCmd(nil)
+
 	gdi.clientMsgsLoop(ctx, w, cmd) // blocking
 
 	return cmd.Wait()
 }
 
+func (gdi *GoDebugInstance) setCmd(cmd *godebug.Cmd) {
+	gdi.cmd.Lock()
+	defer gdi.cmd.Unlock()
+	gdi.cmd.cmd = cmd
+}
+
+func (gdi *GoDebugInstance) getCmd() (*godebug.Cmd, bool) {
+	gdi.cmd.Lock()
+	defer gdi.cmd.Unlock()
+	return gdi.cmd.cmd, gdi.cmd.cmd != nil
+}
+
 //----------
 
 func (gdi *GoDebugInstance) selectERowAnnotation(erow *ERow, ev *ui.TextAreaSelectAnnotationEvent) {
@@ -215,6 +258,12 @@ func (gdi *GoDebugInstance) selectERowAnnotation2(erow *ERow, ev *ui.TextAreaSel
 	case ui.TASelAnnTypePrintAllPrevious:
 		gdi.printIndexAllPrevious(erow, ev.AnnotationIndex, ev.Offset)
 		return false
+	case ui.TASelAnnTypeBreakpoint:
+		gdi.toggleBreakpoint(erow.Info.Name(), ev.AnnotationIndex)
+		return false
+	case ui.TASelAnnTypeInspect:
+		gdi.insp.inspect(erow.Info.Name(), ev.AnnotationIndex)
+		return false
 	default:
 		log.Printf("todo: %#v", ev)
 	}
@@ -234,6 +283,46 @@ func (gdi *GoDebugInstance) annotationFind(s string) error {
 
 //----------
 
+func (gdi *GoDebugInstance) setGoroutineFilter(goid int64) error {
+	if err := gdi.di.setGoFilter(goid); err != nil {
+		return err
+	}
+	gdi.openArrivalIndexERow()
+	gdi.updateUIShowLine(gdi.ed.GoodRowPos())
+	return nil
+}
+
+// Should be called under UI goroutine.
+func (gdi *GoDebugInstance) listGoroutines() {
+	gs, filter := gdi.di.goroutinesInfo()
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "goroutines: %d", len(gs))
+	if filter != 0 {
+		fmt.Fprintf(buf, " (filter: %d)", filter)
+	}
+	fmt.Fprintf(buf, "\n")
+	for _, g := range gs {
+		pos := godebugFilePosString(gdi.ed, g.filename, g.last.Offset)
+		fmt.Fprintf(buf, "goroutine %d: %v: #%d at %v (%d msgs)\n", g.id, pos, g.lastArrivalIndex, g.last.Time, g.n)
+	}
+
+	erow, _ := ExistingERowOrNewBasic(gdi.ed, "+GoDebugGoroutines")
+	erow.Row.TextArea.SetBytesClearPos(buf.Bytes())
+	erow.Flash()
+}
+
+// Returns a "file:line:col" string, or just the filename if the offset can't be converted. Should be called under UI goroutine (reads open rows).
+func godebugFilePosString(ed *Editor, filename string, offset int) string {
+	if rd, err := erowOrFileReaderAt(ed, filename); err == nil {
+		if l, c, err := parseutil.IndexLineColumn(rd, offset); err == nil {
+			return fmt.Sprintf("%v:%v:%v", filename, l, c)
+		}
+	}
+	return filename
+}
+
+//----------
+
 func (gdi *GoDebugInstance) selectAnnotation(rowPos *ui.RowPos, ev *ui.RootSelectAnnotationEvent) {
 	if gdi.selectAnnotation2(ev) {
 		gdi.updateUIShowLine(rowPos)
@@ -275,8 +364,9 @@ func (gdi *GoDebugInstance) printIndex(erow *ERow, annIndex, offset int) {
 		return
 	}
 	// build output
-	s := godebug.StringifyItemFull(msg.dbgLineMsg.Item)
-	gdi.gdm.Printf("annotation: #%d\n\t%v\n", msg.arrivalIndex, s)
+	dlm := msg.dbgLineMsg
+	s := godebug.StringifyItemFull(dlm.Item)
+	gdi.gdm.Printf("annotation: #%d (goroutine %d, %v)\n\t%v\n", msg.arrivalIndex, dlm.Goid, dlm.Time, s)
 }
 
 func (gdi *GoDebugInstance) printIndexAllPrevious(erow *ERow, annIndex, offset int) {
@@ -347,6 +437,10 @@ func (gdi *GoDebugInstance) handleMsg(msg interface{}, cmd *godebug.Cmd) error {
 		if err := gdi.di.handleFilesDataMsg(t); err != nil {
 			return err
 		}
+		// breakpoints need the files indexes
+		if err := gdi.sendBreakpoints2(cmd); err != nil {
+			return fmt.Errorf("set breakpoints: %w", err)
+		}
 		// on receiving the filesdatamsg, send a requeststart
 		if err := cmd.RequestStart(); err != nil {
 			return fmt.Errorf("request start: %w", err)
@@ -355,6 +449,16 @@ func (gdi *GoDebugInstance) handleMsg(msg interface{}, cmd *godebug.Cmd) error {
 		return gdi.di.handleLineMsgs(t)
 	case []*debug.LineMsg:
 		return gdi.di.handleLineMsgs(t...)
+	case *debug.PausedMsg:
+		if !cmd.IsReplay() { // can't continue a replay
+			gdi.handlePausedMsg(t)
+		}
+	case *debug.ContinuedMsg:
+		gdi.handleContinuedMsg(t)
+	case *debug.ValueMsg:
+		gdi.ed.UI.RunOnUIGoRoutine(func() {
+			gdi.insp.handleValueMsg(t)
+		})
 	default:
 		return fmt.Errorf("unexpected msg: %T", msg)
 	}
@@ -393,6 +497,7 @@ func (gdi *GoDebugInstance) clearInfosUI() {
 }
 
 func (gdi *GoDebugInstance) clearInfoUI(info *ERowInfo) {
+	info.UpdatePausedRowState(false)
 	info.UpdateAnnotationsRowState(false)
 	info.UpdateAnnotationsEditedRowState(false)
 	gdi.clearAnnotations(info)
@@ -414,12 +519,14 @@ func (gdi *GoDebugInstance) updateInfoUI(info *ERowInfo) {
 	// file belongs to the godebug session
 	findex, ok := gdi.di.FilesIndex(info.Name())
 	if !ok {
+		info.UpdatePausedRowState(false)
 		info.UpdateAnnotationsRowState(false)
 		info.UpdateAnnotationsEditedRowState(false)
 		gdi.clearAnnotations(info)
 		return
 	}
 	info.UpdateAnnotationsRowState(true)
+	info.UpdatePausedRowState(gdi.di.isFilePaused(findex))
 
 	// check if content has changed
 	edited := gdi.di.updateFileEdited(info)
@@ -517,6 +624,11 @@ type GDDataIndex struct {
 	afds  []*debug.AnnotatorFileData // [fileindex]
 	files []*GDFileMsgs              // [fileindex]
 
+	arrivals   []*GDLineMsg           // [arrivalIndex]
+	goroutines map[int64]*GDGoroutine // [goid]
+	goFilter   int64                  // only select msgs from this goroutine (0: all)
+	paused     map[int64]*debug.PausedMsg
+
 	lastArrivalIndex int
 	selected         struct {
 		arrivalIndex  int
@@ -530,6 +642,7 @@ func NewGDDataIndex(ed *Editor) *GDDataIndex {
 	di := &GDDataIndex{ed: ed}
 	di.filesIndexM = map[string]int{}
 	di.filesEdited = map[int]bool{}
+	di.paused = map[int64]*debug.PausedMsg{}
 	di.clearMsgs()
 	return di
 }
@@ -554,6 +667,8 @@ func (di *GDDataIndex) clearMsgs() {
 		u := NewGDFileMsgs(n)
 		*f = *u
 	}
+	di.arrivals = nil
+	di.goroutines = map[int64]*GDGoroutine{}
 	di.lastArrivalIndex = -1
 	di.selected.arrivalIndex = di.lastArrivalIndex
 }
@@ -613,12 +728,29 @@ func (di *GDDataIndex) _handleLineMsg(u *debug.LineMsg) error {
 	// index msg
 	w := &di.files[u.FileIndex].linesMsgs[u.DebugIndex].lineMsgs
 	*w = append(*w, lm)
+	di.arrivals = append(di.arrivals, lm)
 	// mark file as having new data (performance)
 	//di.files[u.FileIndex].hasNewData = true
 
+	// index goroutine
+	g, ok := di.goroutines[u.Goid]
+	if !ok {
+		g = &GDGoroutine{id: u.Goid}
+		di.goroutines[u.Goid] = g
+	}
+	prevLast := g.last
+	g.last = lm
+	g.n++
+
 	// auto update selected index if at last position
-	if di.selected.arrivalIndex == di.lastArrivalIndex-1 {
-		di.selected.arrivalIndex = di.lastArrivalIndex
+	if di.goFilter == 0 {
+		if di.selected.arrivalIndex == di.lastArrivalIndex-1 {
+			di.selected.arrivalIndex = di.lastArrivalIndex
+		}
+	} else if u.Goid == di.goFilter {
+		if prevLast == nil || di.selected.arrivalIndex == prevLast.arrivalIndex {
+			di.selected.arrivalIndex = di.lastArrivalIndex
+		}
 	}
 
 	return nil
@@ -626,6 +758,98 @@ func (di *GDDataIndex) _handleLineMsg(u *debug.LineMsg) error {
 
 //----------
 
+func (di *GDDataIndex) setGoFilter(goid int64) error {
+	di.Lock()
+	defer di.Unlock()
+	if goid == 0 {
+		di.goFilter = 0
+		return nil
+	}
+	g, ok := di.goroutines[goid]
+	if !ok {
+		return fmt.Errorf("goroutine not found: %v", goid)
+	}
+	di.goFilter = goid
+	// keep the selection if it belongs to the goroutine
+	if !di._goFilterMatch(di.selected.arrivalIndex) {
+		di.selected.arrivalIndex = g.last.arrivalIndex
+	}
+	return nil
+}
+
+// Not locked
+func (di *GDDataIndex) _goFilterMatch(arrivalIndex int) bool {
+	if arrivalIndex < 0 || arrivalIndex >= len(di.arrivals) {
+		return false
+	}
+	return di.goFilter == 0 || di.arrivals[arrivalIndex].dbgLineMsg.Goid == di.goFilter
+}
+
+func (di *GDDataIndex) filename(findex int) string {
+	di.RLock()
+	defer di.RUnlock()
+	if findex < 0 || findex >= len(di.afds) {
+		return ""
+	}
+	return di.afds[findex].Filename
+}
+
+//----------
+
+// Selects the msg that paused the goroutine (sent before the paused msg).
+func (di *GDDataIndex) setPaused(msg *debug.PausedMsg) {
+	di.Lock()
+	defer di.Unlock()
+	di.paused[msg.Goid] = msg
+	if g, ok := di.goroutines[msg.Goid]; ok {
+		if di._goFilterMatch(g.last.arrivalIndex) {
+			di.selected.arrivalIndex = g.last.arrivalIndex
+		}
+	}
+}
+
+func (di *GDDataIndex) setContinued(goid int64) {
+	di.Lock()
+	defer di.Unlock()
+	delete(di.paused, goid)
+}
+
+func (di *GDDataIndex) isFilePaused(findex int) bool {
+	di.RLock()
+	defer di.RUnlock()
+	for _, msg := range di.paused {
+		if msg.FileIndex == findex {
+			return true
+		}
+	}
+	return false
+}
+
+//----------
+
+func (di *GDDataIndex) goroutinesInfo() ([]*GDGoroutineInfo, int64) {
+	di.RLock()
+	defer di.RUnlock()
+	u := []*GDGoroutineInfo{}
+	for _, g := range di.goroutines {
+		dlm := g.last.dbgLineMsg
+		gi := &GDGoroutineInfo{
+			id:               g.id,
+			n:                g.n,
+			last:             dlm,
+			lastArrivalIndex: g.last.arrivalIndex,
+			filename:         di.afds[dlm.FileIndex].Filename,
+		}
+		u = append(u, gi)
+	}
+	sort.Slice(u, func(a, b int) bool {
+		return u[a].id < u[b].id
+	})
+	return u, di.goFilter
+}
+
+//----------
+
 func (di *GDDataIndex) annMsg(filename string, annIndex int) (*GDLineMsg, bool) {
 	di.RLock()
 	defer di.RUnlock()
@@ -655,7 +879,17 @@ func (di *GDDataIndex) annPreviousMsgs(filename string, annIndex int) ([]*GDLine
 	if k < 0 || k >= len(line.lineMsgs) { // currently nothing is shown or cleared
 		return nil, false
 	}
-	return line.lineMsgs[:k+1], true
+	msgs := line.lineMsgs[:k+1]
+	if di.goFilter != 0 {
+		u := []*GDLineMsg{}
+		for _, msg := range msgs {
+			if msg.dbgLineMsg.Goid == di.goFilter {
+				u = append(u, msg)
+			}
+		}
+		msgs = u
+	}
+	return msgs, true
 }
 
 func (di *GDDataIndex) selectedAnnFind(s string) (*GDLineMsg, bool) {
@@ -677,6 +911,9 @@ func (di *GDDataIndex) selectedAnnFind(s string) (*GDLineMsg, bool) {
 	for i := 0; i < len(line.lineMsgs); i++ {
 		h := (k + 1 + i) % len(line.lineMsgs)
 		msg := line.lineMsgs[h]
+		if !di._goFilterMatch(msg.arrivalIndex) {
+			continue
+		}
 		ann := msg.annotation()
 		j := bytes.Index(ann.Bytes, b)
 		if j >= 0 {
@@ -704,12 +941,12 @@ func (di *GDDataIndex) annMsgChangeCurrent(filename string, annIndex int, typ ui
 	case ui.TASelAnnTypeCurrent:
 		// allow to select first if no line is visible
 		if k < 0 {
-			k = 0
+			k = line.stepIndex(-1, 1, di.goFilter)
 		}
 	case ui.TASelAnnTypeCurrentPrev:
-		k--
+		k = line.stepIndex(k, -1, di.goFilter)
 	case ui.TASelAnnTypeCurrentNext:
-		k++
+		k = line.stepIndex(k, 1, di.goFilter)
 	default:
 		panic(fmt.Sprintf("unexpected type: %v", typ))
 	}
@@ -741,9 +978,10 @@ func (di *GDDataIndex) _annIndexFileLine(filename string, annIndex int) (*GDFile
 func (di *GDDataIndex) selectFirst() bool {
 	di.Lock()
 	defer di.Unlock()
-	if di.selected.arrivalIndex != 0 && 0 <= di.lastArrivalIndex { // could be -1
-		di.selected.arrivalIndex = 0
-		return true
+	for i := 0; i <= di.lastArrivalIndex; i++ { // could be -1
+		if di._goFilterMatch(i) {
+			return di._select(i)
+		}
 	}
 	return false
 }
@@ -751,19 +989,24 @@ func (di *GDDataIndex) selectFirst() bool {
 func (di *GDDataIndex) selectLast() bool {
 	di.Lock()
 	defer di.Unlock()
-	if di.selected.arrivalIndex != di.lastArrivalIndex {
-		di.selected.arrivalIndex = di.lastArrivalIndex
-		return true
+	i := di.lastArrivalIndex
+	if di.goFilter != 0 {
+		g, ok := di.goroutines[di.goFilter]
+		if !ok {
+			return false
+		}
+		i = g.last.arrivalIndex
 	}
-	return false
+	return di._select(i)
 }
 
 func (di *GDDataIndex) selectPrev() bool {
 	di.Lock()
 	defer di.Unlock()
-	if di.selected.arrivalIndex > 0 {
-		di.selected.arrivalIndex--
-		return true
+	for i := di.selected.arrivalIndex - 1; i >= 0; i-- {
+		if di._goFilterMatch(i) {
+			return di._select(i)
+		}
 	}
 	return false
 }
@@ -771,8 +1014,18 @@ func (di *GDDataIndex) selectPrev() bool {
 func (di *GDDataIndex) selectNext() bool {
 	di.Lock()
 	defer di.Unlock()
-	if di.selected.arrivalIndex < di.lastArrivalIndex {
-		di.selected.arrivalIndex++
+	for i := di.selected.arrivalIndex + 1; i <= di.lastArrivalIndex; i++ {
+		if di._goFilterMatch(i) {
+			return di._select(i)
+		}
+	}
+	return false
+}
+
+// Not locked
+func (di *GDDataIndex) _select(arrivalIndex int) bool {
+	if di.selected.arrivalIndex != arrivalIndex {
+		di.selected.arrivalIndex = arrivalIndex
 		return true
 	}
 	return false
@@ -784,7 +1037,7 @@ func (di *GDDataIndex) findSelectedAndUpdateAnnEntries(findex int) (int, bool) {
 	di.Lock()
 	defer di.Unlock()
 	file := di.files[findex]
-	selLine, selLineStep, selFound := file._findSelectedAndUpdateAnnEntries(di.selected.arrivalIndex)
+	selLine, selLineStep, selFound := file._findSelectedAndUpdateAnnEntries(di.selected.arrivalIndex, di.goFilter)
 	if selFound {
 		di.selected.fileIndex = findex
 		di.selected.lineIndex = selLine
@@ -903,12 +1156,12 @@ func NewGDFileMsgs(n int) *GDFileMsgs {
 }
 
 // Not locked
-func (file *GDFileMsgs) _findSelectedAndUpdateAnnEntries(arrivalIndex int) (int, int, bool) {
+func (file *GDFileMsgs) _findSelectedAndUpdateAnnEntries(arrivalIndex int, goid int64) (int, int, bool) {
 	found := false
 	selLine := 0
 	selLineStep := 0
 	for line, lm := range file.linesMsgs {
-		k, eqK, foundK := lm.findIndex(arrivalIndex)
+		k, eqK, foundK := lm.findGoIndex(arrivalIndex, goid)
 		if foundK {
 			file.annEntries[line] = lm.lineMsgs[k].annotation()
 			file.annEntriesLMIndex[line] = k
@@ -952,6 +1205,28 @@ func (lm *GDLineMsgs) findIndex(arrivalIndex int) (int, bool, bool) {
 	return k, eqK, foundK
 }
 
+// Same as findIndex, but only considering the msgs from the goroutine (0: all).
+func (lm *GDLineMsgs) findGoIndex(arrivalIndex int, goid int64) (int, bool, bool) {
+	k, eqK, foundK := lm.findIndex(arrivalIndex)
+	if goid == 0 || !foundK {
+		return k, eqK, foundK
+	}
+	for ; k >= 0 && lm.lineMsgs[k].dbgLineMsg.Goid != goid; k-- {
+		eqK = false
+	}
+	return k, eqK, k >= 0
+}
+
+// Index of the next (dir=1) or previous (dir=-1) msg from k that belongs to the goroutine (0: all). Returns an index out of range if there is none.
+func (lm *GDLineMsgs) stepIndex(k, dir int, goid int64) int {
+	for k += dir; k >= 0 && k < len(lm.lineMsgs); k += dir {
+		if goid == 0 || lm.lineMsgs[k].dbgLineMsg.Goid == goid {
+			break
+		}
+	}
+	return k
+}
+
 //----------
 
 type GDLineMsg struct {
@@ -987,3 +1262,20 @@ func (msg *GDLineMsg) emptyAnnotation() *drawer4.Annotation {
 	ann.NotesBytes = nil
 	return ann
 }
+
+//----------
+
+type GDGoroutine struct {
+	id   int64
+	n    int // number of msgs
+	last *GDLineMsg
+}
+
+// Copy of the goroutine data to be used outside of the data index lock.
+type GDGoroutineInfo struct {
+	id               int64
+	n                int
+	last             *debug.LineMsg
+	lastArrivalIndex int
+	filename         string
+}
@@ -0,0 +1,254 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/core/godebug/debug"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestGDDataIndexGoFilter(t *testing.T) {
+	di := NewGDDataIndex(&Editor{})
+	fdm := &debug.FilesDataMsg{Data: []*debug.AnnotatorFileData{
+		{FileIndex: 0, DebugLen: 2, Filename: "/a.go"},
+	}}
+	if err := di.handleFilesDataMsg(fdm); err != nil {
+		t.Fatal(err)
+	}
+	lms := []*debug.LineMsg{
+		{DebugIndex: 0, Goid: 1}, // #0
+		{DebugIndex: 0, Goid: 2}, // #1
+		{DebugIndex: 1, Goid: 1}, // #2
+		{DebugIndex: 0, Goid: 2}, // #3
+		{DebugIndex: 0, Goid: 1}, // #4
+	}
+	if err := di.handleLineMsgs(lms...); err != nil {
+		t.Fatal(err)
+	}
+	sel := func() int { return di.selected.arrivalIndex }
+
+	if err := di.setGoFilter(3); err == nil {
+		t.Fatal("expecting error")
+	}
+	if err := di.setGoFilter(2); err != nil {
+		t.Fatal(err)
+	}
+	if sel() != 3 {
+		t.Fatal(sel())
+	}
+	if !di.selectPrev() || sel() != 1 {
+		t.Fatal(sel())
+	}
+	if di.selectPrev() || sel() != 1 {
+		t.Fatal(sel())
+	}
+	if !di.selectLast() || sel() != 3 {
+		t.Fatal(sel())
+	}
+
+	// annotations of the other goroutine are not shown
+	di.setGoFilter(1)
+	di.selectFirst()
+	if sel() != 0 {
+		t.Fatal(sel())
+	}
+	di.selectNext()
+	if _, ok := di.findSelectedAndUpdateAnnEntries(0); !ok || sel() != 2 {
+		t.Fatal(sel())
+	}
+	if k := di.files[0].annEntriesLMIndex[0]; k != 0 {
+		t.Fatal(k)
+	}
+	// step at line 0: skips the msg #3 (goroutine 2)
+	if !di.annMsgChangeCurrent("/a.go", 0, ui.TASelAnnTypeCurrentNext) || sel() != 4 {
+		t.Fatal(sel())
+	}
+
+	// new msgs of the filtered goroutine keep the selection at the last one
+	if err := di.handleLineMsgs(&debug.LineMsg{Goid: 2}, &debug.LineMsg{Goid: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if sel() != 6 {
+		t.Fatal(sel())
+	}
+
+	gs, filter := di.goroutinesInfo()
+	if len(gs) != 2 || filter != 1 || gs[0].id != 1 || gs[0].n != 4 || gs[1].lastArrivalIndex != 5 {
+		t.Fatalf("%v %v", gs, filter)
+	}
+}
+
+func TestGDBreakpoints(t *testing.T) {
+	bps := NewGDBreakpoints()
+	b1 := GDBreakpoint{Filename: "/b.go", Offset: 3}
+	b2 := GDBreakpoint{Filename: "/a.go", Offset: 5}
+	if !bps.toggle(b1) || !bps.toggle(b2) {
+		t.Fatal("expecting added")
+	}
+	if err := bps.set(b1, "("); err == nil {
+		t.Fatal("expecting regexp error")
+	}
+	if err := bps.set(b1, "^a"); err != nil {
+		t.Fatal(err)
+	}
+	u, conds := bps.list()
+	if len(u) != 2 || u[0] != b2 || conds[0] != "" || conds[1] != "^a" {
+		t.Fatalf("%v %v", u, conds)
+	}
+	if bps.toggle(b2) {
+		t.Fatal("expecting removed")
+	}
+	if u, _ := bps.list(); len(u) != 1 {
+		t.Fatal(u)
+	}
+}
+
+func TestGDDataIndexPaused(t *testing.T) {
+	di := NewGDDataIndex(&Editor{})
+	fdm := &debug.FilesDataMsg{Data: []*debug.AnnotatorFileData{
+		{FileIndex: 0, DebugLen: 1, Filename: "/a.go"},
+		{FileIndex: 1, DebugLen: 1, Filename: "/b.go"},
+	}}
+	if err := di.handleFilesDataMsg(fdm); err != nil {
+		t.Fatal(err)
+	}
+	lms := []*debug.LineMsg{
+		{FileIndex: 1, Goid: 2}, // #0
+		{FileIndex: 0, Goid: 1}, // #1
+	}
+	if err := di.handleLineMsgs(lms...); err != nil {
+		t.Fatal(err)
+	}
+	di.setPaused(&debug.PausedMsg{Goid: 2, FileIndex: 1})
+	if di.selected.arrivalIndex != 0 {
+		t.Fatal(di.selected.arrivalIndex)
+	}
+	if di.isFilePaused(0) || !di.isFilePaused(1) {
+		t.Fatal("bad paused state")
+	}
+	di.setContinued(2)
+	if di.isFilePaused(1) {
+		t.Fatal("bad paused state")
+	}
+}
+
+func TestGDDataIndexWatches(t *testing.T) {
+	src := "package p1\nfunc f() {\n\tx := 1\n\tx = 2\n}\n"
+	ws, err := godebug.NewWatchSrc("/a.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	di := NewGDDataIndex(&Editor{})
+	fdm := &debug.FilesDataMsg{Data: []*debug.AnnotatorFileData{
+		{FileIndex: 0, DebugLen: 2, Filename: "/a.go"},
+	}}
+	if err := di.handleFilesDataMsg(fdm); err != nil {
+		t.Fatal(err)
+	}
+	ia := func(v string) debug.Item {
+		l := &debug.ItemList{List: []debug.Item{&debug.ItemValue{Str: v}}}
+		return debug.IA(l, l)
+	}
+	o1 := strings.Index(src, "x := 1\n") + 6
+	o2 := strings.Index(src, "x = 2\n") + 5
+	lms := []*debug.LineMsg{
+		{DebugIndex: 0, Offset: o1, Goid: 1, Item: ia("1")}, // #0
+		{DebugIndex: 1, Offset: o2, Goid: 2, Item: ia("2")}, // #1
+	}
+	if err := di.handleLineMsgs(lms...); err != nil {
+		t.Fatal(err)
+	}
+	src2 := func(findex int, filename string, edited bool) (*godebug.WatchSrc, bool) {
+		return ws, !edited
+	}
+	hists := di.watchesHistory([]string{"x", "y"}, src2)
+	if len(hists[0]) != 2 || hists[0][1].arrivalIndex != 1 || hists[0][1].value != "2" || len(hists[1]) != 0 {
+		t.Fatal(hists)
+	}
+
+	selectArrival := func(arrivalIndex int) bool {
+		filename, annIndex, ok := di.arrivalAnnIndex(arrivalIndex)
+		return ok && di.annMsgChangeCurrent(filename, annIndex, ui.TASelAnnTypeCurrent)
+	}
+	if !selectArrival(1) || di.selected.arrivalIndex != 1 {
+		t.Fatal(di.selected.arrivalIndex)
+	}
+	if !selectArrival(0) || di.selected.arrivalIndex != 0 {
+		t.Fatal(di.selected.arrivalIndex)
+	}
+	if selectArrival(2) {
+		t.Fatal("expecting fail")
+	}
+	di.setGoFilter(2)
+	if selectArrival(0) {
+		t.Fatal("expecting fail")
+	}
+	if hists := di.watchesHistory([]string{"x"}, src2); len(hists[0]) != 1 {
+		t.Fatal(hists)
+	}
+
+	wl := NewGDWatches()
+	if !wl.toggle("x") || !wl.toggle("a.b") || wl.toggle("x") {
+		t.Fatal("bad toggle")
+	}
+	if u := wl.list(); len(u) != 1 || u[0] != "a.b" {
+		t.Fatal(u)
+	}
+}
+
+func TestGDInspectNode(t *testing.T) {
+	node := &GDInspectNode{vnode: &debug.ValueNode{Str: "&{1}", Len: -1, More: true}, valueId: 3}
+	if s := node.label(); s != "+ &{1}" {
+		t.Fatal(s)
+	}
+	vn := &debug.ValueNode{Str: "&{1}", Type: "*main.T", Len: -1, Kids: []*debug.ValueNode{
+		{Name: "A", Str: "1", Type: "int", Len: -1},
+		{Name: "B", Str: "[2]", Type: "[]int", Len: 1, More: true},
+	}}
+	node.setValueNode(vn)
+	node.expanded = true
+	if s := node.label(); s != "- &{1} (*main.T)" {
+		t.Fatal(s)
+	}
+	kid := node.kids[1]
+	if kid.valueId != 3 || len(kid.path) != 1 || kid.path[0] != 1 {
+		t.Fatalf("%+v", kid)
+	}
+	if s := kid.label(); s != "+ B: [2] ([]int, len=1)" {
+		t.Fatal(s)
+	}
+	if s := node.kids[0].label(); s != "  A: 1 (int)" {
+		t.Fatal(s)
+	}
+}
+
+func TestGDDataIndexLineBreakpoint(t *testing.T) {
+	src := "package p\nfunc f() {\n\ta := 1\n\n\tb := a\n}\n"
+	di := NewGDDataIndex(&Editor{})
+	o1, o2 := strings.Index(src, "1\n")+1, strings.Index(src, "b := a")+6
+	fdm := &debug.FilesDataMsg{Data: []*debug.AnnotatorFileData{
+		{FileIndex: 0, DebugLen: 2, Filename: "/a.go", Offsets: []int{o1, o2}},
+	}}
+	if err := di.handleFilesDataMsg(fdm); err != nil {
+		t.Fatal(err)
+	}
+	rd := iorw.NewStringReaderAt(src)
+
+	// no msgs received: resolved from the file data
+	bp, err := di.lineBreakpoint("/a.go", rd, strings.Index(src, "b :="))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bp.Filename != "/a.go" || bp.Offset != o2 {
+		t.Fatalf("%+v", bp)
+	}
+	if _, err := di.lineBreakpoint("/a.go", rd, strings.Index(src, "\n\n")+1); err == nil {
+		t.Fatal("expecting no debug step at empty line")
+	}
+	if _, err := di.lineBreakpoint("/b.go", rd, 0); err == nil {
+		t.Fatal("expecting file not in session")
+	}
+}
@@ -0,0 +1,215 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jmigpin/editor/core/godebug"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Row that lists the watches history. Clicking on a history line selects that debug step (see contentcmds).
+const GoDebugWatchesERowName = "+GoDebugWatches"
+
+// Watch expressions are kept by the manager to be used in the next sessions.
+type GDWatches struct {
+	sync.Mutex
+	m map[string]bool // [expr]
+}
+
+func NewGDWatches() *GDWatches {
+	return &GDWatches{m: map[string]bool{}}
+}
+
+// Returns true if the watch was added.
+func (ws *GDWatches) toggle(expr string) bool {
+	ws.Lock()
+	defer ws.Unlock()
+	if ws.m[expr] {
+		delete(ws.m, expr)
+		return false
+	}
+	ws.m[expr] = true
+	return true
+}
+
+func (ws *GDWatches) clear() {
+	ws.Lock()
+	defer ws.Unlock()
+	ws.m = map[string]bool{}
+}
+
+// Sorted.
+func (ws *GDWatches) list() []string {
+	ws.Lock()
+	defer ws.Unlock()
+	u := []string{}
+	for expr := range ws.m {
+		u = append(u, expr)
+	}
+	sort.Strings(u)
+	return u
+}
+
+//----------
+
+// Should be called under UI goroutine.
+func (gdm *GoDebugManager) ToggleWatch(expr string) error {
+	expr2, err := godebug.WatchExprString(expr)
+	if err != nil {
+		return fmt.Errorf("bad watch expression: %v", err)
+	}
+	if gdm.watches.toggle(expr2) {
+		gdm.Printf("watch added: %v\n", expr2)
+	} else {
+		gdm.Printf("watch removed: %v\n", expr2)
+	}
+	gdm.ListWatches()
+	return nil
+}
+
+// Should be called under UI goroutine.
+func (gdm *GoDebugManager) ClearWatches() {
+	gdm.watches.clear()
+	gdm.ListWatches()
+}
+
+// Should be called under UI goroutine.
+func (gdm *GoDebugManager) ListWatches() {
+	exprs := gdm.watches.list()
+	hists := make([][]*GDWatchEntry, len(exprs))
+	gdm.inst.Lock()
+	if gdm.inst.inst != nil {
+		hists = gdm.inst.inst.watchesHistory(exprs)
+	}
+	gdm.inst.Unlock()
+
+	buf := &bytes.Buffer{}
+	// snapshot: not updated on new msgs (would reset the row position while clicking the history lines)
+	fmt.Fprintf(buf, "watches: %d (snapshot, run GoDebugWatches to refresh)\n", len(exprs))
+	for i, expr := range exprs {
+		fmt.Fprintf(buf, "%v: %d entries\n", expr, len(hists[i]))
+		for _, we := range hists[i] {
+			pos := godebugFilePosString(gdm.ed, we.filename, we.offset)
+			fmt.Fprintf(buf, "\t#%d goroutine %d: %v: %v\n", we.arrivalIndex, we.goid, pos, we.value)
+		}
+	}
+
+	erow, _ := ExistingERowOrNewBasic(gdm.ed, GoDebugWatchesERowName)
+	erow.Row.TextArea.SetBytesClearPos(buf.Bytes())
+	erow.Flash()
+}
+
+func (gdm *GoDebugManager) SelectArrivalIndex(arrivalIndex int) error {
+	gdm.inst.Lock()
+	defer gdm.inst.Unlock()
+	if gdm.inst.inst == nil {
+		return fmt.Errorf("missing godebug instance")
+	}
+	return gdm.inst.inst.selectArrivalIndex(arrivalIndex)
+}
+
+//----------
+
+func (gdi *GoDebugInstance) selectArrivalIndex(arrivalIndex int) error {
+	// same path as a click on the annotation showing the msg
+	filename, annIndex, ok := gdi.di.arrivalAnnIndex(arrivalIndex)
+	if !ok || !gdi.di.annMsgChangeCurrent(filename, annIndex, ui.TASelAnnTypeCurrent) {
+		return fmt.Errorf("debug step not available: #%d", arrivalIndex)
+	}
+	gdi.openArrivalIndexERow()
+	gdi.updateUIShowLine(gdi.ed.GoodRowPos())
+	return nil
+}
+
+// Should be called under UI goroutine (reads open rows).
+func (gdi *GoDebugInstance) watchesHistory(exprs []string) [][]*GDWatchEntry {
+	srcs := map[int]*godebug.WatchSrc{} // [fileindex]
+	src := func(findex int, filename string, edited bool) (*godebug.WatchSrc, bool) {
+		if edited {
+			return nil, false // offsets don't match
+		}
+		ws, ok := srcs[findex]
+		if !ok {
+			srcs[findex] = nil // try only once
+			rd, err := erowOrFileReaderAt(gdi.ed, filename)
+			if err != nil {
+				return nil, false
+			}
+			b, err := iorw.ReadFastFull(rd)
+			if err != nil {
+				return nil, false
+			}
+			ws, err = godebug.NewWatchSrc(filename, b)
+			if err != nil {
+				return nil, false
+			}
+			srcs[findex] = ws
+		}
+		return ws, ws != nil
+	}
+	return gdi.di.watchesHistory(exprs, src)
+}
+
+//----------
+
+// Values of the watched exprs, in arrival order.
+func (di *GDDataIndex) watchesHistory(exprs []string, src func(findex int, filename string, edited bool) (*godebug.WatchSrc, bool)) [][]*GDWatchEntry {
+	di.RLock()
+	defer di.RUnlock()
+	hists := make([][]*GDWatchEntry, len(exprs))
+	for _, msg := range di.arrivals {
+		if !di._goFilterMatch(msg.arrivalIndex) {
+			continue
+		}
+		dlm := msg.dbgLineMsg
+		filename := di.afds[dlm.FileIndex].Filename
+		ws, ok := src(dlm.FileIndex, filename, di.filesEdited[dlm.FileIndex])
+		if !ok {
+			continue
+		}
+		for i, expr := range exprs {
+			for _, v := range ws.Values(expr, dlm.Offset, dlm.Item) {
+				we := &GDWatchEntry{
+					arrivalIndex: msg.arrivalIndex,
+					goid:         dlm.Goid,
+					filename:     filename,
+					offset:       dlm.Offset,
+					value:        v,
+				}
+				hists[i] = append(hists[i], we)
+			}
+		}
+	}
+	return hists
+}
+
+// Returns the annotation index of the msg, and sets the msg as the current entry of the annotation (used by annMsgChangeCurrent).
+func (di *GDDataIndex) arrivalAnnIndex(arrivalIndex int) (string, int, bool) {
+	di.Lock() // writes annEntriesLMIndex
+	defer di.Unlock()
+	if !di._goFilterMatch(arrivalIndex) {
+		return "", 0, false
+	}
+	dlm := di.arrivals[arrivalIndex].dbgLineMsg
+	file := di.files[dlm.FileIndex]
+	k, eqK, _ := file.linesMsgs[dlm.DebugIndex].findIndex(arrivalIndex)
+	if !eqK {
+		return "", 0, false
+	}
+	file.annEntriesLMIndex[dlm.DebugIndex] = k
+	return di.afds[dlm.FileIndex].Filename, dlm.DebugIndex, true
+}
+
+//----------
+
+type GDWatchEntry struct {
+	arrivalIndex int
+	goid         int64
+	filename     string
+	offset       int
+	value        string
+}
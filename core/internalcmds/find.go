@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/iout/iorw"
 	"github.com/jmigpin/editor/util/iout/iorw/rwedit"
 )
 
@@ -12,7 +14,7 @@ func Find(args0 *core.InternalCmdArgs) error {
 	erow := args0.ERow
 	part := args0.Part
 
-	args := part.Args[1:]
+	opt, args := searchFlags(part.Args[1:])
 	if len(args) < 1 {
 		return fmt.Errorf("expecting argument")
 	}
@@ -26,7 +28,7 @@ func Find(args0 *core.InternalCmdArgs) error {
 		str = strings.TrimSpace(s)
 	}
 
-	found, err := rwedit.Find(args0.Ctx, erow.Row.TextArea.EditCtx(), str)
+	found, err := rwedit.Find(args0.Ctx, erow.Row.TextArea.EditCtx(), str, opt)
 	if err != nil {
 		return err
 	}
@@ -42,3 +44,26 @@ func Find(args0 *core.InternalCmdArgs) error {
 
 	return nil
 }
+
+//----------
+
+// Parses the leading search flags. Returns the remaining args.
+func searchFlags(args []*toolbarparser.Arg) (iorw.SearchOpt, []*toolbarparser.Arg) {
+	opt := iorw.SearchOpt{}
+	for len(args) > 0 {
+		switch args[0].UnquotedStr() {
+		case "-re":
+			opt.Regexp = true
+		case "-w":
+			opt.WholeWord = true
+		case "-c":
+			opt.CaseSensitive = true
+		case "--": // end of flags (allows searching a flag string)
+			return opt, args[1:]
+		default:
+			return opt, args
+		}
+		args = args[1:]
+	}
+	return opt, args
+}
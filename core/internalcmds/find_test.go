@@ -0,0 +1,36 @@
+package internalcmds
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestSearchFlags(t *testing.T) {
+	type entry struct {
+		str     string
+		replace bool
+		exp     iorw.SearchOpt
+		rest    int
+	}
+	entries := []entry{
+		{"Find -re -w a", false, iorw.SearchOpt{Regexp: true, WholeWord: true}, 1},
+		{"Find -c a b", false, iorw.SearchOpt{CaseSensitive: true}, 2},
+		{"Find -- -c", false, iorw.SearchOpt{}, 1},
+		// replace is always case sensitive
+		{"Replace foo x", true, iorw.SearchOpt{CaseSensitive: true}, 2},
+		{"Replace -re foo x", true, iorw.SearchOpt{Regexp: true, CaseSensitive: true}, 2},
+	}
+	for _, e := range entries {
+		part := toolbarparser.Parse(e.str).Parts[0]
+		fn := searchFlags
+		if e.replace {
+			fn = replaceSearchFlags
+		}
+		opt, args := fn(part.Args[1:])
+		if opt != e.exp || len(args) != e.rest {
+			t.Fatalf("%q: expecting %+v %v, got %+v %v", e.str, e.exp, e.rest, opt, len(args))
+		}
+	}
+}
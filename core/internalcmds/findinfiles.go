@@ -0,0 +1,72 @@
+package internalcmds
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func FindInFiles(args0 *core.InternalCmdArgs) error {
+	opt, args := searchFlags(args0.Part.Args[1:])
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("expecting <pattern> [dir]")
+	}
+	s, err := iorw.NewSearcher(args[0].UnquotedStr(), opt)
+	if err != nil {
+		return err
+	}
+	dir, err := filesSearchDir(args0, args[1:])
+	if err != nil {
+		return err
+	}
+	core.FindInFiles(args0.Ed, dir, s)
+	return nil
+}
+
+func ReplaceInFiles(args0 *core.InternalCmdArgs) error {
+	opt, args := replaceSearchFlags(args0.Part.Args[1:])
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("expecting <old> <new> [dir]")
+	}
+	s, err := iorw.NewSearcher(args[0].UnquotedStr(), opt)
+	if err != nil {
+		return err
+	}
+	dir, err := filesSearchDir(args0, args[2:])
+	if err != nil {
+		return err
+	}
+	// preview, edits are applied on confirm
+	args0.Ed.ReplacePreview.Show(dir, s, args[1].UnquotedStr())
+	return nil
+}
+
+func ReplaceInFilesConfirm(args0 *core.InternalCmdArgs) error {
+	return args0.Ed.ReplacePreview.Confirm()
+}
+
+func ReplaceInFilesCancel(args0 *core.InternalCmdArgs) error {
+	args0.Ed.ReplacePreview.Cancel()
+	return nil
+}
+
+//----------
+
+// The optional dir argument is relative to the row directory.
+func filesSearchDir(args0 *core.InternalCmdArgs, args []*toolbarparser.Arg) (string, error) {
+	dir := args0.ERow.Info.Dir()
+	if len(args) > 0 {
+		d := args0.Ed.HomeVars.Decode(args[0].UnquotedStr())
+		if !filepath.IsAbs(d) {
+			d = filepath.Join(dir, d)
+		}
+		dir = d
+	}
+	if dir == "" {
+		return "", fmt.Errorf("row has no directory")
+	}
+	return dir, nil
+}
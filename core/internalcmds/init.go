@@ -13,6 +13,9 @@ func init() {
 	cmdERow := func(name string, fn core.InternalCmdFn) {
 		ic.Set(&core.InternalCmd{Name: name, Fn: fn, NeedsERow: true})
 	}
+	cmdERowDetach := func(name string, fn core.InternalCmdFn) {
+		ic.Set(&core.InternalCmd{Name: name, Fn: fn, NeedsERow: true, Detach: true})
+	}
 
 	cmd("Exit", Exit)
 
@@ -42,9 +45,17 @@ func init() {
 
 	cmdERow("Find", Find)
 	cmdERow("Replace", Replace)
+	cmdERow("FindInFiles", FindInFiles)
+	cmdERow("ReplaceInFiles", ReplaceInFiles)
+	cmd("ReplaceInFilesConfirm", ReplaceInFilesConfirm)
+	cmd("ReplaceInFilesCancel", ReplaceInFilesCancel)
 	cmdERow("GotoLine", GotoLine)
 	cmdERow("GoToLine", GotoLine)
 
+	cmdERowDetach("Pipe", Pipe)
+	cmdERowDetach("Insert", Insert)
+	cmdERowDetach("Send", Send)
+
 	cmdERow("CopyFilePosition", CopyFilePosition)
 	cmdERow("RuneCodes", RuneCodes)
 	cmd("FontRunes", FontRunes)
@@ -58,11 +69,27 @@ func init() {
 	cmdERow("GoRename", GoRename)
 	cmdERow("GoDebug", GoDebug)
 	cmdERow("GoDebugFind", GoDebugFind)
+	cmd("GoDebugGoroutines", GoDebugGoroutines)
+	cmd("GoDebugGoroutine", GoDebugGoroutine)
+	cmdERow("GoDebugBreakpoint", GoDebugBreakpoint)
+	cmd("GoDebugBreakpoints", GoDebugBreakpoints)
+	cmd("GoDebugContinue", GoDebugContinue)
+	cmd("GoDebugStep", GoDebugStep)
+	cmd("GoDebugWatch", GoDebugWatch)
+	cmd("GoDebugWatches", GoDebugWatches)
 
 	// Deprecated: in favor of "LspCloseAll"
 	cmd("LSProtoCloseAll", LSProtoCloseAll)
 	cmd("LsprotoCloseAll", LSProtoCloseAll)
 	cmdERow("LsprotoRename", LSProtoRename)
+	cmd("LsprotoRenameConfirm", LSProtoRenameConfirm)
+	cmd("LsprotoRenameCancel", LSProtoRenameCancel)
+	cmd("LsprotoDiagnostics", LSProtoDiagnostics)
+	cmdERowDetach("LsprotoReferences", LSProtoReferences)
+	cmdERowDetach("LsprotoSymbols", LSProtoSymbols)
+	cmdERowDetach("LsprotoWorkspaceSymbols", LSProtoWorkspaceSymbols)
+	cmdERowDetach("LsprotoFormat", LSProtoFormat)
+	cmdERowDetach("LsprotoCodeAction", LSProtoCodeAction)
 
 	cmd("ColorTheme", ColorTheme)
 	cmd("FontTheme", FontTheme)
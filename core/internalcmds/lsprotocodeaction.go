@@ -0,0 +1,128 @@
+package internalcmds
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Formats the selection, or the whole content if there is no selection.
+func LSProtoFormat(args0 *core.InternalCmdArgs) error {
+	erow := args0.ERow
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	ta := erow.Row.TextArea
+	b, err := iorw.ReadFullCopy(ta.RW())
+	if err != nil {
+		return err
+	}
+	rd := iorw.NewBytesReadWriterAt(b)
+
+	man := args0.Ed.LSProtoMan
+	var edits []*lsproto.TextEdit
+	if i1, i2, ok := ta.Cursor().SelectionIndexes(); ok {
+		edits, err = man.TextDocumentRangeFormatting(args0.Ctx, erow.Info.Name(), rd, i1, i2-i1)
+	} else {
+		edits, err = man.TextDocumentFormatting(args0.Ctx, erow.Info.Name(), rd)
+	}
+	if err != nil {
+		return err
+	}
+
+	args0.Ed.UI.RunOnUIGoRoutine(func() {
+		if err := core.LSProtoPatchERow(erow, b, edits); err != nil {
+			args0.Ed.Error(err)
+		}
+	})
+	return nil
+}
+
+//----------
+
+// Lists the code actions available at the cursor (or selection). With an index argument, applies the corresponding action.
+func LSProtoCodeAction(args0 *core.InternalCmdArgs) error {
+	erow := args0.ERow
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	// optional index of the action to apply
+	index := 0
+	if a := args0.Part.ArgsUnquoted(); len(a) >= 2 {
+		v, err := strconv.Atoi(a[1])
+		if err != nil {
+			return fmt.Errorf("expecting action index: %w", err)
+		}
+		index = v
+	}
+
+	ta := erow.Row.TextArea
+	b, err := iorw.ReadFullCopy(ta.RW())
+	if err != nil {
+		return err
+	}
+	rd := iorw.NewBytesReadWriterAt(b)
+
+	offset, n := ta.CursorIndex(), 0
+	if i1, i2, ok := ta.Cursor().SelectionIndexes(); ok {
+		offset, n = i1, i2-i1
+	}
+
+	man := args0.Ed.LSProtoMan
+	actions, err := man.TextDocumentCodeAction(args0.Ctx, erow.Info.Name(), rd, offset, n)
+	if err != nil {
+		return err
+	}
+
+	// list actions
+	if index == 0 {
+		buf := &bytes.Buffer{}
+		fmt.Fprintf(buf, "code actions: %d (apply with \"LsprotoCodeAction <n>\" at the same position)\n", len(actions))
+		for i, ca := range actions {
+			kind := ""
+			if ca.Kind != "" {
+				kind = fmt.Sprintf(" (%v)", ca.Kind)
+			}
+			fmt.Fprintf(buf, "%d: %v%v\n", i+1, ca.Title, kind)
+		}
+		core.ShowSpecialERowBytes(args0.Ed, "+CodeActions", buf.Bytes())
+		return nil
+	}
+
+	if index < 1 || index > len(actions) {
+		return fmt.Errorf("action index out of range: %v (%v actions)", index, len(actions))
+	}
+	ca := actions[index-1]
+
+	// the edit is applied before running the command
+	if ca.Edit != nil {
+		errC := make(chan error, 1)
+		args0.Ed.UI.RunOnUIGoRoutine(func() {
+			b2, err := ta.Bytes()
+			if err == nil && !bytes.Equal(b, b2) {
+				err = fmt.Errorf("content changed while waiting for the server")
+			}
+			if err == nil {
+				err = core.LSProtoApplyWorkspaceEdit(args0.Ed, ca.Edit)
+			}
+			errC <- err
+		})
+		if err := <-errC; err != nil {
+			return err
+		}
+	}
+	if ca.Command != nil {
+		if err := man.WorkspaceExecuteCommand(args0.Ctx, erow.Info.Name(), ca.Command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
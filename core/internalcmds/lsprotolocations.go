@@ -0,0 +1,120 @@
+package internalcmds
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func LSProtoReferences(args0 *core.InternalCmdArgs) error {
+	erow := args0.ERow
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	rd, ci, err := erowContentCopy(erow)
+	if err != nil {
+		return err
+	}
+	locs, err := args0.Ed.LSProtoMan.TextDocumentReferences(args0.Ctx, erow.Info.Name(), rd, ci)
+	if err != nil {
+		return err
+	}
+	b, err := core.LSProtoLocationsBytes(args0.Ed, locs)
+	if err != nil {
+		return err
+	}
+	b = append([]byte(fmt.Sprintf("references: %d\n", len(locs))), b...)
+	core.ShowSpecialERowBytes(args0.Ed, "+References", b)
+	return nil
+}
+
+//----------
+
+func LSProtoSymbols(args0 *core.InternalCmdArgs) error {
+	erow := args0.ERow
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	// optional query to filter the results
+	query := ""
+	if a := args0.Part.ArgsUnquoted(); len(a) >= 2 {
+		query = strings.ToLower(strings.Join(a[1:], " "))
+	}
+
+	rd, _, err := erowContentCopy(erow)
+	if err != nil {
+		return err
+	}
+	syms, err := args0.Ed.LSProtoMan.TextDocumentDocumentSymbol(args0.Ctx, erow.Info.Name(), rd)
+	if err != nil {
+		return err
+	}
+	if query != "" {
+		u := syms[:0]
+		for _, si := range syms {
+			if strings.Contains(strings.ToLower(si.Name), query) {
+				u = append(u, si)
+			}
+		}
+		syms = u
+	}
+
+	b, err := core.LSProtoSymbolsBytes(args0.Ed, syms)
+	if err != nil {
+		return err
+	}
+	b = append([]byte(fmt.Sprintf("symbols: %d\n", len(syms))), b...)
+	core.ShowSpecialERowBytes(args0.Ed, "+Symbols", b)
+	return nil
+}
+
+func LSProtoWorkspaceSymbols(args0 *core.InternalCmdArgs) error {
+	erow := args0.ERow
+
+	if !erow.Info.IsFileButNotDir() {
+		return fmt.Errorf("not a file")
+	}
+
+	a := args0.Part.ArgsUnquoted()
+	if len(a) < 2 {
+		return fmt.Errorf("missing query")
+	}
+	query := strings.Join(a[1:], " ")
+
+	syms, err := args0.Ed.LSProtoMan.WorkspaceSymbol(args0.Ctx, erow.Info.Name(), query)
+	if err != nil {
+		return err
+	}
+
+	b, err := core.LSProtoSymbolsBytes(args0.Ed, syms)
+	if err != nil {
+		return err
+	}
+	b = append([]byte(fmt.Sprintf("workspace symbols: %d\n", len(syms))), b...)
+	core.ShowSpecialERowBytes(args0.Ed, "+Symbols", b)
+	return nil
+}
+
+//----------
+
+// Copies the row content and cursor index in the UI goroutine (the cmds run detached, only the lsproto request runs outside the UI goroutine).
+func erowContentCopy(erow *core.ERow) (iorw.ReaderAt, int, error) {
+	var b []byte
+	var ci int
+	var err error
+	erow.Ed.UI.WaitRunOnUIGoRoutine(func() {
+		ta := erow.Row.TextArea
+		b, err = ta.Bytes()
+		ci = ta.CursorIndex()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return iorw.NewBytesReadWriterAt(b), ci, nil
+}
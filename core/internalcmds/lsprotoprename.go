@@ -5,7 +5,6 @@ import (
 
 	"github.com/jmigpin/editor/core"
 	"github.com/jmigpin/editor/core/lsproto"
-	"github.com/jmigpin/editor/ui"
 )
 
 func LSProtoRename(args0 *core.InternalCmdArgs) error {
@@ -15,10 +14,6 @@ func LSProtoRename(args0 *core.InternalCmdArgs) error {
 		return fmt.Errorf("not a file")
 	}
 
-	if erow.Row.HasState(ui.RowStateEdited | ui.RowStateFsDiffer) {
-		return fmt.Errorf("row has edits, save first")
-	}
-
 	args := args0.Part.Args[1:]
 	if len(args) < 1 {
 		return fmt.Errorf("expecting at least 1 argument")
@@ -39,33 +34,16 @@ func LSProtoRename(args0 *core.InternalCmdArgs) error {
 		return err
 	}
 
-	// before patching, check all affected files are not edited
-	for _, wec := range wecs {
-		info, ok := args0.Ed.ERowInfo(wec.Filename)
-		if !ok { // erow not open
-			continue
-		}
-		if info.HasRowState(ui.RowStateEdited | ui.RowStateFsDiffer) {
-			return fmt.Errorf("row has edits, save first: %v", info.Name())
-		}
-	}
-
-	if err := lsproto.PatchWorkspaceEditChanges(wecs); err != nil {
-		return err
-	}
+	// preview, edits are applied on confirm
+	header := fmt.Sprintf("rename to %q", to)
+	return args0.Ed.LSProtoPreview.Show(header, wecs)
+}
 
-	// reload filenames
-	args0.Ed.UI.RunOnUIGoRoutine(func() {
-		for _, wec := range wecs {
-			info, ok := args0.Ed.ERowInfo(wec.Filename)
-			if !ok { // erow not open
-				continue
-			}
-			if err := info.ReloadFile(); err != nil {
-				args0.Ed.Error(err)
-			}
-		}
-	})
+func LSProtoRenameConfirm(args0 *core.InternalCmdArgs) error {
+	return args0.Ed.LSProtoPreview.Confirm()
+}
 
+func LSProtoRenameCancel(args0 *core.InternalCmdArgs) error {
+	args0.Ed.LSProtoPreview.Cancel()
 	return nil
 }
@@ -2,6 +2,7 @@ package internalcmds
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/jmigpin/editor/core"
 	"github.com/jmigpin/editor/ui"
@@ -153,6 +154,109 @@ func GoDebugFind(args *core.InternalCmdArgs) error {
 	return args.Ed.GoDebug.AnnotationFind(s)
 }
 
+func GoDebugGoroutines(args *core.InternalCmdArgs) error {
+	return args.Ed.GoDebug.ListGoroutines()
+}
+
+func GoDebugGoroutine(args *core.InternalCmdArgs) error {
+	a := args.Part.ArgsUnquoted()
+	if len(a) != 2 {
+		return fmt.Errorf("expecting goroutine id or \"all\"")
+	}
+	goid := int64(0) // all
+	if a[1] != "all" {
+		v, err := parseGoid(a[1])
+		if err != nil {
+			return err
+		}
+		goid = v
+	}
+	return args.Ed.GoDebug.SetGoroutineFilter(goid)
+}
+
+func GoDebugBreakpoint(args *core.InternalCmdArgs) error {
+	a := args.Part.ArgsUnquoted()
+	switch len(a) {
+	case 1:
+		return args.Ed.GoDebug.ToggleCursorBreakpoint(args.ERow)
+	case 2:
+		return args.Ed.GoDebug.SetCursorBreakpoint(args.ERow, a[1]) // unquoted if quoted
+	default:
+		cond := args.Part.FromArgString(1) // verbatim
+		return args.Ed.GoDebug.SetCursorBreakpoint(args.ERow, cond)
+	}
+}
+
+func GoDebugBreakpoints(args *core.InternalCmdArgs) error {
+	a := args.Part.ArgsUnquoted()
+	if len(a) == 2 && a[1] == "-clear" {
+		return args.Ed.GoDebug.ClearBreakpoints()
+	}
+	if len(a) != 1 {
+		return fmt.Errorf("usage: %v [-clear]", a[0])
+	}
+	args.Ed.GoDebug.ListBreakpoints()
+	return nil
+}
+
+func GoDebugWatch(args *core.InternalCmdArgs) error {
+	a := args.Part.ArgsUnquoted()
+	if len(a) < 2 {
+		return fmt.Errorf("missing expression to watch")
+	}
+	expr := args.Part.FromArgString(1) // verbatim
+	if len(a) == 2 {
+		expr = a[1] // single arg, unquoted if quoted
+	}
+	return args.Ed.GoDebug.ToggleWatch(expr)
+}
+
+func GoDebugWatches(args *core.InternalCmdArgs) error {
+	a := args.Part.ArgsUnquoted()
+	if len(a) == 2 && a[1] == "-clear" {
+		args.Ed.GoDebug.ClearWatches()
+		return nil
+	}
+	if len(a) != 1 {
+		return fmt.Errorf("usage: %v [-clear]", a[0])
+	}
+	args.Ed.GoDebug.ListWatches()
+	return nil
+}
+
+func GoDebugContinue(args *core.InternalCmdArgs) error {
+	return goDebugContinue(args, false)
+}
+
+func GoDebugStep(args *core.InternalCmdArgs) error {
+	return goDebugContinue(args, true)
+}
+
+func goDebugContinue(args *core.InternalCmdArgs, step bool) error {
+	a := args.Part.ArgsUnquoted()
+	goid := int64(0) // all paused goroutines
+	switch len(a) {
+	case 1:
+	case 2:
+		v, err := parseGoid(a[1])
+		if err != nil {
+			return err
+		}
+		goid = v
+	default:
+		return fmt.Errorf("usage: %v [goroutine-id]", a[0])
+	}
+	return args.Ed.GoDebug.Continue(goid, step)
+}
+
+func parseGoid(s string) (int64, error) {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("bad goroutine id: %v", s)
+	}
+	return v, nil
+}
+
 //----------
 
 func ColorTheme(args *core.InternalCmdArgs) error {
@@ -188,6 +292,10 @@ func FontRunes(args *core.InternalCmdArgs) error {
 func LSProtoCloseAll(args *core.InternalCmdArgs) error {
 	return args.Ed.LSProtoMan.Close()
 }
+func LSProtoDiagnostics(args *core.InternalCmdArgs) error {
+	core.ListLSProtoDiagnostics(args.Ed)
+	return nil
+}
 func CtxutilCallsState(args *core.InternalCmdArgs) error {
 	s := ctxutil.CallsState()
 	args.Ed.Messagef("%s", s)
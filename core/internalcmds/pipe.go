@@ -0,0 +1,15 @@
+package internalcmds
+
+import (
+	"github.com/jmigpin/editor/core"
+)
+
+func Pipe(args *core.InternalCmdArgs) error {
+	return core.PipeCmd(args.Ctx, args.ERow, core.PipeModeReplace, args.Part)
+}
+func Insert(args *core.InternalCmdArgs) error {
+	return core.PipeCmd(args.Ctx, args.ERow, core.PipeModeInsert, args.Part)
+}
+func Send(args *core.InternalCmdArgs) error {
+	return core.PipeCmd(args.Ctx, args.ERow, core.PipeModeSend, args.Part)
+}
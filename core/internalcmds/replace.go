@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/jmigpin/editor/core"
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/iout/iorw"
 	"github.com/jmigpin/editor/util/iout/iorw/rwedit"
 )
 
@@ -11,7 +13,7 @@ func Replace(args0 *core.InternalCmdArgs) error {
 	erow := args0.ERow
 	part := args0.Part
 
-	args := part.Args[1:]
+	opt, args := replaceSearchFlags(part.Args[1:])
 	if len(args) != 2 {
 		return fmt.Errorf("expecting 2 arguments")
 	}
@@ -21,7 +23,7 @@ func Replace(args0 *core.InternalCmdArgs) error {
 	ta := erow.Row.TextArea
 	ta.BeginUndoGroup()
 	defer ta.EndUndoGroup()
-	replaced, err := rwedit.Replace(ta.EditCtx(), old, new)
+	replaced, err := rwedit.Replace(args0.Ctx, ta.EditCtx(), old, new, opt)
 	if err != nil {
 		return err
 	}
@@ -30,3 +32,10 @@ func Replace(args0 *core.InternalCmdArgs) error {
 	}
 	return nil
 }
+
+// Same as searchFlags, but always case sensitive ("-c" implied).
+func replaceSearchFlags(args []*toolbarparser.Arg) (iorw.SearchOpt, []*toolbarparser.Arg) {
+	opt, args := searchFlags(args)
+	opt.CaseSensitive = true
+	return opt, args
+}
@@ -0,0 +1,206 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/iout/iorw/rwedit"
+	"github.com/jmigpin/editor/util/uiutil/event"
+)
+
+// Maps key combinations to named actions. The action is looked up in the registered actions (ex: "rwedit.Comment"), otherwise it runs as a toolbar command (internal cmds, plugins, external cmds). Bindings are checked before the hard-coded shortcuts, and the bound keys don't reach the ui.
+type KeyMap struct {
+	ed      *Editor
+	binds   map[event.KeyCombo]string
+	actions map[string]KeyMapActionFn
+}
+
+type KeyMapActionFn func(args *KeyMapActionArgs) error
+
+type KeyMapActionArgs struct {
+	Ed   *Editor
+	TA   *ui.TextArea // textarea under the pointer, could be nil
+	ERow *ERow        // could be nil
+}
+
+func NewKeyMap(ed *Editor) *KeyMap {
+	km := &KeyMap{
+		ed:      ed,
+		binds:   map[event.KeyCombo]string{},
+		actions: map[string]KeyMapActionFn{},
+	}
+	km.setupActions()
+	return km
+}
+
+//----------
+
+func (km *KeyMap) Bind(combo, action string) error {
+	kc, err := event.ParseKeyCombo(combo)
+	if err != nil {
+		return err
+	}
+	km.binds[kc] = action
+	return nil
+}
+
+func (km *KeyMap) Unbind(combo string) error {
+	kc, err := event.ParseKeyCombo(combo)
+	if err != nil {
+		return err
+	}
+	delete(km.binds, kc)
+	return nil
+}
+
+func (km *KeyMap) Binding(combo string) (string, bool) {
+	kc, err := event.ParseKeyCombo(combo)
+	if err != nil {
+		return "", false
+	}
+	action, ok := km.binds[kc]
+	return action, ok
+}
+
+// Registers a named action (ex: from a plugin OnLoad) that can then be used in bindings.
+func (km *KeyMap) SetAction(name string, fn KeyMapActionFn) {
+	km.actions[name] = fn
+}
+
+//----------
+
+func (km *KeyMap) LoadFile(filename string) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := km.Load(f); err != nil {
+		return fmt.Errorf("keymap: %v: %w", filename, err)
+	}
+	return nil
+}
+
+// Format: one binding per line, the key combination followed by the action (ex: "ctrl+shift+k rwedit.RemoveLines", "f5 GoDebug run"). Empty lines and lines starting with "#" are ignored.
+func (km *KeyMap) Load(r io.Reader) error {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		i := strings.IndexAny(s, " \t")
+		if i < 0 {
+			return fmt.Errorf("line %v: missing action", line)
+		}
+		if err := km.Bind(s[:i], strings.TrimSpace(s[i:])); err != nil {
+			return fmt.Errorf("line %v: %w", line, err)
+		}
+	}
+	return sc.Err()
+}
+
+//----------
+
+func (km *KeyMap) handleEvent(ev interface{}) (consumed bool) {
+	wi, ok := ev.(*event.WindowInput)
+	if !ok {
+		return false
+	}
+	kd, ok := wi.Event.(*event.KeyDown)
+	if !ok {
+		return false
+	}
+	action, ok := km.binds[event.KeyDownCombo(kd)]
+	if !ok {
+		return false
+	}
+
+	args := &KeyMapActionArgs{Ed: km.ed}
+	if ta, ok := km.ed.UI.Root.TextAreaAtPoint(wi.Point); ok {
+		args.TA = ta
+		if erow, ok := km.ed.NodeERow(ta); ok {
+			args.ERow = erow
+		}
+	}
+	if err := km.runAction(action, args); err != nil {
+		km.ed.Errorf("keymap: %v: %v", action, err)
+	}
+	return true
+}
+
+func (km *KeyMap) runAction(action string, args *KeyMapActionArgs) error {
+	if fn, ok := km.actions[action]; ok {
+		return fn(args)
+	}
+
+	// toolbar cmd
+	data := toolbarparser.Parse(action)
+	if len(data.Parts) == 0 || len(data.Parts[0].Args) == 0 {
+		return fmt.Errorf("empty action")
+	}
+	internalCmd(km.ed, data.Parts[0], args.ERow)
+	return nil
+}
+
+//----------
+
+func (km *KeyMap) setupActions() {
+	km.SetAction("nop", func(args *KeyMapActionArgs) error {
+		return nil // disables a hard-coded shortcut
+	})
+	km.SetAction("editor.cancel", func(args *KeyMapActionArgs) error {
+		args.Ed.cancelAll()
+		return nil
+	})
+	km.SetAction("editor.toggleInfo", func(args *KeyMapActionArgs) error {
+		args.Ed.toggleInfoFloatBox()
+		return nil
+	})
+
+	// rwedit functions, run on the textarea under the pointer
+	rw := func(name string, fn func(*rwedit.Ctx) error) {
+		km.SetAction("rwedit."+name, func(args *KeyMapActionArgs) error {
+			ta := args.TA
+			if ta == nil {
+				return fmt.Errorf("no textarea")
+			}
+			ta.BeginUndoGroup()
+			defer ta.EndUndoGroup()
+			ctx := ta.EditCtx()
+			if err := fn(ctx); err != nil {
+				return err
+			}
+			ctx.Fns.MakeIndexVisible(ctx.C.Index())
+			return nil
+		})
+	}
+	rw("AddCursorNextOccurrence", rwedit.AddCursorNextOccurrence)
+	rw("AutoIndent", rwedit.AutoIndent)
+	rw("Backspace", rwedit.Backspace)
+	rw("Comment", rwedit.Comment)
+	rw("Copy", rwedit.Copy)
+	rw("Cut", rwedit.Cut)
+	rw("Delete", rwedit.Delete)
+	rw("DuplicateLines", rwedit.DuplicateLines)
+	rw("MoveLineDown", rwedit.MoveLineDown)
+	rw("MoveLineUp", rwedit.MoveLineUp)
+	rw("Paste", func(ctx *rwedit.Ctx) error {
+		rwedit.Paste(ctx, event.CIClipboard)
+		return nil
+	})
+	rw("Redo", rwedit.Redo)
+	rw("RemoveLines", rwedit.RemoveLines)
+	rw("SelectAll", rwedit.SelectAll)
+	rw("SelectLine", rwedit.SelectLine)
+	rw("SelectWord", rwedit.SelectWord)
+	rw("TabLeft", rwedit.TabLeft)
+	rw("TabRight", rwedit.TabRight)
+	rw("Uncomment", rwedit.Uncomment)
+	rw("Undo", rwedit.Undo)
+}
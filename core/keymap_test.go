@@ -0,0 +1,48 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/util/uiutil/event"
+)
+
+func TestKeyMapLoad(t *testing.T) {
+	s := `
+# comment
+ctrl+shift+k	rwedit.RemoveLines
+Alt+F5 GoDebug run -t
+`
+	km := NewKeyMap(nil)
+	if err := km.Load(strings.NewReader(s)); err != nil {
+		t.Fatal(err)
+	}
+	action, ok := km.Binding("shift+ctrl+k")
+	if !ok || action != "rwedit.RemoveLines" {
+		t.Fatalf("%v %q", ok, action)
+	}
+	ev := &event.KeyDown{KeySym: event.KSymF5, Mods: event.ModAlt | event.ModNum}
+	action, ok = km.binds[event.KeyDownCombo(ev)]
+	if !ok || action != "GoDebug run -t" {
+		t.Fatalf("%v %q", ok, action)
+	}
+}
+
+func TestKeyMapLoadErr(t *testing.T) {
+	for _, s := range []string{"ctrl+k", "ctrl+nokey aaa", "hyper+k aaa"} {
+		km := NewKeyMap(nil)
+		if err := km.Load(strings.NewReader(s)); err == nil {
+			t.Fatalf("expecting error: %q", s)
+		}
+	}
+}
+
+func TestKeyComboString(t *testing.T) {
+	kc, err := event.ParseKeyCombo("shift+ctrl+escape")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := kc.String(); s != "ctrl+shift+escape" {
+		t.Fatal(s)
+	}
+}
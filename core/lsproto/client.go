@@ -14,7 +14,6 @@ import (
 
 	"github.com/jmigpin/editor/util/ctxutil"
 	"github.com/jmigpin/editor/util/iout"
-	"github.com/jmigpin/editor/util/iout/iorw"
 	"github.com/jmigpin/editor/util/parseutil"
 )
 
@@ -23,7 +22,7 @@ type Client struct {
 	li           *LangInstance
 	readLoopWait sync.WaitGroup
 
-	fversions map[string]int
+	foldersMu sync.Mutex
 	folders   []*WorkspaceFolder
 
 	serverCapabilities struct {
@@ -31,7 +30,8 @@ type Client struct {
 			folders bool
 			symbol  bool
 		}
-		rename bool
+		rename           bool
+		textDocumentSync TextDocumentSyncKind
 	}
 }
 
@@ -50,11 +50,11 @@ func NewClientTCP(ctx context.Context, addr string, li *LangInstance) (*Client,
 //----------
 
 func NewClientIO(ctx context.Context, rwc io.ReadWriteCloser, li *LangInstance) *Client {
-	cli := &Client{li: li, fversions: map[string]int{}}
+	cli := &Client{li: li}
 
 	cc := NewJsonCodec(rwc)
-	cc.OnNotificationMessage = cli.onNotificationMessage
 	cc.OnUnexpectedServerReply = cli.onUnexpectedServerReply
+	cli.registerServerHandlers(cc)
 
 	cli.rcli = rpc.NewClientWithCodec(cc)
 
@@ -137,12 +137,140 @@ func (cli *Client) Call(ctx context.Context, method string, args, reply interfac
 
 //----------
 
-func (cli *Client) onNotificationMessage(msg *NotificationMessage) {
-	// Msgs like:
-	// - a notification was sent to the srv, not expecting a reply, but it receives one because it was an error (has id)
-	// {"error":{"code":-32601,"message":"method not found"},"id":2,"jsonrpc":"2.0"}
+// Handlers for messages initiated by the server.
+func (cli *Client) registerServerHandlers(cc *JsonCodec) {
+	// notifications
+	notifs := map[string]func(json.RawMessage) error{
+		"textDocument/publishDiagnostics": cli.onPublishDiagnostics,
+		"window/showMessage":              cli.onShowMessage,
+		"window/logMessage":               cli.onShowMessage,
+		"$/progress":                      cli.onProgress,
+	}
+	for method, fn := range notifs {
+		fn := fn
+		cc.HandleNotification(method, func(params json.RawMessage) {
+			if err := fn(params); err != nil {
+				cli.li.lang.PrintWrapError(err)
+			}
+		})
+	}
+
+	// requests
+	cc.HandleRequest("workspace/configuration", cli.onWorkspaceConfiguration)
+	cc.HandleRequest("workspace/applyEdit", cli.onWorkspaceApplyEdit)
+	cc.HandleRequest("workspace/workspaceFolders", cli.onWorkspaceFolders)
+	nullReply := func(json.RawMessage) (interface{}, error) {
+		return nil, nil
+	}
+	cc.HandleRequest("window/workDoneProgress/create", nullReply)
+	cc.HandleRequest("window/showMessageRequest", func(params json.RawMessage) (interface{}, error) {
+		// show the msg, but don't choose any action
+		return nil, cli.onShowMessage(params)
+	})
+	cc.HandleRequest("client/registerCapability", nullReply)
+	cc.HandleRequest("client/unregisterCapability", nullReply)
+}
+
+//----------
+
+func (cli *Client) onPublishDiagnostics(params json.RawMessage) error {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_publishDiagnostics
+
+	opt := PublishDiagnosticsParams{}
+	if err := decodeJsonRaw(params, &opt); err != nil {
+		return fmt.Errorf("publishdiagnostics: %w", err)
+	}
+	filename, err := parseutil.UrlToAbsFilename(string(opt.Uri))
+	if err != nil {
+		return fmt.Errorf("publishdiagnostics: %w", err)
+	}
+	cli.li.lang.man.setDiagnostics(filename, opt.Diagnostics)
+	return nil
+}
+
+func (cli *Client) onShowMessage(params json.RawMessage) error {
+	// https://microsoft.github.io/language-server-protocol/specification#window_showMessage
+	// https://microsoft.github.io/language-server-protocol/specification#window_logMessage
+
+	opt := ShowMessageParams{}
+	if err := decodeJsonRaw(params, &opt); err != nil {
+		return fmt.Errorf("showmessage: %w", err)
+	}
+	s := fmt.Sprintf("%v: %v", opt.Type, opt.Message)
+	cli.li.lang.man.Message(cli.li.lang.WrapMsg(s))
+	return nil
+}
+
+func (cli *Client) onProgress(params json.RawMessage) error {
+	// https://microsoft.github.io/language-server-protocol/specification#progress
+
+	opt := ProgressParams{}
+	if err := decodeJsonRaw(params, &opt); err != nil {
+		return fmt.Errorf("progress: %w", err)
+	}
+	v := WorkDoneProgress{}
+	if err := decodeJsonRaw(opt.Value, &v); err != nil {
+		return fmt.Errorf("progress: %w", err)
+	}
+	s := v.String()
+	if s == "" {
+		return nil
+	}
+	cli.li.lang.man.Message(cli.li.lang.WrapMsg("progress: " + s))
+	return nil
+}
+
+func (cli *Client) onWorkspaceConfiguration(params json.RawMessage) (interface{}, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#workspace_configuration
 
-	//logJson("notification <--: ", msg)
+	opt := ConfigurationParams{}
+	if err := decodeJsonRaw(params, &opt); err != nil {
+		return nil, fmt.Errorf("configuration: %w", err)
+	}
+	settings, err := cli.li.lang.Reg.Settings()
+	if err != nil {
+		return nil, fmt.Errorf("configuration: %w", err)
+	}
+	// one result per item, null if not found
+	result := make([]interface{}, len(opt.Items))
+	for i, item := range opt.Items {
+		if settings == nil {
+			continue
+		}
+		if item.Section == "" {
+			result[i] = settings
+			continue
+		}
+		if v, err := JsonGetPath(settings, item.Section); err == nil {
+			result[i] = v
+		}
+	}
+	return result, nil
+}
+
+func (cli *Client) onWorkspaceApplyEdit(params json.RawMessage) (interface{}, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#workspace_applyEdit
+
+	opt := ApplyWorkspaceEditParams{}
+	if err := decodeJsonRaw(params, &opt); err != nil {
+		return nil, fmt.Errorf("applyedit: %w", err)
+	}
+	result := &ApplyWorkspaceEditResult{}
+	if err := cli.li.lang.man.applyWorkspaceEdit(&opt.Edit); err != nil {
+		result.FailureReason = err.Error()
+		cli.li.lang.PrintWrapError(fmt.Errorf("applyedit: %w", err))
+	} else {
+		result.Applied = true
+	}
+	return result, nil
+}
+
+func (cli *Client) onWorkspaceFolders(params json.RawMessage) (interface{}, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#workspace_workspaceFolders
+	// called from the reader goroutine
+	cli.foldersMu.Lock()
+	defer cli.foldersMu.Unlock()
+	return cli.folders, nil
 }
 
 func (cli *Client) onUnexpectedServerReply(resp *Response) {
@@ -195,30 +323,33 @@ func (cli *Client) initializeParams() (json.RawMessage, error) {
 	_ = rootUri
 
 	// workspace folders
-	cli.folders = []*WorkspaceFolder{{Uri: rootUri}}
-	foldersBytes, err := encodeJson(cli.folders)
+	folders := []*WorkspaceFolder{{Uri: rootUri}}
+	cli.foldersMu.Lock()
+	cli.folders = folders
+	cli.foldersMu.Unlock()
+	foldersBytes, err := encodeJson(folders)
 	if err != nil {
 		return nil, err
 	}
 
-	// other capabilities
-	//"capabilities":{
-	//	"workspace":{
-	//		"configuration":true,
-	//		"workspaceFolders":true
-	//	},
-	//	"textDocument":{
-	//		"publishDiagnostics":{
-	//			"relatedInformation":true
-	//		}
-	//	}
-	//}
+	// capabilities for the requests/notifications initiated by the server
+	caps := `{` +
+		`"workspace":{` +
+		`"configuration":true,` +
+		`"applyEdit":true,` +
+		`"workspaceFolders":true` +
+		`},` +
+		`"window":{` +
+		`"workDoneProgress":true` +
+		`}` +
+		`}`
 
 	raw := json.RawMessage("{" +
 		// TODO: gopls is not allowing rooturi=null at the moment...
 		fmt.Sprintf("%q:%q", "rootUri", rootUri) + "," +
 		// set workspace folders to use the later as "remove" value
-		fmt.Sprintf("%q:%s", "workspaceFolders", foldersBytes) +
+		fmt.Sprintf("%q:%s", "workspaceFolders", foldersBytes) + "," +
+		fmt.Sprintf("%q:%s", "capabilities", caps) +
 		"}")
 	return raw, nil
 }
@@ -243,14 +374,30 @@ func (cli *Client) readServerCapabilities(caps interface{}) {
 		}
 	}
 
+	// can be a bool or an object with options
 	path = "capabilities.workspaceSymbolProvider"
 	v, err = JsonGetPath(caps, path)
 	if err == nil {
-		if b, ok := v.(bool); ok && b == true {
+		switch t := v.(type) {
+		case bool:
+			cli.serverCapabilities.workspace.symbol = t
+		case map[string]interface{}:
 			cli.serverCapabilities.workspace.symbol = true
 		}
 	}
 
+	// can be a number or an object with options
+	path = "capabilities.textDocumentSync"
+	v, err = JsonGetPath(caps, path)
+	if err == nil {
+		if m, ok := v.(map[string]interface{}); ok {
+			v = m["change"]
+		}
+		if f, ok := v.(float64); ok {
+			cli.serverCapabilities.textDocumentSync = TextDocumentSyncKind(f)
+		}
+	}
+
 	path = "capabilities.renameProvider"
 	v, err = JsonGetPath(caps, path)
 	if err == nil {
@@ -317,7 +464,7 @@ func (cli *Client) TextDocumentDidClose(ctx context.Context, filename string) er
 	return cli.Call(ctx, "noreply:textDocument/didClose", &opt, nil)
 }
 
-func (cli *Client) TextDocumentDidChange(ctx context.Context, filename, text string, version int) error {
+func (cli *Client) TextDocumentDidChange(ctx context.Context, filename string, version int, changes []*TextDocumentContentChangeEvent) error {
 	// https://microsoft.github.io/language-server-protocol/specification#textDocument_didChange
 
 	opt := &DidChangeTextDocumentParams{}
@@ -327,25 +474,7 @@ func (cli *Client) TextDocumentDidChange(ctx context.Context, filename, text str
 		return err
 	}
 	opt.TextDocument.Uri = DocumentUri(url)
-
-	// text end line/column
-	rd := iorw.NewStringReaderAt(text)
-	pos, err := OffsetToPosition(rd, len(text))
-	if err != nil {
-		return err
-	}
-
-	// changes
-	opt.ContentChanges = []*TextDocumentContentChangeEvent{
-		{
-			Range: Range{
-				Start: Position{0, 0},
-				End:   pos,
-			},
-			//RangeLength: len(text), // TODO: not working?
-			Text: text,
-		},
-	}
+	opt.ContentChanges = changes
 	return cli.Call(ctx, "noreply:textDocument/didChange", &opt, nil)
 }
 
@@ -410,15 +539,139 @@ func (cli *Client) TextDocumentCompletion(ctx context.Context, filename string,
 
 //----------
 
-func (cli *Client) TextDocumentDidOpenVersion(ctx context.Context, filename string, b []byte) error {
-	v, ok := cli.fversions[filename]
-	if !ok {
-		v = 1
-	} else {
-		v++
+func (cli *Client) TextDocumentHover(ctx context.Context, filename string, pos Position) (*Hover, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_hover
+
+	opt := &TextDocumentPositionParams{}
+	opt.Position = pos
+	url, err := parseutil.AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+
+	result := Hover{}
+	if err := cli.Call(ctx, "textDocument/hover", &opt, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+//----------
+
+// Result can be nil (ex: position is not inside a call).
+func (cli *Client) TextDocumentSignatureHelp(ctx context.Context, filename string, pos Position) (*SignatureHelp, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_signatureHelp
+
+	opt := &TextDocumentPositionParams{}
+	opt.Position = pos
+	url, err := parseutil.AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+
+	var result *SignatureHelp
+	if err := cli.Call(ctx, "textDocument/signatureHelp", &opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//----------
+
+func (cli *Client) TextDocumentReferences(ctx context.Context, filename string, pos Position) ([]*Location, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_references
+
+	opt := &ReferenceParams{}
+	opt.Context.IncludeDeclaration = true
+	opt.Position = pos
+	url, err := parseutil.AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
 	}
-	cli.fversions[filename] = v
-	return cli.TextDocumentDidOpen(ctx, filename, string(b), v)
+	opt.TextDocument.Uri = DocumentUri(url)
+
+	result := []*Location{}
+	if err := cli.Call(ctx, "textDocument/references", &opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//----------
+
+// Hierarchical results (DocumentSymbol) are flattened into SymbolInformation with the parent name as the container name.
+func (cli *Client) TextDocumentDocumentSymbol(ctx context.Context, filename string) ([]*SymbolInformation, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_documentSymbol
+
+	opt := &DocumentSymbolParams{}
+	url, err := parseutil.AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+
+	result := []json.RawMessage{}
+	if err := cli.Call(ctx, "textDocument/documentSymbol", &opt, &result); err != nil {
+		return nil, err
+	}
+
+	res := []*SymbolInformation{}
+	for _, raw := range result {
+		// detect result type by the "location" field
+		m := map[string]json.RawMessage{}
+		if err := decodeJsonRaw(raw, &m); err != nil {
+			return nil, err
+		}
+		if _, ok := m["location"]; ok {
+			si := &SymbolInformation{}
+			if err := decodeJsonRaw(raw, si); err != nil {
+				return nil, err
+			}
+			res = append(res, si)
+			continue
+		}
+		ds := &DocumentSymbol{}
+		if err := decodeJsonRaw(raw, ds); err != nil {
+			return nil, err
+		}
+		res = appendDocumentSymbols(res, opt.TextDocument.Uri, "", ds)
+	}
+	return res, nil
+}
+
+func appendDocumentSymbols(res []*SymbolInformation, uri DocumentUri, container string, ds *DocumentSymbol) []*SymbolInformation {
+	rang := ds.SelectionRange
+	si := &SymbolInformation{
+		Name:          ds.Name,
+		Kind:          ds.Kind,
+		Deprecated:    ds.Deprecated,
+		Location:      Location{Uri: uri, Range: &rang},
+		ContainerName: container,
+	}
+	res = append(res, si)
+	for _, c := range ds.Children {
+		res = appendDocumentSymbols(res, uri, ds.Name, c)
+	}
+	return res
+}
+
+//----------
+
+func (cli *Client) WorkspaceSymbol(ctx context.Context, query string) ([]*SymbolInformation, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#workspace_symbol
+
+	if !cli.serverCapabilities.workspace.symbol {
+		return nil, fmt.Errorf("server did not advertise workspace symbol capability")
+	}
+
+	opt := &WorkspaceSymbolParams{Query: query}
+	result := []*SymbolInformation{}
+	if err := cli.Call(ctx, "workspace/symbol", &opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 //----------
@@ -439,13 +692,16 @@ func (cli *Client) UpdateWorkspaceFolder(ctx context.Context, dir string) error
 		return nil
 	}
 
-	removed := cli.folders
 	url, err := parseutil.AbsFilenameToUrl(dir)
 	if err != nil {
 		return err
 	}
-	cli.folders = []*WorkspaceFolder{{Uri: DocumentUri(url)}}
-	return cli.WorkspaceDidChangeWorkspaceFolders(ctx, cli.folders, removed)
+	added := []*WorkspaceFolder{{Uri: DocumentUri(url)}}
+	cli.foldersMu.Lock()
+	removed := cli.folders
+	cli.folders = added
+	cli.foldersMu.Unlock()
+	return cli.WorkspaceDidChangeWorkspaceFolders(ctx, added, removed)
 
 }
 
@@ -465,40 +721,6 @@ func (cli *Client) UpdateWorkspaceFolder(ctx context.Context, dir string) error
 
 //----------
 
-//func (cli *Client) SyncText(ctx context.Context, filename string, b []byte) error {
-//	v, ok := cli.fversions[filename]
-//	if !ok {
-//		v = 1
-//	} else {
-//		v++
-//	}
-//	cli.fversions[filename] = v
-
-//	// close before opening. Keeps open/close balanced since not using "didchange", while needing to update the src.
-//	if v > 1 {
-//		err := cli.TextDocumentDidClose(ctx, filename)
-//		if err != nil {
-//			return err
-//		}
-//	}
-//	// send latest version of the document
-//	err := cli.TextDocumentDidOpen(ctx, filename, string(b), v)
-//	if err != nil {
-//		return err
-//	}
-
-//	// TODO: clangd doesn't work well with didchange (works with sending always a didopen)
-//	//} else {
-//	//	err := cli.TextDocumentDidChange(ctx, filename, string(b), v)
-//	//	if err != nil {
-//	//		return err
-//	//	}
-//	//}
-//	return nil
-//}
-
-//----------
-
 func (cli *Client) TextDocumentRename(ctx context.Context, filename string, pos Position, newName string) (*WorkspaceEdit, error) {
 	//// Commented: try it anyway
 	//if !cli.serverCapabilities.rename {
@@ -520,6 +742,91 @@ func (cli *Client) TextDocumentRename(ctx context.Context, filename string, pos
 
 //----------
 
+func (cli *Client) TextDocumentFormatting(ctx context.Context, filename string, opts FormattingOptions) ([]*TextEdit, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_formatting
+
+	opt := &DocumentFormattingParams{}
+	opt.Options = opts
+	url, err := parseutil.AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := []*TextEdit{}
+	if err := cli.Call(ctx, "textDocument/formatting", &opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (cli *Client) TextDocumentRangeFormatting(ctx context.Context, filename string, rang Range, opts FormattingOptions) ([]*TextEdit, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_rangeFormatting
+
+	opt := &DocumentRangeFormattingParams{}
+	opt.Range = rang
+	opt.Options = opts
+	url, err := parseutil.AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+	result := []*TextEdit{}
+	if err := cli.Call(ctx, "textDocument/rangeFormatting", &opt, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//----------
+
+func (cli *Client) TextDocumentCodeAction(ctx context.Context, filename string, rang Range, diags []*Diagnostic) ([]*CodeAction, error) {
+	// https://microsoft.github.io/language-server-protocol/specification#textDocument_codeAction
+
+	opt := &CodeActionParams{}
+	opt.Range = rang
+	opt.Context.Diagnostics = diags
+	if opt.Context.Diagnostics == nil {
+		opt.Context.Diagnostics = []*Diagnostic{} // not omitempty
+	}
+	url, err := parseutil.AbsFilenameToUrl(filename)
+	if err != nil {
+		return nil, err
+	}
+	opt.TextDocument.Uri = DocumentUri(url)
+
+	// result: (Command | CodeAction)[]
+	raws := []json.RawMessage{}
+	if err := cli.Call(ctx, "textDocument/codeAction", &opt, &raws); err != nil {
+		return nil, err
+	}
+	result := []*CodeAction{}
+	for _, raw := range raws {
+		ca := &CodeAction{}
+		if err := decodeJsonRaw(raw, ca); err != nil {
+			// a command has the "command" field as a string
+			cmd := &Command{}
+			if err := decodeJsonRaw(raw, cmd); err != nil {
+				return nil, err
+			}
+			ca = &CodeAction{Title: cmd.Title, Command: cmd}
+		}
+		result = append(result, ca)
+	}
+	return result, nil
+}
+
+func (cli *Client) WorkspaceExecuteCommand(ctx context.Context, cmd *Command) error {
+	// https://microsoft.github.io/language-server-protocol/specification#workspace_executeCommand
+
+	opt := &ExecuteCommandParams{}
+	opt.Command = cmd.Command
+	opt.Arguments = cmd.Arguments
+	var result interface{}
+	return cli.Call(ctx, "workspace/executeCommand", &opt, &result)
+}
+
+//----------
+
 func JsonGetPath(v interface{}, path string) (interface{}, error) {
 	args := strings.Split(path, ".")
 	return jsonGetPath2(v, args)
@@ -538,5 +845,8 @@ func jsonGetPath2(v interface{}, args []string) (interface{}, error) {
 	case bool, int, float32, float64:
 		return t, nil
 	}
+	if len(args) == 0 { // path fully matched a non basic value (ex: object)
+		return v, nil
+	}
 	return nil, fmt.Errorf("not found: %v", args[0])
 }
@@ -2,6 +2,7 @@ package lsproto
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -17,7 +18,6 @@ import (
 
 // Implements rpc.ClientCodec
 type JsonCodec struct {
-	OnNotificationMessage   func(*NotificationMessage)
 	OnUnexpectedServerReply func(*Response)
 
 	rwc           io.ReadWriteCloser
@@ -26,12 +26,27 @@ type JsonCodec struct {
 
 	readData readData // used by read response header/body
 
+	writeMu sync.Mutex // requests and replies to server requests
+
+	// handlers for messages initiated by the server
+	handlers struct {
+		sync.Mutex
+		requests      map[string]ServerRequestFn
+		notifications map[string]ServerNotificationFn
+	}
+
 	mu struct {
 		sync.Mutex
 		closed bool
 	}
 }
 
+// Runs on its own goroutine. The result is sent as the reply.
+type ServerRequestFn func(params json.RawMessage) (interface{}, error)
+
+// Runs on the read loop goroutine (keeps the order of the notifications).
+type ServerNotificationFn func(params json.RawMessage)
+
 // Needs a call to ReadLoop() to start reading.
 func NewJsonCodec(rwc io.ReadWriteCloser) *JsonCodec {
 	c := &JsonCodec{rwc: rwc}
@@ -73,7 +88,9 @@ func (c *JsonCodec) WriteRequest(req *rpc.Request, data interface{}) error {
 
 	logPrintf("write req -->: %s%s", h, string(b))
 
+	c.writeMu.Lock()
 	_, err = c.rwc.Write(buf)
+	c.writeMu.Unlock()
 	if err != nil {
 		return err
 	}
@@ -120,6 +137,17 @@ func (c *JsonCodec) ReadResponseHeader(resp *rpc.Response) error {
 		resp.Seq = t
 		return nil
 	case []byte:
+		// server push: requests from the server can have string ids
+		push := &serverPushMessage{}
+		if err := decodeJson(bytes.NewReader(t), push); err != nil {
+			return fmt.Errorf("jsoncodec: decode: %v", err)
+		}
+		if push.isServerPush() {
+			c.readData.resp = &Response{NotificationMessage: push.NotificationMessage}
+			c.readData.pushId = push.Id
+			return nil
+		}
+
 		// decode json
 		lspResp := &Response{}
 		rd := bytes.NewReader(t)
@@ -128,9 +156,7 @@ func (c *JsonCodec) ReadResponseHeader(resp *rpc.Response) error {
 		}
 		c.readData.resp = lspResp
 		// msg id (needed for the rpc to run the reply to the caller)
-		if !lspResp.isServerPush() {
-			resp.Seq = uint64(lspResp.Id)
-		}
+		resp.Seq = uint64(lspResp.Id)
 		return nil
 	default:
 		panic("!")
@@ -143,16 +169,12 @@ func (c *JsonCodec) ReadResponseBody(reply interface{}) error {
 		return nil
 	}
 
-	// server push callback (no id)
+	// server push (request or notification)
 	if c.readData.resp.isServerPush() {
 		if reply != nil {
 			return fmt.Errorf("jsoncodec: server push with reply expecting data: %v", reply)
 		}
-		// run callback
-		nm := c.readData.resp.NotificationMessage
-		if c.OnNotificationMessage != nil {
-			c.OnNotificationMessage(&nm)
-		}
+		c.handleServerPush(c.readData.pushId, &c.readData.resp.NotificationMessage)
 		return nil
 	}
 
@@ -258,6 +280,86 @@ func (c *JsonCodec) unexpectedServerReply(resp *Response) {
 
 //----------
 
+func (c *JsonCodec) HandleRequest(method string, fn ServerRequestFn) {
+	c.handlers.Lock()
+	defer c.handlers.Unlock()
+	if c.handlers.requests == nil {
+		c.handlers.requests = map[string]ServerRequestFn{}
+	}
+	c.handlers.requests[method] = fn
+}
+
+func (c *JsonCodec) HandleNotification(method string, fn ServerNotificationFn) {
+	c.handlers.Lock()
+	defer c.handlers.Unlock()
+	if c.handlers.notifications == nil {
+		c.handlers.notifications = map[string]ServerNotificationFn{}
+	}
+	c.handlers.notifications[method] = fn
+}
+
+// A server push with an id (number or string) is a request that expects a reply.
+func (c *JsonCodec) handleServerPush(id json.RawMessage, nm *NotificationMessage) {
+	isRequest := len(id) > 0 && string(id) != "null"
+
+	c.handlers.Lock()
+	reqFn, okReq := c.handlers.requests[nm.Method]
+	notFn, okNot := c.handlers.notifications[nm.Method]
+	c.handlers.Unlock()
+
+	if !isRequest {
+		if okNot {
+			notFn(nm.Params)
+		}
+		return
+	}
+
+	// reply in another goroutine to not block the read loop (the handler might need to wait on other requests)
+	go func() {
+		var result interface{}
+		var err error
+		if okReq {
+			result, err = reqFn(nm.Params)
+		} else {
+			err = &ResponseError{Code: -32601, Message: fmt.Sprintf("method not found: %v", nm.Method)}
+		}
+		if err2 := c.writeServerRequestReply(id, result, err); err2 != nil {
+			logPrintf("write reply err -->: %v", err2)
+		}
+	}()
+}
+
+func (c *JsonCodec) writeServerRequestReply(id json.RawMessage, result interface{}, err error) error {
+	msg := &ServerRequestReplyMessage{JsonRpc: "2.0", Id: id}
+	if err != nil {
+		re, ok := err.(*ResponseError)
+		if !ok {
+			re = &ResponseError{Code: -32603, Message: err.Error()} // internal error
+		}
+		msg.Error = re
+	} else {
+		b, err := encodeJson(result)
+		if err != nil {
+			return err
+		}
+		msg.Result = b
+	}
+
+	b, err := encodeJson(msg)
+	if err != nil {
+		return err
+	}
+	h := fmt.Sprintf("Content-Length: %v\r\n\r\n", len(b))
+	logPrintf("write reply -->: %s%s", h, string(b))
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err = c.rwc.Write(append([]byte(h), b...))
+	return err
+}
+
+//----------
+
 func (c *JsonCodec) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -279,6 +381,7 @@ func (c *JsonCodec) isClosed() bool {
 type readData struct {
 	noReply bool
 	resp    *Response
+	pushId  json.RawMessage // server push request id
 }
 
 //----------
@@ -0,0 +1,68 @@
+package lsproto
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"strings"
+	"testing"
+)
+
+func TestJsonCodecServerRequest(t *testing.T) {
+	cconn, sconn := net.Pipe()
+	defer sconn.Close()
+
+	cc := NewJsonCodec(cconn)
+	cc.HandleRequest("workspace/configuration", func(params json.RawMessage) (interface{}, error) {
+		return []int{1}, nil
+	})
+	notifc := make(chan string, 1)
+	cc.HandleNotification("window/logMessage", func(params json.RawMessage) {
+		notifc <- string(params)
+	})
+	rcli := rpc.NewClientWithCodec(cc)
+	defer rcli.Close()
+	go cc.ReadLoop()
+
+	write := func(s string) {
+		fmt.Fprintf(sconn, "Content-Length: %v\r\n\r\n%s", len(s), s)
+	}
+	br := bufio.NewReader(sconn)
+	read := func() string {
+		n := 0
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				break
+			}
+			fmt.Sscanf(line, "Content-Length: %d", &n)
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(br, b); err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(string(b))
+	}
+
+	write(`{"jsonrpc":"2.0","method":"window/logMessage","params":{"type":3,"message":"m"}}`)
+	if s := <-notifc; s != `{"type":3,"message":"m"}` {
+		t.Fatal(s)
+	}
+
+	write(`{"jsonrpc":"2.0","id":"a1","method":"workspace/configuration","params":{}}`)
+	if s := read(); s != `{"jsonrpc":"2.0","id":"a1","result":[1]}` {
+		t.Fatal(s)
+	}
+
+	write(`{"jsonrpc":"2.0","id":7,"method":"unknown/method"}`)
+	if s := read(); !strings.Contains(s, `"id":7,"error":{"code":-32601`) {
+		t.Fatal(s)
+	}
+}
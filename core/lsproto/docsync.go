@@ -0,0 +1,273 @@
+package lsproto
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf16"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Documents kept open in the server. Changes are sent incrementally (if supported by the server) on the next request.
+type documents struct {
+	sync.Mutex
+	m map[string]*document // [filename]
+}
+
+type document struct {
+	cli     *Client
+	opened  bool
+	version int
+	size    int // expected content size after the changes
+	lines   lineStarts
+	changes []*TextDocumentContentChangeEvent
+	full    bool // changes could not be tracked, send the full content
+	reading bool // full content is being read/sent
+
+	sendMu sync.Mutex // keeps the order of the sent changes
+}
+
+//----------
+
+// Keeps the change to be sent to the server on the next request. Should be called before the content is changed (rd has the previous content). Changes are only kept for documents that are open in the server.
+func (man *Manager) TextDocumentChange(filename string, rd iorw.ReaderAt, index, n int, p []byte) {
+	man.docs.Lock()
+	defer man.docs.Unlock()
+	doc, ok := man.docs.m[filename]
+	if !ok {
+		return
+	}
+	doc.size += len(p) - n
+	if doc.full || doc.reading || doc.lines == nil {
+		doc.full = true
+		return
+	}
+	ch, err := textDocumentChangeEvent(rd, doc.lines, index, n, p)
+	if err != nil {
+		doc.full = true
+		return
+	}
+	doc.changes = append(doc.changes, ch)
+	doc.lines.update(index, n, p)
+}
+
+func textDocumentChangeEvent(rd iorw.ReaderAt, lines lineStarts, index, n int, p []byte) (*TextDocumentContentChangeEvent, error) {
+	start, err := lines.position(rd, index)
+	if err != nil {
+		return nil, err
+	}
+	b, err := rd.ReadFastAt(index, n)
+	if err != nil {
+		return nil, err
+	}
+	end := positionAfterText(start, b)
+	rang := Range{Start: start, End: end}
+	ch := &TextDocumentContentChangeEvent{Range: &rang, Text: string(p)}
+	return ch, nil
+}
+
+//----------
+
+// Opens the document in the server, or sends the pending changes if already opened.
+func (man *Manager) syncDocument(ctx context.Context, cli *Client, filename string, rd iorw.ReaderAt) error {
+	man.docs.Lock()
+	if man.docs.m == nil {
+		man.docs.m = map[string]*document{}
+	}
+	doc, ok := man.docs.m[filename]
+	if !ok || doc.cli != cli { // new document, or the client was restarted
+		doc = &document{cli: cli}
+		man.docs.m[filename] = doc
+	}
+	man.docs.Unlock()
+
+	doc.sendMu.Lock()
+	defer doc.sendMu.Unlock()
+
+	kind := cli.serverCapabilities.textDocumentSync
+
+	man.docs.Lock()
+	incremental := doc.opened &&
+		!doc.full &&
+		doc.size == rd.Max() && // otherwise the content was changed without being reported
+		(len(doc.changes) == 0 || kind == TextDocumentSyncKindIncremental)
+	if !incremental {
+		doc.reading = true
+		doc.full = false
+	}
+	changes := doc.changes
+	doc.changes = nil
+	opened := doc.opened
+	if incremental && len(changes) == 0 {
+		man.docs.Unlock()
+		return nil // nothing to send
+	}
+	doc.version++
+	version := doc.version
+	man.docs.Unlock()
+
+	if incremental {
+		err := cli.TextDocumentDidChange(ctx, filename, version, changes)
+		if err != nil {
+			man.docs.Lock()
+			doc.full = true
+			man.docs.Unlock()
+		}
+		return err
+	}
+
+	// full content
+	b, err := iorw.ReadFastFull(rd)
+	if err == nil {
+		err = man.sendFullDocument(ctx, cli, filename, opened, version, string(b))
+	}
+
+	man.docs.Lock()
+	defer man.docs.Unlock()
+	doc.reading = false
+	if err != nil {
+		doc.full = true
+		return err
+	}
+	doc.opened = true
+	doc.size = len(b)
+	doc.lines = newLineStarts(b)
+	return nil
+}
+
+func (man *Manager) sendFullDocument(ctx context.Context, cli *Client, filename string, opened bool, version int, text string) error {
+	if opened {
+		switch cli.serverCapabilities.textDocumentSync {
+		case TextDocumentSyncKindFull, TextDocumentSyncKindIncremental:
+			changes := []*TextDocumentContentChangeEvent{{Text: text}}
+			return cli.TextDocumentDidChange(ctx, filename, version, changes)
+		default: // server doesn't accept changes, reopen
+			if err := cli.TextDocumentDidClose(ctx, filename); err != nil {
+				return err
+			}
+		}
+	}
+	return cli.TextDocumentDidOpen(ctx, filename, text, version)
+}
+
+//----------
+
+// Closes the document in the server (if opened).
+func (man *Manager) DidClose(ctx context.Context, filename string) error {
+	man.docs.Lock()
+	doc, ok := man.docs.m[filename]
+	delete(man.docs.m, filename)
+	man.docs.Unlock()
+	if !ok {
+		return nil
+	}
+
+	doc.sendMu.Lock()
+	defer doc.sendMu.Unlock()
+	if !doc.opened {
+		return nil
+	}
+	return doc.cli.TextDocumentDidClose(ctx, filename)
+}
+
+// Version of the document content sent to the server. Not open if the server is using the file on disk. Errors if the content was changed since (changes not sent yet).
+func (man *Manager) DocumentVersion(filename string) (version int, open bool, _ error) {
+	man.docs.Lock()
+	defer man.docs.Unlock()
+	doc, ok := man.docs.m[filename]
+	if !ok || !doc.opened {
+		return 0, false, nil
+	}
+	if len(doc.changes) > 0 || doc.full || doc.reading {
+		return doc.version, true, fmt.Errorf("content changed since the last request: %v", filename)
+	}
+	return doc.version, true, nil
+}
+
+func (man *Manager) clearDocuments() {
+	man.docs.Lock()
+	defer man.docs.Unlock()
+	man.docs.m = nil
+}
+
+//----------
+
+// Offsets of the lines starts of the document content. Updated with each change to avoid reading the content from the start to get a position.
+type lineStarts []int
+
+func newLineStarts(b []byte) lineStarts {
+	ls := lineStarts{0}
+	for i, c := range b {
+		if c == '\n' {
+			ls = append(ls, i+1)
+		}
+	}
+	return ls
+}
+
+// Zero based position of the offset. Only reads the content of the offset line.
+func (ls lineStarts) position(rd iorw.ReaderAt, offset int) (Position, error) {
+	k := sort.SearchInts(ls, offset+1) - 1 // last line start <= offset
+	b, err := rd.ReadFastAt(ls[k], offset-ls[k])
+	if err != nil {
+		return Position{}, err
+	}
+	return Position{Line: k, Character: utf16Len(b)}, nil
+}
+
+// Replaces n bytes at index with p.
+func (ls *lineStarts) update(index, n int, p []byte) {
+	u := *ls
+	// line starts inside the replaced bytes are removed
+	a := sort.SearchInts(u, index+1)
+	b := sort.SearchInts(u, index+n+1)
+
+	nl := bytes.Count(p, []byte("\n"))
+	tail := len(u) - b
+	size := a + nl + tail
+	if size > cap(u) {
+		u2 := make([]int, size, size*5/4+8)
+		copy(u2, u[:a])
+		copy(u2[a+nl:], u[b:])
+		u = u2
+	} else {
+		u = u[:size]
+		copy(u[a+nl:], u[b:b+tail])
+	}
+
+	// shift the following line starts
+	d := len(p) - n
+	for i := a + nl; i < size; i++ {
+		u[i] += d
+	}
+	// new line starts
+	k := a
+	for i, c := range p {
+		if c == '\n' {
+			u[k] = index + i + 1
+			k++
+		}
+	}
+	*ls = u
+}
+
+//----------
+
+// Zero based position after the text that starts at pos.
+func positionAfterText(pos Position, b []byte) Position {
+	k := bytes.LastIndexByte(b, '\n')
+	if k < 0 {
+		pos.Character += utf16Len(b)
+		return pos
+	}
+	pos.Line += bytes.Count(b, []byte("\n"))
+	pos.Character = utf16Len(b[k+1:])
+	return pos
+}
+
+func utf16Len(b []byte) int {
+	return len(utf16.Encode([]rune(string(b))))
+}
@@ -0,0 +1,123 @@
+package lsproto
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestTextDocumentChange(t *testing.T) {
+	man := NewManager(nil)
+
+	rw := iorw.NewBytesReadWriterAt([]byte("ab\ncd€\nef"))
+	change := func(i, n int, s string) {
+		t.Helper()
+		man.TextDocumentChange("/a/b.go", rw, i, n, []byte(s))
+		if err := rw.OverwriteAt(i, n, []byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// not open in a server, not kept
+	change(0, 0, "0")
+	if len(man.docs.m) != 0 {
+		t.Fatal(man.docs.m)
+	}
+
+	b, _ := iorw.ReadFastFull(rw)
+	doc := &document{opened: true, size: rw.Max(), lines: newLineStarts(b)}
+	man.docs.m = map[string]*document{"/a/b.go": doc}
+
+	change(4, 7, "X") // "cd€\ne"
+	change(1, 0, "\n\n")
+
+	if len(doc.changes) != 2 {
+		t.Fatal(doc.changes)
+	}
+	ch := doc.changes[0]
+	if *ch.Range != (Range{Start: Position{1, 0}, End: Position{2, 1}}) || ch.Text != "X" {
+		t.Fatalf("%v", ch.Range)
+	}
+	ch = doc.changes[1]
+	if *ch.Range != (Range{Start: Position{0, 1}, End: Position{0, 1}}) || ch.Text != "\n\n" {
+		t.Fatalf("%v", ch.Range)
+	}
+	if doc.size != rw.Max() {
+		t.Fatal(doc.size, rw.Max())
+	}
+	b, _ = iorw.ReadFastFull(rw)
+	if fmt.Sprint(doc.lines) != fmt.Sprint(newLineStarts(b)) {
+		t.Fatal(doc.lines, newLineStarts(b))
+	}
+}
+
+func TestLineStartsUpdate(t *testing.T) {
+	s := "ab\ncd\n\nef\ng"
+	ls := newLineStarts([]byte(s))
+	edits := []struct {
+		i, n int
+		p    string
+	}{
+		{0, 0, "x"},
+		{1, 4, ""},
+		{2, 0, "\n\n\n"},
+		{3, 3, "y\n"},
+		{0, 0, "\n"},
+		{5, 4, "z"},
+	}
+	for _, e := range edits {
+		s = s[:e.i] + e.p + s[e.i+e.n:]
+		ls.update(e.i, e.n, []byte(e.p))
+		exp := newLineStarts([]byte(s))
+		if fmt.Sprint(ls) != fmt.Sprint(exp) {
+			t.Fatalf("%q: %v, expecting %v", s, ls, exp)
+		}
+	}
+
+	rd := iorw.NewStringReaderAt(s)
+	for i := 0; i <= len(s); i++ {
+		p1, err := ls.position(rd, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p2, err := OffsetToPosition(rd, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p1 != p2 {
+			t.Fatalf("%v: %v, expecting %v", i, p1, p2)
+		}
+	}
+}
+
+func TestPositionAfterText(t *testing.T) {
+	p := positionAfterText(Position{2, 3}, []byte("a€𝄞"))
+	if p != (Position{2, 7}) {
+		t.Fatal(p)
+	}
+	p = positionAfterText(Position{2, 3}, []byte("a\nb\n𝄞c"))
+	if p != (Position{4, 3}) {
+		t.Fatal(p)
+	}
+}
+
+func TestDocumentVersion(t *testing.T) {
+	man := NewManager(nil)
+	if _, open, err := man.DocumentVersion("/a/b.go"); open || err != nil {
+		t.Fatal(open, err)
+	}
+
+	rw := iorw.NewBytesReadWriterAt([]byte("abc"))
+	doc := &document{opened: true, version: 3, size: rw.Max(), lines: lineStarts{0}}
+	man.docs.m = map[string]*document{"/a/b.go": doc}
+	if v, open, err := man.DocumentVersion("/a/b.go"); v != 3 || !open || err != nil {
+		t.Fatal(v, open, err)
+	}
+
+	// changes not sent yet
+	man.TextDocumentChange("/a/b.go", rw, 0, 0, []byte("0"))
+	if _, _, err := man.DocumentVersion("/a/b.go"); err == nil {
+		t.Fatal("expecting error")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/jmigpin/editor/util/iout"
 	"github.com/jmigpin/editor/util/iout/iorw"
@@ -21,11 +23,29 @@ type Manager struct {
 	langs []*LangManager
 	msgFn func(string)
 
+	// options sent on formatting requests
+	FormattingOptions FormattingOptions
+
+	// called when the diagnostics of a file were updated (not on the UI goroutine)
+	OnDiagnostics func(filename string)
+
+	// called when the server requests to apply a workspace edit (not on the UI goroutine)
+	OnApplyWorkspaceEdit func(*WorkspaceEdit) error
+
+	diags struct {
+		sync.Mutex
+		m map[string][]*Diagnostic // [filename]
+	}
+
+	docs documents
+
 	serverWrapW io.Writer // test purposes only
 }
 
 func NewManager(msgFn func(string)) *Manager {
-	return &Manager{msgFn: msgFn}
+	man := &Manager{msgFn: msgFn}
+	man.FormattingOptions = FormattingOptions{TabSize: 8}
+	return man
 }
 
 //----------
@@ -91,6 +111,8 @@ func (man *Manager) Close() error {
 			}
 		}
 	}
+	man.clearDocuments()
+	man.clearDiagnostics()
 	if count == 0 {
 		return fmt.Errorf("no instances are running")
 	}
@@ -99,6 +121,72 @@ func (man *Manager) Close() error {
 
 //----------
 
+// Diagnostics last published by the servers for the filename.
+func (man *Manager) Diagnostics(filename string) []*Diagnostic {
+	man.diags.Lock()
+	defer man.diags.Unlock()
+	return man.diags.m[filename]
+}
+
+// Sorted filenames that have diagnostics.
+func (man *Manager) DiagnosticsFilenames() []string {
+	man.diags.Lock()
+	defer man.diags.Unlock()
+	u := []string{}
+	for k := range man.diags.m {
+		u = append(u, k)
+	}
+	sort.Strings(u)
+	return u
+}
+
+func (man *Manager) setDiagnostics(filename string, diags []*Diagnostic) {
+	man.diags.Lock()
+	if man.diags.m == nil {
+		man.diags.m = map[string][]*Diagnostic{}
+	}
+	_, had := man.diags.m[filename]
+	if len(diags) == 0 {
+		delete(man.diags.m, filename)
+	} else {
+		sortDiagnostics(diags)
+		man.diags.m[filename] = diags
+	}
+	man.diags.Unlock()
+
+	if had || len(diags) > 0 {
+		man.diagnosticsUpdated(filename)
+	}
+}
+
+func (man *Manager) clearDiagnostics() {
+	man.diags.Lock()
+	m := man.diags.m
+	man.diags.m = nil
+	man.diags.Unlock()
+
+	for filename := range m {
+		man.diagnosticsUpdated(filename)
+	}
+}
+
+func (man *Manager) diagnosticsUpdated(filename string) {
+	if man.OnDiagnostics != nil {
+		man.OnDiagnostics(filename)
+	}
+}
+
+//----------
+
+func (man *Manager) applyWorkspaceEdit(we *WorkspaceEdit) error {
+	if man.OnApplyWorkspaceEdit == nil {
+		return fmt.Errorf("not supported")
+	}
+	return man.OnApplyWorkspaceEdit(we)
+}
+
+//----------
+
 func (man *Manager) TextDocumentDefinition(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) (string, *Range, error) {
 	cli, _, err := man.langInstanceClient(ctx, filename)
 	if err != nil {
@@ -110,10 +198,9 @@ func (man *Manager) TextDocumentDefinition(ctx context.Context, filename string,
 		return "", nil, err
 	}
 
-	if err := man.didOpenVersion(ctx, cli, filename, rd); err != nil {
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
 		return "", nil, err
 	}
-	defer man.didClose(ctx, cli, filename)
 
 	pos, err := OffsetToPosition(rd, offset)
 	if err != nil {
@@ -147,10 +234,9 @@ func (man *Manager) TextDocumentCompletion(ctx context.Context, filename string,
 		return nil, err
 	}
 
-	if err := man.didOpenVersion(ctx, cli, filename, rd); err != nil {
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
 		return nil, err
 	}
-	defer man.didClose(ctx, cli, filename)
 
 	pos, err := OffsetToPosition(rd, offset)
 	if err != nil {
@@ -192,50 +278,220 @@ func (man *Manager) TextDocumentCompletionDetailStrings(ctx context.Context, fil
 
 //----------
 
-func (man *Manager) didOpenVersion(ctx context.Context, cli *Client, filename string, rd iorw.ReaderAt) error {
-	b, err := iorw.ReadFastFull(rd)
+func (man *Manager) TextDocumentHover(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) (string, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
 	if err != nil {
-		return err
+		return "", err
 	}
-	return cli.TextDocumentDidOpenVersion(ctx, filename, b)
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return "", err
+	}
+
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
+		return "", err
+	}
+
+	pos, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return "", err
+	}
+
+	h, err := cli.TextDocumentHover(ctx, filename, pos)
+	if err != nil {
+		return "", err
+	}
+	return HoverContentsString(h.Contents)
 }
 
-func (man *Manager) didClose(ctx context.Context, cli *Client, filename string) error {
-	return cli.TextDocumentDidClose(ctx, filename)
+//----------
+
+// Returns the active signature label with the active parameter marked. Returns an empty string if there is no signature at the offset.
+func (man *Manager) TextDocumentSignatureHelp(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) (string, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return "", err
+	}
+
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
+		return "", err
+	}
+
+	pos, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return "", err
+	}
+
+	sh, err := cli.TextDocumentSignatureHelp(ctx, filename, pos)
+	if err != nil {
+		return "", err
+	}
+	return SignatureHelpString(sh), nil
 }
 
 //----------
 
-//func (man *Manager) DidSave(ctx context.Context, filename string, text []byte) error {
-//	// no error if there is no lang registered
-//	_, err := man.lang(filename)
-//	if err != nil {
-//		return nil
-//	}
-//	return man.TextDocumentDidSave(ctx, filename, text)
-//}
-
-//func (man *Manager) TextDocumentDidSave(ctx context.Context, filename string, text []byte) error {
-//	cli, _, err := man.langClient(ctx, filename)
-//	if err != nil {
-//		return err
-//	}
-//	return cli.TextDocumentDidSave(ctx, filename, text)
-//}
+func (man *Manager) TextDocumentReferences(ctx context.Context, filename string, rd iorw.ReaderAt, offset int) ([]*Location, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return nil, err
+	}
+
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
+		return nil, err
+	}
+
+	pos, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return cli.TextDocumentReferences(ctx, filename, pos)
+}
+
+func (man *Manager) TextDocumentDocumentSymbol(ctx context.Context, filename string, rd iorw.ReaderAt) ([]*SymbolInformation, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return nil, err
+	}
+
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
+		return nil, err
+	}
+
+	return cli.TextDocumentDocumentSymbol(ctx, filename)
+}
+
+// The filename is used to get the lang instance and workspace folder.
+func (man *Manager) WorkspaceSymbol(ctx context.Context, filename string, query string) ([]*SymbolInformation, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return nil, err
+	}
+
+	return cli.WorkspaceSymbol(ctx, query)
+}
 
 //----------
 
-//func (man *Manager) syncText(ctx context.Context, filename string, rd iorw.Reader) error {
-//	cli, _, err := man.autoStart(ctx, filename)
-//	if err != nil {
-//		return err
-//	}
-//	b, err := iorw.ReadFullSlice(rd)
-//	if err != nil {
-//		return err
-//	}
-//	return cli.SyncText(ctx, filename, b)
-//}
+// Notifies the server that the file was saved. Allows the server to publish updated diagnostics.
+func (man *Manager) DidSave(ctx context.Context, filename string, rd iorw.ReaderAt) error {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return err
+	}
+
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
+		return err
+	}
+
+	return cli.TextDocumentDidSave(ctx, filename, nil)
+}
+
+//----------
+
+func (man *Manager) TextDocumentFormatting(ctx context.Context, filename string, rd iorw.ReaderAt) ([]*TextEdit, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return nil, err
+	}
+
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
+		return nil, err
+	}
+
+	return cli.TextDocumentFormatting(ctx, filename, man.FormattingOptions)
+}
+
+func (man *Manager) TextDocumentRangeFormatting(ctx context.Context, filename string, rd iorw.ReaderAt, offset, length int) ([]*TextEdit, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return nil, err
+	}
+
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
+		return nil, err
+	}
+
+	rang, err := OffsetLenToRange(rd, offset, length)
+	if err != nil {
+		return nil, err
+	}
+
+	return cli.TextDocumentRangeFormatting(ctx, filename, rang, man.FormattingOptions)
+}
+
+//----------
+
+// Code actions available at the range. The known diagnostics that intersect the range are sent as context.
+func (man *Manager) TextDocumentCodeAction(ctx context.Context, filename string, rd iorw.ReaderAt, offset, length int) ([]*CodeAction, error) {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	if err := cli.UpdateWorkspaceFolder(ctx, dir); err != nil {
+		return nil, err
+	}
+
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
+		return nil, err
+	}
+
+	rang, err := OffsetLenToRange(rd, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	diags := diagnosticsInRange(man.Diagnostics(filename), rang)
+
+	return cli.TextDocumentCodeAction(ctx, filename, rang, diags)
+}
+
+// Runs a command (ex: from a code action). The server might send the resulting edits with a "workspace/applyEdit" request.
+func (man *Manager) WorkspaceExecuteCommand(ctx context.Context, filename string, cmd *Command) error {
+	cli, _, err := man.langInstanceClient(ctx, filename)
+	if err != nil {
+		return err
+	}
+	return cli.WorkspaceExecuteCommand(ctx, cmd)
+}
 
 //----------
 
@@ -250,10 +506,9 @@ func (man *Manager) TextDocumentRename(ctx context.Context, filename string, rd
 		return nil, err
 	}
 
-	if err := man.didOpenVersion(ctx, cli, filename, rd); err != nil {
+	if err := man.syncDocument(ctx, cli, filename, rd); err != nil {
 		return nil, err
 	}
-	defer man.didClose(ctx, cli, filename)
 
 	pos, err := OffsetToPosition(rd, offset)
 	if err != nil {
@@ -173,6 +173,39 @@ func TestManagerC1(t *testing.T) {
 
 //----------
 
+func TestManagerDiagnostics(t *testing.T) {
+	man := NewManager(nil)
+	updated := []string{}
+	man.OnDiagnostics = func(filename string) {
+		updated = append(updated, filename)
+	}
+
+	diags := []*Diagnostic{
+		{Range: Range{Start: Position{Line: 3}}, Message: "b"},
+		{Range: Range{Start: Position{Line: 1, Character: 2}}, Message: "a"},
+	}
+	man.setDiagnostics("/a/b.go", diags)
+	man.setDiagnostics("/a/c.go", nil) // no previous diagnostics, no update
+
+	u := man.Diagnostics("/a/b.go")
+	if len(u) != 2 || u[0].Message != "a" || u[1].Message != "b" {
+		t.Fatalf("%v", u)
+	}
+	if fs := man.DiagnosticsFilenames(); len(fs) != 1 || fs[0] != "/a/b.go" {
+		t.Fatal(fs)
+	}
+
+	man.setDiagnostics("/a/b.go", []*Diagnostic{})
+	if u := man.Diagnostics("/a/b.go"); len(u) != 0 {
+		t.Fatal(u)
+	}
+	if len(updated) != 2 {
+		t.Fatal(updated)
+	}
+}
+
+//----------
+
 func newTestManager(t *testing.T) *Manager {
 	t.Helper()
 
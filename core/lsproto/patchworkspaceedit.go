@@ -1,7 +1,6 @@
 package lsproto
 
 import (
-	"bytes"
 	"io/ioutil"
 	"sort"
 
@@ -12,6 +11,7 @@ import (
 type WorkspaceEditChange struct {
 	Filename string
 	Edits    []*TextEdit
+	Version  *int // document version the edits refer to (if provided)
 }
 
 func WorkspaceEditChanges(we *WorkspaceEdit) ([]*WorkspaceEditChange, error) {
@@ -21,19 +21,22 @@ func WorkspaceEditChanges(we *WorkspaceEdit) ([]*WorkspaceEditChange, error) {
 		if err != nil {
 			return nil, err
 		}
-		m[filename] = &WorkspaceEditChange{filename, edits}
+		m[filename] = &WorkspaceEditChange{Filename: filename, Edits: edits}
 	}
 	for _, tde := range we.DocumentChanges {
 		filename, err := parseutil.UrlToAbsFilename(string(tde.TextDocument.Uri))
 		if err != nil {
 			return nil, err
 		}
-		m[filename] = &WorkspaceEditChange{filename, tde.Edits}
+		m[filename] = &WorkspaceEditChange{Filename: filename, Edits: tde.Edits, Version: tde.TextDocument.Version}
 	}
 	u := []*WorkspaceEditChange{}
 	for _, v := range m {
 		u = append(u, v)
 	}
+	sort.Slice(u, func(i, j int) bool {
+		return u[i].Filename < u[j].Filename
+	})
 	return u, nil
 }
 
@@ -64,27 +67,43 @@ func PatchFileTextEdits(filename string, edits []*TextEdit) error {
 }
 
 func PatchTextEdits(src []byte, edits []*TextEdit) ([]byte, error) {
+	src2 := append([]byte{}, src...) // don't modify src
+	rw := iorw.NewBytesReadWriterAt(src2)
+	if err := PatchTextEditsRW(rw, edits); err != nil {
+		return nil, err
+	}
+	return iorw.ReadFastFull(rw)
+}
+
+// Edits are applied from the end to keep the offsets valid. All offsets are computed before patching, so it fails without changes if a range is invalid.
+func PatchTextEditsRW(rw iorw.ReadWriterAt, edits []*TextEdit) error {
 	sortTextEdits(edits)
-	res := bytes.Buffer{} // resulting patched src
-	rd := iorw.NewBytesReadWriterAt(src)
-	start := 0
+	type patch struct {
+		offset, n int
+		text      []byte
+	}
+	patches := []*patch{}
 	for _, e := range edits {
-		offset, n, err := RangeToOffsetLen(rd, &e.Range)
+		offset, n, err := RangeToOffsetLen(rw, &e.Range)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		res.Write(src[start:offset])
-		res.Write([]byte(e.NewText))
-		start = offset + n
+		patches = append(patches, &patch{offset, n, []byte(e.NewText)})
 	}
-	res.Write(src[start:]) // rest of the src
-	return res.Bytes(), nil
+	for i := len(patches) - 1; i >= 0; i-- {
+		p := patches[i]
+		if err := rw.OverwriteAt(p.offset, p.n, p.text); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func sortTextEdits(edits []*TextEdit) {
-	sort.Slice(edits, func(i, j int) bool {
+	// stable: keeps the order of inserts at the same position
+	sort.SliceStable(edits, func(i, j int) bool {
 		p1, p2 := &edits[i].Range.Start, &edits[j].Range.Start
 		return p1.Line < p2.Line ||
-			(p1.Line == p2.Line && p1.Character <= p2.Character)
+			(p1.Line == p2.Line && p1.Character < p2.Character)
 	})
 }
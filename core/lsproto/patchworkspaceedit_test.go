@@ -0,0 +1,36 @@
+package lsproto
+
+import (
+	"testing"
+)
+
+func TestPatchTextEdits(t *testing.T) {
+	src := []byte("ab\ncd\nef")
+	edits := []*TextEdit{
+		{Range: Range{Start: Position{2, 0}, End: Position{2, 1}}, NewText: "E"},
+		{Range: Range{Start: Position{0, 1}, End: Position{1, 1}}, NewText: "1"},
+		{Range: Range{Start: Position{0, 0}, End: Position{0, 0}}, NewText: "x"},
+		{Range: Range{Start: Position{0, 0}, End: Position{0, 0}}, NewText: "y"},
+	}
+	res, err := PatchTextEdits(src, edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res) != "xya1d\nEf" {
+		t.Fatalf("%q", res)
+	}
+	if string(src) != "ab\ncd\nef" {
+		t.Fatalf("src was modified: %q", src)
+	}
+}
+
+func TestPatchTextEditsBadRange(t *testing.T) {
+	src := []byte("ab")
+	edits := []*TextEdit{
+		{Range: Range{Start: Position{0, 0}, End: Position{0, 1}}, NewText: "x"},
+		{Range: Range{Start: Position{5, 0}, End: Position{5, 1}}, NewText: "y"},
+	}
+	if _, err := PatchTextEdits(src, edits); err == nil {
+		t.Fatal("expecting error")
+	}
+}
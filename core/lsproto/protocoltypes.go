@@ -25,14 +25,28 @@ type ResponseMessage struct {
 	Result json.RawMessage `json:"result,omitempty"`
 }
 type NotificationMessage struct {
-	Method string      `json:"method,omitempty"`
-	Params interface{} `json:"params,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
 }
 
 func (nm *NotificationMessage) isServerPush() bool {
 	return nm.Method != ""
 }
 
+// Message initiated by the server (request if it has an id, notification otherwise).
+type serverPushMessage struct {
+	Id json.RawMessage `json:"id,omitempty"` // int | string
+	NotificationMessage
+}
+
+// Reply to a request initiated by the server.
+type ServerRequestReplyMessage struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"` // "null" is a valid result
+	Error   *ResponseError  `json:"error,omitempty"`
+}
+
 //----------
 
 type ResponseError struct {
@@ -99,6 +113,29 @@ type CompletionList struct {
 	IsIncomplete bool              `json:"isIncomplete"`
 	Items        []*CompletionItem `json:"items"`
 }
+type Hover struct {
+	Contents json.RawMessage `json:"contents"` // MarkedString | MarkedString[] | MarkupContent
+	Range    *Range          `json:"range,omitempty"`
+}
+type MarkupContent struct {
+	Kind  string `json:"kind,omitempty"` // "plaintext" | "markdown"
+	Value string `json:"value"`
+}
+type SignatureHelp struct {
+	Signatures      []*SignatureInformation `json:"signatures"`
+	ActiveSignature int                     `json:"activeSignature,omitempty"`
+	ActiveParameter int                     `json:"activeParameter,omitempty"`
+}
+type SignatureInformation struct {
+	Label           string                  `json:"label"`
+	Documentation   json.RawMessage         `json:"documentation,omitempty"` // string | MarkupContent
+	Parameters      []*ParameterInformation `json:"parameters,omitempty"`
+	ActiveParameter *int                    `json:"activeParameter,omitempty"`
+}
+type ParameterInformation struct {
+	Label         json.RawMessage `json:"label"`                   // string | [uinteger, uinteger] (utf16 offsets in the signature label)
+	Documentation json.RawMessage `json:"documentation,omitempty"` // string | MarkupContent
+}
 type DidOpenTextDocumentParams struct {
 	TextDocument TextDocumentItem `json:"textDocument"`
 }
@@ -124,11 +161,18 @@ type VersionedTextDocumentIdentifier struct {
 	Version *int `json:"version"`
 }
 type TextDocumentContentChangeEvent struct {
-	Range       Range  `json:"range,omitempty"`
-	RangeLength int    `json:"rangeLength,omitempty"`
-	Text        string `json:"text,omitempty"`
+	Range *Range `json:"range,omitempty"` // nil: text is the full content
+	Text  string `json:"text"`
 }
 
+type TextDocumentSyncKind int
+
+const (
+	TextDocumentSyncKindNone        TextDocumentSyncKind = 0
+	TextDocumentSyncKindFull        TextDocumentSyncKind = 1
+	TextDocumentSyncKindIncremental TextDocumentSyncKind = 2
+)
+
 type DidChangeWorkspaceFoldersParams struct {
 	Event *WorkspaceFoldersChangeEvent `json:"event,omitempty"`
 }
@@ -156,6 +200,196 @@ type TextEdit struct {
 	NewText string `json:"newText"`
 }
 
+type DocumentFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Options      FormattingOptions      `json:"options"`
+}
+type DocumentRangeFormattingParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Options      FormattingOptions      `json:"options"`
+}
+type FormattingOptions struct {
+	TabSize      int  `json:"tabSize"`
+	InsertSpaces bool `json:"insertSpaces"`
+}
+
+type CodeActionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Range        Range                  `json:"range"`
+	Context      CodeActionContext      `json:"context"`
+}
+type CodeActionContext struct {
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+type CodeAction struct {
+	Title       string         `json:"title"`
+	Kind        string         `json:"kind,omitempty"`
+	Diagnostics []*Diagnostic  `json:"diagnostics,omitempty"`
+	IsPreferred bool           `json:"isPreferred,omitempty"`
+	Edit        *WorkspaceEdit `json:"edit,omitempty"`
+	Command     *Command       `json:"command,omitempty"`
+}
+type Command struct {
+	Title     string            `json:"title"`
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+type ExecuteCommandParams struct {
+	Command   string            `json:"command"`
+	Arguments []json.RawMessage `json:"arguments,omitempty"`
+}
+
+type PublishDiagnosticsParams struct {
+	Uri         DocumentUri   `json:"uri"`
+	Version     *int          `json:"version,omitempty"`
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+type Diagnostic struct {
+	Range    Range       `json:"range"`
+	Severity int         `json:"severity,omitempty"` // 1=error, 2=warning, 3=information, 4=hint
+	Code     interface{} `json:"code,omitempty"`     // int or string
+	Source   string      `json:"source,omitempty"`
+	Message  string      `json:"message"`
+}
+
+func (d *Diagnostic) SeverityString() string {
+	switch d.Severity {
+	case 1:
+		return "error"
+	case 2:
+		return "warning"
+	case 3:
+		return "info"
+	case 4:
+		return "hint"
+	default:
+		return "diagnostic"
+	}
+}
+
+type ShowMessageParams struct {
+	Type    MessageType `json:"type"`
+	Message string      `json:"message"`
+}
+
+type MessageType int
+
+func (mt MessageType) String() string {
+	switch mt {
+	case 1:
+		return "error"
+	case 2:
+		return "warning"
+	case 3:
+		return "info"
+	case 4:
+		return "log"
+	default:
+		return fmt.Sprintf("msg(%d)", int(mt))
+	}
+}
+
+type ProgressParams struct {
+	Token json.RawMessage `json:"token"` // int | string
+	Value json.RawMessage `json:"value"`
+}
+
+// Value of the progress notification on work done progress.
+type WorkDoneProgress struct {
+	Kind       string `json:"kind"` // "begin" | "report" | "end"
+	Title      string `json:"title,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Percentage *int   `json:"percentage,omitempty"`
+}
+
+func (p *WorkDoneProgress) String() string {
+	u := []string{}
+	if p.Title != "" {
+		u = append(u, p.Title)
+	}
+	if p.Message != "" {
+		u = append(u, p.Message)
+	}
+	if p.Percentage != nil {
+		u = append(u, fmt.Sprintf("%d%%", *p.Percentage))
+	}
+	if p.Kind == "end" {
+		u = append(u, "done")
+	}
+	return strings.Join(u, ": ")
+}
+
+type ConfigurationParams struct {
+	Items []*ConfigurationItem `json:"items"`
+}
+type ConfigurationItem struct {
+	ScopeUri DocumentUri `json:"scopeUri,omitempty"`
+	Section  string      `json:"section,omitempty"`
+}
+
+type ApplyWorkspaceEditParams struct {
+	Label string        `json:"label,omitempty"`
+	Edit  WorkspaceEdit `json:"edit"`
+}
+type ApplyWorkspaceEditResult struct {
+	Applied       bool   `json:"applied"`
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+type ReferenceParams struct {
+	TextDocumentPositionParams
+	Context ReferenceContext `json:"context"`
+}
+type ReferenceContext struct {
+	IncludeDeclaration bool `json:"includeDeclaration"`
+}
+
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+type WorkspaceSymbolParams struct {
+	Query string `json:"query"`
+}
+
+// Hierarchical symbols (textDocument/documentSymbol).
+type DocumentSymbol struct {
+	Name           string            `json:"name"`
+	Detail         string            `json:"detail,omitempty"`
+	Kind           SymbolKind        `json:"kind"`
+	Deprecated     bool              `json:"deprecated,omitempty"`
+	Range          Range             `json:"range"`
+	SelectionRange Range             `json:"selectionRange"`
+	Children       []*DocumentSymbol `json:"children,omitempty"`
+}
+
+// Flat symbols (textDocument/documentSymbol, workspace/symbol).
+type SymbolInformation struct {
+	Name          string     `json:"name"`
+	Kind          SymbolKind `json:"kind"`
+	Deprecated    bool       `json:"deprecated,omitempty"`
+	Location      Location   `json:"location"`
+	ContainerName string     `json:"containerName,omitempty"`
+}
+
+type SymbolKind int
+
+func (sk SymbolKind) String() string {
+	names := []string{
+		"file", "module", "namespace", "package", "class",
+		"method", "property", "field", "constructor", "enum",
+		"interface", "function", "variable", "constant", "string",
+		"number", "boolean", "array", "object", "key",
+		"null", "enummember", "struct", "event", "operator",
+		"typeparameter",
+	}
+	i := int(sk) - 1 // one based
+	if i >= 0 && i < len(names) {
+		return names[i]
+	}
+	return fmt.Sprintf("kind(%d)", int(sk))
+}
+
 type Position struct {
 	Line      int `json:"line"`      // zero based
 	Character int `json:"character"` // zero based
@@ -1,6 +1,7 @@
 package lsproto
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -38,6 +39,21 @@ func (reg *Registration) HasOptional(s string) bool {
 	return false
 }
 
+// Settings from the "settings=<json>" optional field, used to reply to the server "workspace/configuration" requests. Returns nil if not defined.
+func (reg *Registration) Settings() (interface{}, error) {
+	prefix := "settings="
+	for _, v := range reg.Optional {
+		if strings.HasPrefix(v, prefix) {
+			var u interface{}
+			if err := json.Unmarshal([]byte(v[len(prefix):]), &u); err != nil {
+				return nil, fmt.Errorf("settings: %w", err)
+			}
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
 //----------
 
 func parseRegistration(s string) (*Registration, error) {
@@ -94,6 +110,11 @@ func parseRegistration(s string) (*Registration, error) {
 	reg.Cmd = fields[3]
 	reg.Optional = fields[4:]
 
+	// early check on settings to report errors
+	if _, err := reg.Settings(); err != nil {
+		return nil, err
+	}
+
 	return reg, nil
 }
 
@@ -117,7 +138,13 @@ func RegistrationString(reg *Registration) string {
 		reg.Network,
 		cmd,
 	}
-	u = append(u, reg.Optional...)
+	for _, s := range reg.Optional {
+		// settings field can have commas
+		if strings.ContainsAny(s, ",\"'") {
+			s = strconv.Quote(s)
+		}
+		u = append(u, s)
+	}
 	return strings.Join(u, ",")
 }
 
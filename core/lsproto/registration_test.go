@@ -39,4 +39,29 @@ func TestParseRegistration3(t *testing.T) {
 	}
 }
 
+func TestParseRegistrationSettings(t *testing.T) {
+	s := `go,.go,stdio,gopls,"settings={\"gopls\":{\"a\":1,\"b\":true}}"`
+	reg, err := NewRegistration(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := reg.Settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, err := JsonGetPath(v, "gopls.b")
+	if err != nil || v2 != true {
+		t.Fatal(v2, err)
+	}
+	s2 := RegistrationString(reg)
+	if s2 != s {
+		t.Fatal(s2)
+	}
+
+	s3 := `go,.go,stdio,gopls,"settings={"`
+	if _, err := NewRegistration(s3); err == nil {
+		t.Fatal("expecting error")
+	}
+}
+
 //----------
@@ -8,6 +8,8 @@ import (
 	"io"
 	"log"
 	"os"
+	"sort"
+	"strings"
 	"unicode/utf16"
 
 	"github.com/jmigpin/editor/util/iout/iorw"
@@ -149,4 +151,137 @@ func RangeToOffsetLen(rd iorw.ReaderAt, rang *Range) (int, int, error) {
 	return offset, length, nil
 }
 
+func OffsetLenToRange(rd iorw.ReaderAt, offset, length int) (Range, error) {
+	start, err := OffsetToPosition(rd, offset)
+	if err != nil {
+		return Range{}, err
+	}
+	b, err := rd.ReadFastAt(offset, length)
+	if err != nil {
+		return Range{}, err
+	}
+	end := positionAfterText(start, b)
+	return Range{Start: start, End: end}, nil
+}
+
+//----------
+
+// Decodes the hover contents (MarkedString | MarkedString[] | MarkupContent) into a string.
+func HoverContentsString(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+	switch raw[0] {
+	case '"': // string
+		s := ""
+		err := decodeJsonRaw(raw, &s)
+		return s, err
+	case '[': // array of marked strings
+		u := []json.RawMessage{}
+		if err := decodeJsonRaw(raw, &u); err != nil {
+			return "", err
+		}
+		w := []string{}
+		for _, r := range u {
+			s, err := HoverContentsString(r)
+			if err != nil {
+				return "", err
+			}
+			if s != "" {
+				w = append(w, s)
+			}
+		}
+		return strings.Join(w, "\n\n"), nil
+	default: // markup content, or marked string with language (same value field)
+		mc := MarkupContent{}
+		err := decodeJsonRaw(raw, &mc)
+		return mc.Value, err
+	}
+}
+
 //----------
+
+// Active signature label with the active parameter enclosed in "«»".
+func SignatureHelpString(sh *SignatureHelp) string {
+	if sh == nil || len(sh.Signatures) == 0 {
+		return ""
+	}
+	k := sh.ActiveSignature
+	if k < 0 || k >= len(sh.Signatures) {
+		k = 0
+	}
+	si := sh.Signatures[k]
+	label := si.Label
+
+	ap := sh.ActiveParameter
+	if si.ActiveParameter != nil {
+		ap = *si.ActiveParameter
+	}
+	if ap < 0 || ap >= len(si.Parameters) {
+		return label
+	}
+	a, b, ok := parameterLabelIndexes(label, si.Parameters[ap].Label)
+	if !ok {
+		return label
+	}
+	return label[:a] + "«" + label[a:b] + "»" + label[b:]
+}
+
+// Parameter label is a substring of the signature label, or utf16 offsets in the signature label.
+func parameterLabelIndexes(label string, plabel json.RawMessage) (int, int, bool) {
+	s := ""
+	if err := decodeJsonRaw(plabel, &s); err == nil {
+		i := strings.Index(label, s)
+		if i < 0 || s == "" {
+			return 0, 0, false
+		}
+		return i, i + len(s), true
+	}
+	u := []int{}
+	if err := decodeJsonRaw(plabel, &u); err != nil || len(u) != 2 {
+		return 0, 0, false
+	}
+	a, ok1 := utf16OffsetToUtf8(label, u[0])
+	b, ok2 := utf16OffsetToUtf8(label, u[1])
+	if !ok1 || !ok2 || a > b {
+		return 0, 0, false
+	}
+	return a, b, true
+}
+
+func utf16OffsetToUtf8(s string, u16 int) (int, bool) {
+	n := 0
+	for i, ru := range s {
+		if n >= u16 {
+			return i, n == u16
+		}
+		if ru >= 0x10000 { // surrogate pair
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return len(s), n == u16
+}
+
+//----------
+
+// Diagnostics that intersect the range lines.
+func diagnosticsInRange(diags []*Diagnostic, rang Range) []*Diagnostic {
+	res := []*Diagnostic{}
+	for _, d := range diags {
+		if d.Range.End.Line >= rang.Start.Line && d.Range.Start.Line <= rang.End.Line {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+func sortDiagnostics(diags []*Diagnostic) {
+	sort.SliceStable(diags, func(i, j int) bool {
+		p1, p2 := &diags[i].Range.Start, &diags[j].Range.Start
+		return p1.Line < p2.Line ||
+			(p1.Line == p2.Line && p1.Character < p2.Character)
+	})
+}
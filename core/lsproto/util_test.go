@@ -0,0 +1,54 @@
+package lsproto
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHoverContentsString(t *testing.T) {
+	type in struct {
+		raw string
+		res string
+	}
+	u := []in{
+		{`null`, ""},
+		{`"func f()"`, "func f()"},
+		{`{"kind":"markdown","value":"func f()\n\ndoc"}`, "func f()\n\ndoc"},
+		{`{"language":"go","value":"var a int"}`, "var a int"},
+		{`["a",{"language":"go","value":"b"},""]`, "a\n\nb"},
+	}
+	for _, w := range u {
+		s, err := HoverContentsString(json.RawMessage(w.raw))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s != w.res {
+			t.Fatalf("%q: expecting %q, got %q", w.raw, w.res, s)
+		}
+	}
+}
+
+func TestSignatureHelpString(t *testing.T) {
+	ap := 1
+	sh := &SignatureHelp{
+		Signatures: []*SignatureInformation{
+			{
+				Label: "f(a int, b €) error",
+				Parameters: []*ParameterInformation{
+					{Label: json.RawMessage(`"a int"`)},
+					{Label: json.RawMessage(`[9,12]`)},
+				},
+			},
+		},
+	}
+	if s := SignatureHelpString(sh); s != "f(«a int», b €) error" {
+		t.Fatal(s)
+	}
+	sh.Signatures[0].ActiveParameter = &ap
+	if s := SignatureHelpString(sh); s != "f(a int, «b €») error" {
+		t.Fatal(s)
+	}
+	if s := SignatureHelpString(nil); s != "" {
+		t.Fatal(s)
+	}
+}
@@ -0,0 +1,126 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Not called on the UI goroutine (lsproto client read loop).
+func (ed *Editor) onLSProtoDiagnostics(filename string) {
+	ed.UI.RunOnUIGoRoutine(func() {
+		info, ok := ed.ERowInfo(filename)
+		if ok {
+			ed.UpdateLSProtoDiagnosticsAnnotations(info)
+		}
+	})
+}
+
+// Should be called under UI goroutine.
+func (ed *Editor) UpdateLSProtoDiagnosticsAnnotations(info *ERowInfo) {
+	if !info.IsFileButNotDir() {
+		return
+	}
+	diags := ed.LSProtoMan.Diagnostics(info.Name())
+	erow0, ok := info.FirstERow()
+	if !ok {
+		return
+	}
+	// erows share the same content
+	entries := lsprotoDiagnosticsAnnotations(erow0.Row.TextArea.RW(), diags)
+	for _, erow := range info.ERows {
+		on := len(entries) > 0
+		ed.SetAnnotations(EdAnnReqLSProtoDiagnostics, erow.Row.TextArea, on, -1, entries)
+	}
+}
+
+func lsprotoDiagnosticsAnnotations(rd iorw.ReaderAt, diags []*lsproto.Diagnostic) []*drawer4.Annotation {
+	entries := []*drawer4.Annotation{}
+	for _, d := range diags {
+		// diagnostics could refer to an older version of the content
+		offset, _, err := lsproto.RangeToOffsetLen(rd, &d.Range)
+		if err != nil {
+			continue
+		}
+		s := fmt.Sprintf("%v: %v", d.SeverityString(), d.Message)
+		entries = append(entries, &drawer4.Annotation{Offset: offset, Bytes: []byte(s)})
+	}
+	return entries
+}
+
+//----------
+
+func (ed *Editor) lsprotoDidSave(info *ERowInfo) {
+	// no error if there is no lang registered
+	if _, err := ed.LSProtoMan.LangManager(info.Name()); err != nil {
+		return
+	}
+	erow0, ok := info.FirstERow()
+	if !ok {
+		return
+	}
+	b, err := erow0.Row.TextArea.Bytes()
+	if err != nil {
+		return
+	}
+	go func() {
+		ctx0 := context.Background()
+		ctx, cancel := context.WithTimeout(ctx0, 5*time.Second)
+		defer cancel()
+		rd := iorw.NewBytesReadWriterAt(b)
+		if err := ed.LSProtoMan.DidSave(ctx, info.Name(), rd); err != nil {
+			ed.Error(err)
+		}
+	}()
+}
+
+func (ed *Editor) lsprotoDidClose(info *ERowInfo) {
+	if !info.IsFileButNotDir() {
+		return
+	}
+	go func() {
+		ctx0 := context.Background()
+		ctx, cancel := context.WithTimeout(ctx0, 5*time.Second)
+		defer cancel()
+		if err := ed.LSProtoMan.DidClose(ctx, info.Name()); err != nil {
+			ed.Error(err)
+		}
+	}()
+}
+
+//----------
+
+// Lists the diagnostics of all files in the "file:line:col" format.
+func ListLSProtoDiagnostics(ed *Editor) {
+	buf := &bytes.Buffer{}
+	filenames := ed.LSProtoMan.DiagnosticsFilenames()
+	n := 0
+	for _, filename := range filenames {
+		n += len(ed.LSProtoMan.Diagnostics(filename))
+	}
+	fmt.Fprintf(buf, "diagnostics: %d\n", n)
+	for _, filename := range filenames {
+		rd, err := erowOrFileReaderAt(ed, filename)
+		if err != nil {
+			ed.Error(err)
+			continue
+		}
+		for _, d := range ed.LSProtoMan.Diagnostics(filename) {
+			pos := LSProtoRangeFilePosString(rd, filename, &d.Range)
+			src := ""
+			if d.Source != "" {
+				src = fmt.Sprintf(" (%v)", d.Source)
+			}
+			fmt.Fprintf(buf, "%v: %v: %v%v\n", pos, d.SeverityString(), d.Message, src)
+		}
+	}
+
+	erow, _ := ExistingERowOrNewBasic(ed, "+Diagnostics")
+	erow.Row.TextArea.SetBytesClearPos(buf.Bytes())
+	erow.Flash()
+}
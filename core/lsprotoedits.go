@@ -0,0 +1,221 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/ui"
+)
+
+// Patches the textarea content (undo group). Should be called under UI goroutine.
+func LSProtoPatchTextArea(ta *ui.TextArea, edits []*lsproto.TextEdit) error {
+	if len(edits) == 0 {
+		return nil
+	}
+	ta.BeginUndoGroup()
+	defer ta.EndUndoGroup()
+	return lsproto.PatchTextEditsRW(ta.RW(), edits)
+}
+
+// Patches the erow content if it still matches src (the content used for the request that resulted in the edits). Should be called under UI goroutine.
+func LSProtoPatchERow(erow *ERow, src []byte, edits []*lsproto.TextEdit) error {
+	b, err := erow.Row.TextArea.Bytes()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(b, src) {
+		return fmt.Errorf("content changed while waiting for the server: %v", erow.Info.Name())
+	}
+	return LSProtoPatchTextArea(erow.Row.TextArea, edits)
+}
+
+// Open files have their rows content patched (not saved), other files are patched on disk. Should be called under UI goroutine.
+func LSProtoApplyWorkspaceEdit(ed *Editor, we *lsproto.WorkspaceEdit) error {
+	wecs, err := lsproto.WorkspaceEditChanges(we)
+	if err != nil {
+		return err
+	}
+	return LSProtoApplyWorkspaceEditChanges(ed, wecs)
+}
+
+// Applies a workspace edit requested by the server. Waits for the UI goroutine.
+func (ed *Editor) onLSProtoApplyWorkspaceEdit(we *lsproto.WorkspaceEdit) error {
+	errc := make(chan error, 1)
+	ed.UI.RunOnUIGoRoutine(func() {
+		errc <- LSProtoApplyWorkspaceEdit(ed, we)
+	})
+	return <-errc
+}
+
+// All changes are checked before patching. Each open file is patched as one undo group. Should be called under UI goroutine.
+func LSProtoApplyWorkspaceEditChanges(ed *Editor, wecs []*lsproto.WorkspaceEditChange) error {
+	for _, wec := range wecs {
+		if err := lsprotoCheckWorkspaceEditChange(ed, wec); err != nil {
+			return err
+		}
+	}
+	for _, wec := range wecs {
+		if info, ok := ed.ERowInfo(wec.Filename); ok {
+			if erow0, ok := info.FirstERow(); ok {
+				if err := LSProtoPatchTextArea(erow0.Row.TextArea, wec.Edits); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if err := lsproto.PatchFileTextEdits(wec.Filename, wec.Edits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Checks that the edits refer to the current content of the open file.
+func lsprotoCheckWorkspaceEditChange(ed *Editor, wec *lsproto.WorkspaceEditChange) error {
+	info, ok := ed.ERowInfo(wec.Filename)
+	if !ok {
+		return nil // not open, patched on disk
+	}
+	v, open, err := ed.LSProtoMan.DocumentVersion(wec.Filename)
+	if err != nil {
+		return err
+	}
+	if !open {
+		// the server used the file on disk
+		if info.HasRowState(ui.RowStateEdited | ui.RowStateFsDiffer) {
+			return fmt.Errorf("row has edits, save first: %v", info.Name())
+		}
+		return nil
+	}
+	if wec.Version != nil && *wec.Version != v {
+		return fmt.Errorf("version mismatch (edits=%v, sent=%v): %v", *wec.Version, v, info.Name())
+	}
+	return nil
+}
+
+//----------
+
+// Lists the edits in the "file:line:col: old -> new" format.
+func LSProtoWorkspaceEditChangesBytes(ed *Editor, wecs []*lsproto.WorkspaceEditChange) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	for _, wec := range wecs {
+		rd, err := erowOrFileReaderAt(ed, wec.Filename)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range wec.Edits {
+			pos := LSProtoRangeFilePosString(rd, wec.Filename, &e.Range)
+			old := ""
+			if offset, n, err := lsproto.RangeToOffsetLen(rd, &e.Range); err == nil {
+				if b, err := rd.ReadFastAt(offset, n); err == nil {
+					old = string(b)
+				}
+			}
+			fmt.Fprintf(buf, "%v: %q -> %q\n", pos, old, e.NewText)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+//----------
+
+// Workspace edit changes waiting to be confirmed (ex: rename preview).
+type LSProtoEditPreview struct {
+	ed   *Editor
+	wecs []*lsproto.WorkspaceEditChange
+}
+
+const lsprotoEditPreviewRowName = "+LsprotoRename"
+
+// Should be called under UI goroutine.
+func (p *LSProtoEditPreview) Show(header string, wecs []*lsproto.WorkspaceEditChange) error {
+	b, err := LSProtoWorkspaceEditChangesBytes(p.ed, wecs)
+	if err != nil {
+		return err
+	}
+	n := 0
+	for _, wec := range wecs {
+		n += len(wec.Edits)
+	}
+	s := fmt.Sprintf("%v: %d edits in %d files\n", header, n, len(wecs))
+
+	// keep the sent versions to detect changes until confirmed
+	for _, wec := range wecs {
+		if wec.Version == nil {
+			if v, open, err := p.ed.LSProtoMan.DocumentVersion(wec.Filename); err == nil && open {
+				wec.Version = &v
+			}
+		}
+	}
+	p.wecs = wecs
+
+	erow, isNew := ExistingERowOrNewBasic(p.ed, lsprotoEditPreviewRowName)
+	if isNew {
+		erow.ToolbarSetStrAfterNameClearHistory(" | LsprotoRenameConfirm | LsprotoRenameCancel")
+	}
+	erow.Row.TextArea.SetBytesClearPos(append([]byte(s), b...))
+	erow.Flash()
+	return nil
+}
+
+// Should be called under UI goroutine.
+func (p *LSProtoEditPreview) Confirm() error {
+	if p.wecs == nil {
+		return fmt.Errorf("no edits to confirm")
+	}
+	wecs := p.wecs
+	p.wecs = nil
+	if err := LSProtoApplyWorkspaceEditChanges(p.ed, wecs); err != nil {
+		p.setStatus(fmt.Sprintf("error: %v", err))
+		return err
+	}
+	p.setStatus(fmt.Sprintf("applied edits to %d files", len(wecs)))
+	return nil
+}
+
+// Should be called under UI goroutine.
+func (p *LSProtoEditPreview) Cancel() {
+	p.wecs = nil
+	p.setStatus("canceled")
+}
+
+func (p *LSProtoEditPreview) setStatus(s string) {
+	if info, ok := p.ed.ERowInfo(lsprotoEditPreviewRowName); ok {
+		if erow0, ok := info.FirstERow(); ok {
+			erow0.Row.TextArea.SetStrClearPos(s + "\n")
+		}
+	}
+}
+
+//----------
+
+// Formats the content if the lsproto registration has the "formatOnSave" optional field. Should be called under UI goroutine.
+func (ed *Editor) lsprotoFormatOnSave(info *ERowInfo) {
+	lang, err := ed.LSProtoMan.LangManager(info.Name())
+	if err != nil {
+		return
+	}
+	if !lang.Reg.HasOptional("formatOnSave") {
+		return
+	}
+	erow0, ok := info.FirstERow()
+	if !ok {
+		return
+	}
+	ctx0 := context.Background()
+	ctx, cancel := context.WithTimeout(ctx0, 5*time.Second)
+	defer cancel()
+	ta := erow0.Row.TextArea
+	edits, err := ed.LSProtoMan.TextDocumentFormatting(ctx, info.Name(), ta.RW())
+	if err != nil {
+		// the file is saved anyway
+		ed.Error(err)
+		return
+	}
+	if err := LSProtoPatchTextArea(ta, edits); err != nil {
+		ed.Error(err)
+	}
+}
@@ -0,0 +1,112 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/jmigpin/editor/core/lsproto"
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/parseutil"
+)
+
+// UI safe. Lines in the "file:line:col: text" format, usable by the "OpenFilename" content cmd.
+func LSProtoLocationsBytes(ed *Editor, locs []*lsproto.Location) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	rds := map[string]iorw.ReaderAt{}
+	for _, loc := range locs {
+		if loc.Range == nil {
+			continue
+		}
+		filename, err := parseutil.UrlToAbsFilename(string(loc.Uri))
+		if err != nil {
+			return nil, err
+		}
+		rd, ok := rds[filename]
+		if !ok {
+			rd, err = erowOrFileReaderAtCopy(ed, filename)
+			if err != nil {
+				return nil, err
+			}
+			rds[filename] = rd
+		}
+		pos := LSProtoRangeFilePosString(rd, filename, loc.Range)
+		text := lsprotoRangeLineText(rd, loc.Range)
+		fmt.Fprintf(buf, "%v: %v\n", pos, text)
+	}
+	return buf.Bytes(), nil
+}
+
+// UI safe. Lines in the "file:line:col: kind name" format, usable by the "OpenFilename" content cmd.
+func LSProtoSymbolsBytes(ed *Editor, syms []*lsproto.SymbolInformation) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	rds := map[string]iorw.ReaderAt{}
+	for _, si := range syms {
+		if si.Location.Range == nil {
+			continue
+		}
+		filename, err := parseutil.UrlToAbsFilename(string(si.Location.Uri))
+		if err != nil {
+			return nil, err
+		}
+		rd, ok := rds[filename]
+		if !ok {
+			rd, err = erowOrFileReaderAtCopy(ed, filename)
+			if err != nil {
+				return nil, err
+			}
+			rds[filename] = rd
+		}
+		pos := LSProtoRangeFilePosString(rd, filename, si.Location.Range)
+		name := si.Name
+		if si.ContainerName != "" {
+			name = si.ContainerName + "." + name
+		}
+		fmt.Fprintf(buf, "%v: %v %v\n", pos, si.Kind, name)
+	}
+	return buf.Bytes(), nil
+}
+
+//----------
+
+// UI safe. Shows the content in a special row (created if it doesn't exist).
+func ShowSpecialERowBytes(ed *Editor, name string, b []byte) {
+	ed.UI.RunOnUIGoRoutine(func() {
+		erow, _ := ExistingERowOrNewBasic(ed, name)
+		erow.Row.TextArea.SetBytesClearPos(b)
+		erow.Flash()
+	})
+}
+
+//----------
+
+// Returns a "file:line:col" string (one-based, utf8 columns). Falls back to the range positions if the range doesn't fit the content.
+func LSProtoRangeFilePosString(rd iorw.ReaderAt, filename string, rang *lsproto.Range) string {
+	line, col := rang.Start.Line+1, rang.Start.Character+1
+	if offset, _, err := lsproto.RangeToOffsetLen(rd, rang); err == nil {
+		if l, c, err := parseutil.IndexLineColumn(rd, offset); err == nil {
+			line, col = l, c
+		}
+	}
+	return fmt.Sprintf("%v:%v:%v", filename, line, col)
+}
+
+func lsprotoRangeLineText(rd iorw.ReaderAt, rang *lsproto.Range) string {
+	offset, _, err := lsproto.RangeToOffsetLen(rd, rang)
+	if err != nil {
+		return ""
+	}
+	a, err := iorw.LineStartIndex(rd, offset)
+	if err != nil {
+		return ""
+	}
+	b, _, err := iorw.LineEndIndex(rd, offset)
+	if err != nil {
+		return ""
+	}
+	line, err := rd.ReadFastAt(a, b-a)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(line))
+}
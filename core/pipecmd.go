@@ -0,0 +1,110 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/jmigpin/editor/core/toolbarparser"
+	"github.com/jmigpin/editor/util/iout/iorw/rwedit"
+	"github.com/jmigpin/editor/util/osutil"
+)
+
+// Selection filter modes (acme-style "|", "<", ">").
+type PipeMode int
+
+const (
+	PipeModeReplace PipeMode = iota // replace the selection with the output
+	PipeModeInsert                  // insert the output at the cursor
+	PipeModeSend                    // only send the selection, output goes to the messages
+)
+
+//----------
+
+// Feeds the row selection to the cmd stdin and applies the output according to the mode. Runs outside the UI goroutine.
+func PipeCmd(ctx context.Context, erow *ERow, mode PipeMode, part *toolbarparser.Part) error {
+	if len(part.Args) < 2 {
+		return fmt.Errorf("missing cmd")
+	}
+	// cmd args without the internal cmd name
+	part2 := *part
+	part2.Args = part.Args[1:]
+	cargs := cmdPartArgs(&part2)
+	env := populateEnvVars(erow, cargs)
+
+	ta := erow.Row.TextArea
+	var in pipeCmdInput
+	erow.Ed.UI.WaitRunOnUIGoRoutine(func() {
+		in = readPipeCmdInput(ta.EditCtx())
+	})
+
+	out, err := runPipeCmd(ctx, erow.Info.Dir(), env, cargs, in.sel)
+	if err != nil {
+		return err
+	}
+
+	if mode == PipeModeSend {
+		if len(out) > 0 {
+			erow.Ed.Messagef("%s", out)
+		}
+		return nil
+	}
+
+	erow.Ed.UI.RunOnUIGoRoutine(func() {
+		ta.BeginUndoGroup()
+		defer ta.EndUndoGroup()
+		if err := applyPipeCmdOutput(ta.EditCtx(), mode, &in, out); err != nil {
+			erow.Ed.Error(err)
+		}
+	})
+	return nil
+}
+
+//----------
+
+type pipeCmdInput struct {
+	a, b int    // selection (or cursor if empty)
+	ci   int    // cursor index
+	sel  []byte // selection content
+}
+
+func readPipeCmdInput(ctx *rwedit.Ctx) pipeCmdInput {
+	ci := ctx.C.Index()
+	in := pipeCmdInput{a: ci, b: ci, ci: ci}
+	if a, b, ok := ctx.C.SelectionIndexes(); ok {
+		in.a, in.b = a, b
+	}
+	if s, ok := ctx.Selection(); ok {
+		in.sel = s
+	}
+	return in
+}
+
+func runPipeCmd(ctx context.Context, dir string, env, cargs []string, in []byte) ([]byte, error) {
+	cmd := osutil.NewCmd(ctx, cargs...)
+	cmd.Dir = dir
+	cmd.Env = env
+	return osutil.RunCmdStdoutAndStderrInErr(cmd, bytes.NewReader(in))
+}
+
+// Selects the new text. Fails if the selection content changed while the cmd was running.
+func applyPipeCmdOutput(ctx *rwedit.Ctx, mode PipeMode, in *pipeCmdInput, out []byte) error {
+	cur, err := ctx.RW.ReadFastAt(in.a, in.b-in.a)
+	if err != nil || !bytes.Equal(cur, in.sel) {
+		return fmt.Errorf("selection changed while running cmd")
+	}
+
+	i, n := in.a, in.b-in.a // replace
+	if mode == PipeModeInsert {
+		i, n = in.ci, 0
+	}
+	if err := ctx.RW.OverwriteAt(i, n, out); err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		ctx.C.SetIndexSelectionOff(i)
+	} else {
+		ctx.C.SetSelection(i, i+len(out))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/iout/iorw/rwedit"
+)
+
+func newPipeCmdTestCtx(s string, si, ci int) *rwedit.Ctx {
+	ctx := rwedit.NewCtx()
+	ctx.RW = iorw.NewBytesReadWriterAt([]byte(s))
+	if si != ci {
+		ctx.C.SetSelection(si, ci)
+	} else {
+		ctx.C.SetIndex(ci)
+	}
+	return ctx
+}
+
+func pipeCmdTest(t *testing.T, ctx *rwedit.Ctx, mode PipeMode, cargs ...string) {
+	t.Helper()
+	in := readPipeCmdInput(ctx)
+	out, err := runPipeCmd(context.Background(), "", nil, cargs, in.sel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyPipeCmdOutput(ctx, mode, &in, out); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func pipeCmdTestResult(t *testing.T, ctx *rwedit.Ctx, exp string, esi, eci int) {
+	t.Helper()
+	b, err := iorw.ReadFastFull(ctx.RW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != exp {
+		t.Fatalf("expecting %q, got %q", exp, b)
+	}
+	si, ci, ok := ctx.C.SelectionIndexes()
+	if !ok || si != esi || ci != eci {
+		t.Fatalf("expecting selection [%v,%v], got [%v,%v] (%v)", esi, eci, si, ci, ok)
+	}
+}
+
+//----------
+
+func TestPipeCmdReplace(t *testing.T) {
+	ctx := newPipeCmdTestCtx("a\nc\nb\nz", 0, 6)
+	pipeCmdTest(t, ctx, PipeModeReplace, "sort")
+	pipeCmdTestResult(t, ctx, "a\nb\nc\nz", 0, 6)
+}
+
+func TestPipeCmdInsert(t *testing.T) {
+	ctx := newPipeCmdTestCtx("abc", 1, 3)
+	pipeCmdTest(t, ctx, PipeModeInsert, "tr", "a-z", "A-Z")
+	pipeCmdTestResult(t, ctx, "abcBC", 3, 5)
+}
+
+func TestPipeCmdSelectionChanged(t *testing.T) {
+	ctx := newPipeCmdTestCtx("abc", 0, 2)
+	in := readPipeCmdInput(ctx)
+	if err := ctx.RW.OverwriteAt(0, 1, []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyPipeCmdOutput(ctx, PipeModeReplace, &in, []byte("y")); err == nil {
+		t.Fatal("expecting error")
+	}
+}
+
+func TestPipeCmdError(t *testing.T) {
+	_, err := runPipeCmd(context.Background(), "", nil, []string{"sh", "-c", "echo abc >&2; exit 1"}, nil)
+	if err == nil {
+		t.Fatal("expecting error")
+	}
+}
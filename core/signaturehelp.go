@@ -0,0 +1,195 @@
+package core
+
+import (
+	"context"
+	"sync"
+
+	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Shows the lsproto signature help as an annotation at the call open parenthesis while typing the call arguments.
+type SignatureHelp struct {
+	ed *Editor
+
+	mu struct {
+		sync.Mutex
+		cancel context.CancelFunc
+		ta     *ui.TextArea // if not nil, signature help is on
+		start  int          // call open parenthesis index
+		seq    int          // request sequence, ignores late replies
+	}
+}
+
+func NewSignatureHelp(ed *Editor) *SignatureHelp {
+	sh := &SignatureHelp{ed: ed}
+	sh.mu.cancel = func() {} // avoid testing for nil
+	return sh
+}
+
+//----------
+
+// Requests the signature help if the write inserted a "(" or ",".
+func (sh *SignatureHelp) OnWrite(erow *ERow, ev *iorw.RWEvWrite2) {
+	if !(ev.Dn == 0 && ev.In == 1 && ev.Changed) {
+		return
+	}
+	if !erow.Info.IsFileButNotDir() {
+		return
+	}
+	ta := erow.Row.TextArea
+	b, err := ta.RW().ReadFastAt(ev.Index, 1)
+	if err != nil {
+		return
+	}
+	if b[0] != '(' && b[0] != ',' {
+		return
+	}
+	// early pre-check if filename is supported
+	if _, err := sh.ed.LSProtoMan.LangManager(erow.Info.Name()); err != nil {
+		return
+	}
+	index := ev.Index + 1 // the cursor is moved after the write
+	start, ok := callOpenParenIndex(ta.RW(), index)
+	if !ok {
+		return
+	}
+
+	sh.mu.Lock()
+	sh.mu.cancel() // cancel previous run
+	ctx, cancel := context.WithCancel(erow.ctx)
+	sh.mu.cancel = cancel
+	sh.mu.seq++
+	seq := sh.mu.seq
+	sh.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		s, err := sh.ed.LSProtoMan.TextDocumentSignatureHelp(ctx, erow.Info.Name(), ta.RW(), index)
+		if err != nil {
+			// don't show errors while typing
+			return
+		}
+		sh.ed.UI.RunOnUIGoRoutine(func() {
+			sh.mu.Lock()
+			late := seq != sh.mu.seq
+			sh.mu.Unlock()
+			if late {
+				return
+			}
+			if s == "" {
+				sh.clear(ta)
+				return
+			}
+			// cursor might have left the call while waiting
+			if k, ok := callOpenParenIndex(ta.RW(), ta.CursorIndex()); !ok || k != start {
+				return
+			}
+			sh.mu.Lock()
+			sh.mu.ta = ta
+			sh.mu.start = start
+			sh.mu.Unlock()
+			entries := []*drawer4.Annotation{{Offset: start, Bytes: []byte(s)}}
+			sh.setAnnotations(ta, entries)
+		})
+	}()
+}
+
+//----------
+
+func (sh *SignatureHelp) IsOn(ta *ui.TextArea) bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.mu.ta != nil && sh.mu.ta == ta
+}
+
+func (sh *SignatureHelp) setAnnotations(ta *ui.TextArea, entries []*drawer4.Annotation) {
+	on := len(entries) > 0
+	sh.ed.SetAnnotations(EdAnnReqSignatureHelp, ta, on, -1, entries)
+	if !on {
+		sh.setOff(ta)
+	}
+}
+
+func (sh *SignatureHelp) clear(ta *ui.TextArea) {
+	if sh.IsOn(ta) {
+		sh.setAnnotations(ta, nil)
+	}
+}
+
+// Turns off without clearing the annotations (ex: replaced by other annotations).
+func (sh *SignatureHelp) setOff(ta *ui.TextArea) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.mu.ta == ta {
+		sh.mu.ta = nil
+		sh.mu.cancel()
+	}
+}
+
+//----------
+
+func (sh *SignatureHelp) CancelAndClear() {
+	sh.mu.Lock()
+	sh.mu.cancel()
+	sh.mu.seq++ // ignore running requests
+	ta := sh.mu.ta
+	sh.mu.Unlock()
+	if ta != nil {
+		sh.setAnnotations(ta, nil)
+	}
+}
+
+// Hides the signature help if the cursor is not inside the call anymore.
+func (sh *SignatureHelp) CancelOnCursorChange() {
+	sh.mu.Lock()
+	ta := sh.mu.ta
+	start := sh.mu.start
+	sh.mu.Unlock()
+	if ta != nil {
+		k, ok := callOpenParenIndex(ta.RW(), ta.CursorIndex())
+		if !ok || k != start {
+			sh.setAnnotations(ta, nil)
+		}
+	}
+}
+
+//----------
+
+// Index of the open parenthesis of the call that contains the index (simple parenthesis balance, strings are not considered).
+func callOpenParenIndex(rd iorw.ReaderAt, index int) (int, bool) {
+	// limit the search
+	min := index - 4096
+	if min < rd.Min() {
+		min = rd.Min()
+	}
+	b, err := rd.ReadFastAt(min, index-min)
+	if err != nil {
+		return 0, false
+	}
+	depth, bdepth := 0, 0 // parenthesis, braces
+	for i := len(b) - 1; i >= 0; i-- {
+		switch b[i] {
+		case ')':
+			depth++
+		case '(':
+			if depth == 0 {
+				return min + i, true
+			}
+			depth--
+		case '}':
+			bdepth++
+		case '{':
+			if bdepth == 0 {
+				return 0, false
+			}
+			bdepth--
+		case ';':
+			if depth == 0 && bdepth == 0 {
+				return 0, false
+			}
+		}
+	}
+	return 0, false
+}
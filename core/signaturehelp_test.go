@@ -0,0 +1,31 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+func TestCallOpenParenIndex(t *testing.T) {
+	type in struct {
+		src   string
+		index int
+		res   int
+		ok    bool
+	}
+	u := []in{
+		{"f(a, g(b), ", 11, 1, true},
+		{"f(a, g(b", 8, 6, true},
+		{"f(func() { a() }, ", 18, 1, true},
+		{"f(func() { a(", 10, 0, false},
+		{"f(a); b", 7, 0, false},
+		{"a, b", 4, 0, false},
+	}
+	for _, w := range u {
+		rd := iorw.NewStringReaderAt(w.src)
+		k, ok := callOpenParenIndex(rd, w.index)
+		if ok != w.ok || (ok && k != w.res) {
+			t.Fatalf("%q: expecting %v %v, got %v %v", w.src, w.res, w.ok, k, ok)
+		}
+	}
+}
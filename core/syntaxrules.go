@@ -0,0 +1,114 @@
+package core
+
+import "github.com/jmigpin/editor/util/drawutil"
+
+// Syntax highlight rules (keywords, numbers, strings) selected by file extension in ERow.setupTextAreaSyntaxHighlight. Comments are setup separately.
+
+var (
+	dqString = &drawutil.SyntaxHighlightString{S: `"`, E: `"`, Escape: '\\'}
+	sqString = &drawutil.SyntaxHighlightString{S: "'", E: "'", Escape: '\\'}
+	// single quotes with a max length to not match apostrophes
+	sqCharString = &drawutil.SyntaxHighlightString{S: "'", E: "'", Escape: '\\', MaxLen: 10}
+)
+
+var goSyntaxRules = &drawutil.SyntaxHighlightRules{
+	Strings: []*drawutil.SyntaxHighlightString{
+		dqString,
+		sqCharString,
+		{S: "`", E: "`", Multiline: true}, // raw string
+	},
+	Keywords: []string{
+		"break", "case", "chan", "const", "continue", "default", "defer",
+		"else", "fallthrough", "for", "func", "go", "goto", "if",
+		"import", "interface", "map", "package", "range", "return",
+		"select", "struct", "switch", "type", "var",
+	},
+	Numbers: true,
+}
+
+var cSyntaxRules = &drawutil.SyntaxHighlightRules{
+	Strings: []*drawutil.SyntaxHighlightString{
+		{S: `R"(`, E: `)"`, Multiline: true}, // c++ raw string
+		dqString,
+		sqCharString,
+	},
+	Keywords: []string{
+		// c
+		"auto", "break", "case", "char", "const", "continue", "default",
+		"do", "double", "else", "enum", "extern", "float", "for", "goto",
+		"if", "inline", "int", "long", "register", "restrict", "return",
+		"short", "signed", "sizeof", "static", "struct", "switch",
+		"typedef", "union", "unsigned", "void", "volatile", "while",
+		// c++
+		"bool", "catch", "class", "constexpr", "delete", "explicit",
+		"false", "friend", "namespace", "new", "nullptr", "operator",
+		"private", "protected", "public", "template", "this", "throw",
+		"true", "try", "typename", "using", "virtual",
+	},
+	Numbers: true,
+}
+
+var javaSyntaxRules = &drawutil.SyntaxHighlightRules{
+	Strings: []*drawutil.SyntaxHighlightString{
+		{S: `"""`, E: `"""`, Escape: '\\', Multiline: true}, // text block
+		dqString,
+		sqCharString,
+	},
+	Keywords: []string{
+		"abstract", "boolean", "break", "byte", "case", "catch", "char",
+		"class", "continue", "default", "do", "double", "else", "enum",
+		"extends", "false", "final", "finally", "float", "for", "if",
+		"implements", "import", "instanceof", "int", "interface", "long",
+		"new", "null", "package", "private", "protected", "public",
+		"return", "short", "static", "super", "switch", "synchronized",
+		"this", "throw", "throws", "true", "try", "void", "volatile",
+		"while",
+	},
+	Numbers: true,
+}
+
+var jsSyntaxRules = &drawutil.SyntaxHighlightRules{
+	Strings: []*drawutil.SyntaxHighlightString{
+		dqString,
+		sqString,
+		{S: "`", E: "`", Escape: '\\', Multiline: true}, // template literal
+	},
+	Keywords: []string{
+		"async", "await", "break", "case", "catch", "class", "const",
+		"continue", "default", "delete", "do", "else", "export",
+		"extends", "false", "finally", "for", "function", "if", "import",
+		"in", "instanceof", "let", "new", "null", "of", "return", "super",
+		"switch", "this", "throw", "true", "try", "typeof", "undefined",
+		"var", "void", "while", "yield",
+	},
+	Numbers: true,
+}
+
+var pythonSyntaxRules = &drawutil.SyntaxHighlightRules{
+	Strings: []*drawutil.SyntaxHighlightString{
+		{S: `"""`, E: `"""`, Escape: '\\', Multiline: true},
+		{S: `'''`, E: `'''`, Escape: '\\', Multiline: true},
+		dqString,
+		sqString,
+	},
+	Keywords: []string{
+		"False", "None", "True", "and", "as", "assert", "async", "await",
+		"break", "class", "continue", "def", "del", "elif", "else",
+		"except", "finally", "for", "from", "global", "if", "import",
+		"in", "is", "lambda", "nonlocal", "not", "or", "pass", "raise",
+		"return", "try", "while", "with", "yield",
+	},
+	Numbers: true,
+}
+
+var shellSyntaxRules = &drawutil.SyntaxHighlightRules{
+	Strings: []*drawutil.SyntaxHighlightString{
+		{S: `"`, E: `"`, Escape: '\\', Multiline: true},
+		{S: "'", E: "'", Multiline: true}, // no escapes
+	},
+	Keywords: []string{
+		"case", "do", "done", "elif", "else", "esac", "export", "fi",
+		"for", "function", "if", "in", "local", "return", "select",
+		"then", "until", "while",
+	},
+}
@@ -2,8 +2,15 @@ package core
 
 import (
 	"fmt"
+	"image/color"
 	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"unicode/utf8"
+
+	"github.com/jmigpin/editor/util/osutil"
 )
 
 //godebug:annotatefile
@@ -22,6 +29,15 @@ type TerminalFilter struct {
 
 		csi tfCsi
 	}
+
+	sgr tfSgr // graphic rendition state (colors)
+
+	// pty mode ($terminal=p)
+	screen *TerminalScreen
+	pty    struct {
+		sync.Mutex
+		ptm *os.File
+	}
 }
 
 //----------
@@ -34,6 +50,10 @@ func NewTerminalFilter(erow *ERow) *TerminalFilter {
 func NewTerminalFilter2(tio TerminalIO, erow *ERow) *TerminalFilter {
 	tf := &TerminalFilter{tio: tio, erow: erow}
 	tf.p.stateFn = tf.stParseDefault
+	if erow != nil && erow.terminalOpt.pty {
+		tf.screen = NewTerminalScreen(80, 24)
+		tf.screen.reply = tio.AddToRead
+	}
 	tf.tio.Init(tf)
 	return tf
 }
@@ -41,7 +61,12 @@ func NewTerminalFilter2(tio TerminalIO, erow *ERow) *TerminalFilter {
 //----------
 
 func (tf *TerminalFilter) Write(p []byte) (int, error) {
-	if tf.erow != nil && tf.erow.terminalOpt.filter {
+	if tf.screen != nil {
+		_, _ = tf.screen.Write(p)
+		if err := tf.tio.WriteOp(tf.screen); err != nil {
+			return 0, err
+		}
+	} else if tf.erow != nil && tf.erow.terminalOpt.filter {
 		tf.filter(p)
 	} else {
 		if err := tf.tio.WriteOp(p); err != nil {
@@ -61,6 +86,27 @@ func (tf *TerminalFilter) Close() error {
 
 //----------
 
+// Sets the pty master to receive the screen size changes.
+func (tf *TerminalFilter) SetPty(ptm *os.File) error {
+	tf.pty.Lock()
+	defer tf.pty.Unlock()
+	tf.pty.ptm = ptm
+	cols, rows := tf.screen.Size()
+	return osutil.SetPtySize(ptm, cols, rows)
+}
+
+func (tf *TerminalFilter) resizeScreen(cols, rows int) {
+	tf.pty.Lock()
+	defer tf.pty.Unlock()
+	tf.screen.Resize(cols, rows)
+	if tf.pty.ptm != nil {
+		// ignoring error: the program could have ended
+		_ = osutil.SetPtySize(tf.pty.ptm, cols, rows)
+	}
+}
+
+//----------
+
 func (tf *TerminalFilter) filter(p []byte) {
 	tf.p.src = append(tf.p.src, p...)
 
@@ -245,6 +291,8 @@ func (tf *TerminalFilter) interpretCSI() error {
 	switch tf.p.csi.final {
 	case 'J': // erase display
 		return tf.eraseDisplay()
+	case 'm': // select graphic rendition
+		return tf.selectGraphicRendition()
 	}
 	return nil
 }
@@ -263,6 +311,21 @@ func (tf *TerminalFilter) eraseDisplay() error {
 
 //----------
 
+func (tf *TerminalFilter) selectGraphicRendition() error {
+	if tf.p.csi.qMark {
+		return nil
+	}
+	sgr := tf.sgr
+	sgr.apply(string(tf.p.csi.param))
+	if sgr == tf.sgr {
+		return nil
+	}
+	tf.sgr = sgr
+	return tf.tio.WriteOp(sgr.colorOp())
+}
+
+//----------
+
 func (tf *TerminalFilter) stParseOsc() error {
 	ru, err := tf.nextRune()
 	if err != nil {
@@ -342,3 +405,135 @@ type tfCsi struct {
 }
 
 //----------
+
+type tfSgr struct {
+	fg, bg  color.Color // nil is the default color
+	reverse bool
+}
+
+func (sgr *tfSgr) apply(params string) {
+	u := []int{}
+	for _, s := range strings.Split(params, ";") {
+		v, _ := strconv.Atoi(s) // empty is zero (reset)
+		u = append(u, v)
+	}
+	for i := 0; i < len(u); i++ {
+		switch v := u[i]; {
+		case v == 0:
+			*sgr = tfSgr{}
+		case v == 7:
+			sgr.reverse = true
+		case v == 27:
+			sgr.reverse = false
+		case v >= 30 && v <= 37:
+			sgr.fg = tfPalette[v-30]
+		case v >= 90 && v <= 97:
+			sgr.fg = tfPalette[8+v-90]
+		case v == 39:
+			sgr.fg = nil
+		case v >= 40 && v <= 47:
+			sgr.bg = tfPalette[v-40]
+		case v >= 100 && v <= 107:
+			sgr.bg = tfPalette[8+v-100]
+		case v == 49:
+			sgr.bg = nil
+		case v == 38 || v == 48:
+			c, n := tfExtendedColor(u[i+1:])
+			i += n
+			if c != nil {
+				if v == 38 {
+					sgr.fg = c
+				} else {
+					sgr.bg = c
+				}
+			}
+		default:
+			// ignored: bold, italic, underline, blink, ...
+		}
+	}
+}
+
+func (sgr *tfSgr) colorOp() *tfColorOp {
+	if sgr.reverse {
+		fg, bg := sgr.bg, sgr.fg
+		if fg == nil {
+			fg = tfPalette[0]
+		}
+		if bg == nil {
+			bg = tfPalette[7]
+		}
+		return &tfColorOp{fg: fg, bg: bg}
+	}
+	return &tfColorOp{fg: sgr.fg, bg: sgr.bg}
+}
+
+//----------
+
+// Colors the output written after it (written with WriteOp).
+type tfColorOp struct {
+	fg, bg color.Color // nil is the default color
+}
+
+//----------
+
+// Returns the color and the number of params used.
+func tfExtendedColor(u []int) (color.Color, int) {
+	if len(u) == 0 {
+		return nil, 0
+	}
+	switch u[0] {
+	case 5: // 256 colors
+		if len(u) < 2 {
+			return nil, len(u)
+		}
+		return tfColor256(u[1]), 2
+	case 2: // rgb
+		if len(u) < 4 {
+			return nil, len(u)
+		}
+		return color.RGBA{uint8(u[1]), uint8(u[2]), uint8(u[3]), 255}, 4
+	}
+	return nil, 1
+}
+
+func tfColor256(v int) color.Color {
+	switch {
+	case v < 0 || v > 255:
+		return nil
+	case v < 16:
+		return tfPalette[v]
+	case v < 232: // 6x6x6 cube
+		v -= 16
+		c := func(k int) uint8 {
+			if k == 0 {
+				return 0
+			}
+			return uint8(55 + k*40)
+		}
+		return color.RGBA{c(v / 36), c(v / 6 % 6), c(v % 6), 255}
+	default: // grayscale
+		g := uint8(8 + (v-232)*10)
+		return color.RGBA{g, g, g, 255}
+	}
+}
+
+var tfPalette = [16]color.Color{
+	color.RGBA{0, 0, 0, 255},       // black
+	color.RGBA{205, 0, 0, 255},     // red
+	color.RGBA{0, 160, 0, 255},     // green
+	color.RGBA{180, 140, 0, 255},   // yellow
+	color.RGBA{0, 0, 238, 255},     // blue
+	color.RGBA{205, 0, 205, 255},   // magenta
+	color.RGBA{0, 160, 160, 255},   // cyan
+	color.RGBA{229, 229, 229, 255}, // white
+	color.RGBA{127, 127, 127, 255}, // bright black
+	color.RGBA{255, 0, 0, 255},     // bright red
+	color.RGBA{0, 205, 0, 255},     // bright green
+	color.RGBA{205, 205, 0, 255},   // bright yellow
+	color.RGBA{92, 92, 255, 255},   // bright blue
+	color.RGBA{255, 0, 255, 255},   // bright magenta
+	color.RGBA{0, 205, 205, 255},   // bright cyan
+	color.RGBA{255, 255, 255, 255}, // bright white
+}
+
+//----------
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestTerminalFilterSgr(t *testing.T) {
+	tio := &tfTestIO{}
+	tf := NewTerminalFilter2(tio, nil)
+	tf.filter([]byte("a\x1b[31mb\x1b[1;42mc\x1b[0m\x1b[md\x1b[38;5;196me\x1b[38;2;1;2;3mf\x1b[7mg"))
+
+	red := tfPalette[1]
+	green := tfPalette[2]
+	w := []interface{}{
+		"a",
+		&tfColorOp{fg: red},
+		"b",
+		&tfColorOp{fg: red, bg: green},
+		"c",
+		&tfColorOp{},
+		"d",
+		&tfColorOp{fg: color.RGBA{255, 0, 0, 255}},
+		"e",
+		&tfColorOp{fg: color.RGBA{1, 2, 3, 255}},
+		"f",
+		&tfColorOp{fg: tfPalette[0], bg: color.RGBA{1, 2, 3, 255}},
+		"g",
+	}
+	if len(tio.ops) != len(w) {
+		t.Fatalf("%#v", tio.ops)
+	}
+	for i, op := range tio.ops {
+		switch t2 := w[i].(type) {
+		case string:
+			if b, ok := op.([]byte); !ok || string(b) != t2 {
+				t.Fatalf("%v: %#v", i, op)
+			}
+		case *tfColorOp:
+			c, ok := op.(*tfColorOp)
+			if !ok || !tfTestColorEq(c.fg, t2.fg) || !tfTestColorEq(c.bg, t2.bg) {
+				t.Fatalf("%v: %#v, expecting %#v", i, op, t2)
+			}
+		}
+	}
+}
+
+func tfTestColorEq(a, b color.Color) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	r1, g1, b1, a1 := a.RGBA()
+	r2, g2, b2, a2 := b.RGBA()
+	return r1 == r2 && g1 == g2 && b1 == b2 && a1 == a2
+}
+
+//----------
+
+type tfTestIO struct {
+	ops []interface{}
+}
+
+func (tio *tfTestIO) Init(tf *TerminalFilter)  {}
+func (tio *tfTestIO) Read([]byte) (int, error) { return 0, nil }
+func (tio *tfTestIO) AddToRead([]byte)         {}
+func (tio *tfTestIO) Close() error             { return nil }
+func (tio *tfTestIO) WriteOp(op interface{}) error {
+	if b, ok := op.([]byte); ok {
+		op = append([]byte{}, b...)
+	}
+	tio.ops = append(tio.ops, op)
+	return nil
+}
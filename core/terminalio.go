@@ -7,6 +7,7 @@ import (
 	"sync"
 
 	"github.com/jmigpin/editor/ui"
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
 	"github.com/jmigpin/editor/util/evreg"
 	"github.com/jmigpin/editor/util/iout/iorw"
 	"github.com/jmigpin/editor/util/uiutil/event"
@@ -20,7 +21,7 @@ type TerminalIO interface {
 	Read([]byte) (int, error) // input interface
 	AddToRead([]byte)         // add input internally to be read
 
-	WriteOp(interface{}) error // accepted types: {[]byte,string}
+	WriteOp(interface{}) error // accepted types: {[]byte,string,*TerminalScreen,*tfColorOp}
 
 	Close() error
 }
@@ -33,7 +34,8 @@ type ERowTermIO struct {
 	erow *ERow
 	tf   *TerminalFilter
 
-	inputReg *evreg.Regist // input events
+	inputReg  *evreg.Regist // input events
+	layoutReg *evreg.Regist // layout events (pty mode: screen size)
 
 	input struct {
 		sync.Mutex
@@ -45,6 +47,7 @@ type ERowTermIO struct {
 		sync.Mutex
 		updating bool
 		ops      []interface{}
+		color    *tfColorOp // last color op applied
 	}
 }
 
@@ -61,6 +64,9 @@ func (tio *ERowTermIO) Init(tf *TerminalFilter) {
 
 func (tio *ERowTermIO) Close() error {
 	tio.inputReg.Unregister()
+	if tio.layoutReg != nil {
+		tio.layoutReg.Unregister()
+	}
 
 	// signal to unblock waiting for a read
 	tio.input.Lock()
@@ -139,15 +145,40 @@ func (tio *ERowTermIO) updateWriteOp2(op interface{}) error {
 			if err := ta.SetBytesClearHistory(nil); err != nil {
 				return err
 			}
+			ta.SetTerminalColorizeOps(nil)
+			if c := tio.update.color; c != nil {
+				tio.updateColorOp(c) // keep current color
+			}
 		default:
 			panic(fmt.Sprintf("todo: %v", t))
 		}
+	case *TerminalScreen:
+		b, ci := t.Render()
+		if err := ta.SetBytesClearHistory(b); err != nil {
+			return err
+		}
+		ta.SetCursorIndex(ci)
+		ta.MakeCursorVisible()
+	case *tfColorOp:
+		tio.updateColorOp(t)
 	default:
 		panic(fmt.Sprintf("todo: %v %T", t, t))
 	}
 	return nil
 }
 
+// Colors the text appended after this op.
+func (tio *ERowTermIO) updateColorOp(c *tfColorOp) {
+	tio.update.color = c
+	ta := tio.erow.Row.TextArea
+	op := &drawer4.ColorizeOp{Offset: ta.RW().Max(), Fg: c.fg, Bg: c.bg}
+	ops := ta.TerminalColorizeOps()
+	if l := len(ops); l > 0 && ops[l-1].Offset == op.Offset {
+		ops = ops[:l-1] // replace (no text was written in between)
+	}
+	ta.SetTerminalColorizeOps(append(ops, op))
+}
+
 func (tio *ERowTermIO) appendOp(op interface{}) {
 	o := &tio.update.ops
 	switch t := op.(type) {
@@ -166,6 +197,14 @@ func (tio *ERowTermIO) appendOp(op interface{}) {
 		}
 
 		*o = append(*o, b)
+	case *TerminalScreen:
+		// performance: the screen is rendered only once
+		for _, op2 := range *o {
+			if op2 == op {
+				return
+			}
+		}
+		*o = append(*o, op)
 	default:
 		*o = append(*o, op)
 	}
@@ -176,6 +215,35 @@ func (tio *ERowTermIO) appendOp(op interface{}) {
 func (tio *ERowTermIO) initInput() {
 	ta := tio.erow.Row.TextArea
 	tio.inputReg = ta.EvReg.Add(ui.TextAreaInputEventId, tio.onTextAreaInputEvent)
+
+	if tio.tf.screen != nil {
+		tio.layoutReg = ta.EvReg.Add(ui.TextAreaLayoutEventId, func(interface{}) {
+			tio.updateScreenSize()
+		})
+		tio.erow.Ed.UI.RunOnUIGoRoutine(tio.updateScreenSize)
+	}
+}
+
+// Screen size from the textarea size in chars. Should be called under UI goroutine.
+func (tio *ERowTermIO) updateScreenSize() {
+	ta := tio.erow.Row.TextArea
+	ff := ta.TreeThemeFontFace()
+	adv, ok := ff.Face.GlyphAdvance('M')
+	if !ok || adv <= 0 || ta.LineHeight() <= 0 {
+		return
+	}
+	r := ta.Bounds
+	cols := r.Dx() / adv.Ceil()
+	rows := r.Dy() / ta.LineHeight()
+	if cols <= 0 || rows <= 0 {
+		return // not laid out yet
+	}
+	cols2, rows2 := tio.tf.screen.Size()
+	if cols == cols2 && rows == rows2 {
+		return
+	}
+	tio.tf.resizeScreen(cols, rows)
+	_ = tio.WriteOp(tio.tf.screen)
 }
 
 func (tio *ERowTermIO) onTextAreaInputEvent(ev0 interface{}) {
@@ -190,6 +258,10 @@ func (tio *ERowTermIO) onTextAreaInputEvent(ev0 interface{}) {
 //----------
 
 func (tio *ERowTermIO) eventToBytes(ev interface{}) ([]byte, event.Handled) {
+	if tio.tf.screen != nil {
+		return ptyEventToBytes(ev)
+	}
+
 	// util funcs
 	keyboardEvs := func() bool {
 		return tio.erow.terminalOpt.keyEvents
@@ -226,3 +298,63 @@ func (tio *ERowTermIO) eventToBytes(ev interface{}) ([]byte, event.Handled) {
 	}
 	return nil, false
 }
+
+// Keyboard input to the pty (no local echo, the program echoes).
+func ptyEventToBytes(ev interface{}) ([]byte, event.Handled) {
+	t, ok := ev.(*event.KeyDown)
+	if !ok {
+		return nil, false
+	}
+	var b []byte
+	switch t.KeySym {
+	case event.KSymReturn:
+		b = []byte{'\r'}
+	case event.KSymBackspace:
+		b = []byte{0x7f}
+	case event.KSymTab:
+		b = []byte{'\t'}
+	case event.KSymEscape:
+		b = []byte{0x1b}
+	case event.KSymUp:
+		b = []byte("\x1b[A")
+	case event.KSymDown:
+		b = []byte("\x1b[B")
+	case event.KSymRight:
+		b = []byte("\x1b[C")
+	case event.KSymLeft:
+		b = []byte("\x1b[D")
+	case event.KSymHome:
+		b = []byte("\x1b[H")
+	case event.KSymEnd:
+		b = []byte("\x1b[F")
+	case event.KSymInsert:
+		b = []byte("\x1b[2~")
+	case event.KSymDelete:
+		b = []byte("\x1b[3~")
+	case event.KSymPageUp:
+		b = []byte("\x1b[5~")
+	case event.KSymPageDown:
+		b = []byte("\x1b[6~")
+	default:
+		ru := t.Rune
+		if ru == 0 {
+			return nil, false // ex: modifier keys
+		}
+		mods := t.Mods.ClearLocks()
+		if mods.HasAny(event.ModCtrl) {
+			switch {
+			case ru >= 'a' && ru <= 'z':
+				ru -= 'a' - 1
+			case ru >= '@' && ru <= '_':
+				ru -= '@'
+			case ru == ' ':
+				ru = 0
+			}
+		}
+		b = []byte(string(ru))
+		if mods.HasAny(event.ModAlt) {
+			b = append([]byte{0x1b}, b...)
+		}
+	}
+	return b, true
+}
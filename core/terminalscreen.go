@@ -0,0 +1,570 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jmigpin/editor/util/mathutil"
+)
+
+// Screen grid of a pty terminal ($terminal=p). Emulates a subset of the vt100/xterm control sequences: cursor movement, erase, insert/delete, scroll regions and the alternate screen. Lines scrolled out of the top of the main screen are kept in the scrollback.
+type TerminalScreen struct {
+	sync.Mutex
+	cols, rows int
+	lines      [][]rune // [row][col] current screen
+	scrollback [][]rune // lines with trailing spaces trimmed
+	cx, cy     int      // cursor
+	wrapNext   bool     // next printed rune goes to the next line
+	top, bot   int      // scroll region (inclusive)
+	saved      struct{ cx, cy int }
+
+	alt struct {
+		on    bool
+		lines [][]rune // main screen lines while the alt screen is on
+		saved struct{ cx, cy int }
+	}
+
+	p struct { // parser
+		state tsState
+		pend  []byte // incomplete utf8 rune
+		priv  byte   // csi private marker (ex: '?')
+		param []byte
+	}
+
+	// replies to the program (ex: cursor position report)
+	reply func([]byte)
+}
+
+func NewTerminalScreen(cols, rows int) *TerminalScreen {
+	ts := &TerminalScreen{}
+	ts.reply = func([]byte) {}
+	ts.resize2(cols, rows)
+	return ts
+}
+
+//----------
+
+func (ts *TerminalScreen) Size() (cols, rows int) {
+	ts.Lock()
+	defer ts.Unlock()
+	return ts.cols, ts.rows
+}
+
+func (ts *TerminalScreen) Resize(cols, rows int) {
+	ts.Lock()
+	defer ts.Unlock()
+	ts.resize2(cols, rows)
+}
+
+func (ts *TerminalScreen) resize2(cols, rows int) {
+	if cols < 1 {
+		cols = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	// keep the cursor line visible by scrolling the main screen
+	if !ts.alt.on && ts.cy >= rows {
+		n := ts.cy - rows + 1
+		for _, l := range ts.lines[:n] {
+			ts.addScrollback(l)
+		}
+		ts.lines = ts.lines[n:]
+		ts.cy -= n
+	}
+	ts.cols, ts.rows = cols, rows
+	ts.lines = tsResizeLines(ts.lines, cols, rows)
+	if ts.alt.on {
+		ts.alt.lines = tsResizeLines(ts.alt.lines, cols, rows)
+	}
+	ts.top, ts.bot = 0, rows-1
+	ts.cx, ts.cy = ts.clampX(ts.cx), ts.clampY(ts.cy)
+	ts.wrapNext = false
+}
+
+//----------
+
+func (ts *TerminalScreen) Write(p []byte) (int, error) {
+	ts.Lock()
+	defer ts.Unlock()
+	b := p
+	if len(ts.p.pend) > 0 {
+		b = append(ts.p.pend, p...)
+		ts.p.pend = nil
+	}
+	for i := 0; i < len(b); {
+		c := b[i]
+		if ts.p.state == tssGround && c >= utf8.RuneSelf {
+			if !utf8.FullRune(b[i:]) {
+				ts.p.pend = append([]byte{}, b[i:]...)
+				break
+			}
+			ru, size := utf8.DecodeRune(b[i:])
+			ts.put(ru)
+			i += size
+			continue
+		}
+		ts.parseByte(c)
+		i++
+	}
+	return len(p), nil
+}
+
+//----------
+
+// Returns the text of the scrollback and screen lines, and the cursor index in the text.
+func (ts *TerminalScreen) Render() ([]byte, int) {
+	ts.Lock()
+	defer ts.Unlock()
+
+	buf := &bytes.Buffer{}
+	if !ts.alt.on {
+		for _, l := range ts.scrollback {
+			buf.WriteString(string(l))
+			buf.WriteByte('\n')
+		}
+	}
+
+	// trailing empty lines after the cursor are not shown
+	n := len(ts.lines)
+	for ; n > ts.cy+1; n-- {
+		if len(tsTrimLine(ts.lines[n-1])) != 0 {
+			break
+		}
+	}
+
+	ci := 0
+	for y, l := range ts.lines[:n] {
+		if y > 0 {
+			buf.WriteByte('\n')
+		}
+		l2 := tsTrimLine(l)
+		if y == ts.cy {
+			// keep the spaces up to the cursor
+			if len(l2) < ts.cx {
+				l2 = l[:ts.cx]
+			}
+			ci = buf.Len() + len(string(l2[:ts.cx]))
+		}
+		buf.WriteString(string(l2))
+	}
+	return buf.Bytes(), ci
+}
+
+//----------
+
+func (ts *TerminalScreen) parseByte(c byte) {
+	switch ts.p.state {
+	case tssGround:
+		ts.ground(c)
+	case tssEsc:
+		ts.esc(c)
+	case tssCsi:
+		ts.csi(c)
+	case tssOsc:
+		switch c {
+		case 0x7: // end
+			ts.p.state = tssGround
+		case 0x1b:
+			ts.p.state = tssOscEsc
+		}
+	case tssOscEsc: // expecting '\' (string terminator)
+		ts.p.state = tssGround
+	case tssSkip1:
+		ts.p.state = tssGround
+	}
+}
+
+func (ts *TerminalScreen) ground(c byte) {
+	switch c {
+	case 0x1b: // ESC
+		ts.p.state = tssEsc
+	case '\r':
+		ts.cx = 0
+		ts.wrapNext = false
+	case '\n', 0xb, 0xc: // newline, vertical tab, formfeed
+		ts.lineFeed()
+	case '\b':
+		if ts.cx > 0 {
+			ts.cx--
+		}
+		ts.wrapNext = false
+	case '\t':
+		ts.cx = ts.clampX((ts.cx/8 + 1) * 8)
+		ts.wrapNext = false
+	default:
+		if c < 0x20 || c == 0x7f {
+			return // ignored controls (ex: BEL)
+		}
+		ts.put(rune(c))
+	}
+}
+
+func (ts *TerminalScreen) esc(c byte) {
+	ts.p.state = tssGround
+	switch c {
+	case '[':
+		ts.p.state = tssCsi
+		ts.p.priv = 0
+		ts.p.param = ts.p.param[:0]
+	case ']', 'P', '_', '^': // os command, device control string, ...
+		ts.p.state = tssOsc
+	case '(', ')', '*', '+', '#': // char sets, alignment test
+		ts.p.state = tssSkip1
+	case '7':
+		ts.saveCursor()
+	case '8':
+		ts.restoreCursor()
+	case 'D': // index
+		ts.lineFeed()
+	case 'E': // next line
+		ts.cx = 0
+		ts.lineFeed()
+	case 'M': // reverse index
+		ts.reverseIndex()
+	case 'c': // reset
+		ts.alt.on = false
+		ts.alt.lines = nil
+		ts.scrollback = nil
+		ts.lines = nil
+		ts.cx, ts.cy = 0, 0
+		ts.resize2(ts.cols, ts.rows)
+	}
+}
+
+func (ts *TerminalScreen) csi(c byte) {
+	switch {
+	case c == 0x1b:
+		ts.p.state = tssEsc
+	case c < 0x20: // controls are executed inside sequences
+		ts.ground(c)
+	case c >= '<' && c <= '?':
+		ts.p.priv = c
+	case c >= '0' && c <= ';':
+		ts.p.param = append(ts.p.param, c)
+	case c >= 0x20 && c <= 0x2f: // intermediate bytes: ignored
+	case c >= 0x40 && c <= 0x7e: // final byte
+		ts.p.state = tssGround
+		ts.wrapNext = false
+		ts.interpretCsi(c)
+	default:
+		ts.p.state = tssGround
+	}
+}
+
+//----------
+
+func (ts *TerminalScreen) interpretCsi(final byte) {
+	params := ts.params()
+	// param with default value (also for zero)
+	pd := func(i, def int) int {
+		if i < len(params) && params[i] > 0 {
+			return params[i]
+		}
+		return def
+	}
+	n := pd(0, 1)
+
+	if ts.p.priv != 0 {
+		if ts.p.priv == '?' && (final == 'h' || final == 'l') {
+			for _, m := range params {
+				ts.privateMode(m, final == 'h')
+			}
+		}
+		return
+	}
+
+	switch final {
+	case '@': // insert blanks
+		l := ts.lines[ts.cy]
+		n = mathutil.Min(n, ts.cols-ts.cx)
+		copy(l[ts.cx+n:], l[ts.cx:])
+		tsFill(l[ts.cx : ts.cx+n])
+	case 'A': // cursor up
+		ts.cy = mathutil.Max(ts.cy-n, ts.regionTopFor(ts.cy))
+	case 'B', 'e': // cursor down
+		ts.cy = mathutil.Min(ts.cy+n, ts.regionBotFor(ts.cy))
+	case 'C', 'a': // cursor forward
+		ts.cx = ts.clampX(ts.cx + n)
+	case 'D': // cursor back
+		ts.cx = ts.clampX(ts.cx - n)
+	case 'E': // cursor next line
+		ts.cx = 0
+		ts.cy = mathutil.Min(ts.cy+n, ts.regionBotFor(ts.cy))
+	case 'F': // cursor previous line
+		ts.cx = 0
+		ts.cy = mathutil.Max(ts.cy-n, ts.regionTopFor(ts.cy))
+	case 'G', '`': // cursor column
+		ts.cx = ts.clampX(n - 1)
+	case 'H', 'f': // cursor position
+		ts.cy = ts.clampY(pd(0, 1) - 1)
+		ts.cx = ts.clampX(pd(1, 1) - 1)
+	case 'd': // cursor row
+		ts.cy = ts.clampY(n - 1)
+	case 'J': // erase display
+		ts.eraseDisplay(pd(0, 0))
+	case 'K': // erase line
+		l := ts.lines[ts.cy]
+		switch pd(0, 0) {
+		case 0:
+			tsFill(l[ts.cx:])
+		case 1:
+			tsFill(l[:ts.cx+1])
+		case 2:
+			tsFill(l)
+		}
+	case 'L': // insert lines
+		if ts.cy >= ts.top && ts.cy <= ts.bot {
+			ts.scrollDownRegion(ts.cy, ts.bot, n)
+			ts.cx = 0
+		}
+	case 'M': // delete lines
+		if ts.cy >= ts.top && ts.cy <= ts.bot {
+			ts.scrollUpRegion(ts.cy, ts.bot, n)
+			ts.cx = 0
+		}
+	case 'P': // delete chars
+		l := ts.lines[ts.cy]
+		n = mathutil.Min(n, ts.cols-ts.cx)
+		copy(l[ts.cx:], l[ts.cx+n:])
+		tsFill(l[ts.cols-n:])
+	case 'X': // erase chars
+		n = mathutil.Min(n, ts.cols-ts.cx)
+		tsFill(ts.lines[ts.cy][ts.cx : ts.cx+n])
+	case 'S': // scroll up
+		ts.scrollUpRegion(ts.top, ts.bot, n)
+	case 'T': // scroll down
+		ts.scrollDownRegion(ts.top, ts.bot, n)
+	case 'r': // set scroll region
+		top, bot := pd(0, 1)-1, pd(1, ts.rows)-1
+		if top < bot && bot < ts.rows {
+			ts.top, ts.bot = top, bot
+			ts.cx, ts.cy = 0, 0
+		}
+	case 's':
+		ts.saveCursor()
+	case 'u':
+		ts.restoreCursor()
+	case 'n': // device status report
+		switch pd(0, 0) {
+		case 5:
+			ts.reply([]byte("\x1b[0n"))
+		case 6:
+			ts.reply([]byte(fmt.Sprintf("\x1b[%d;%dR", ts.cy+1, ts.cx+1)))
+		}
+	case 'c': // device attributes
+		if pd(0, 0) == 0 {
+			ts.reply([]byte("\x1b[?1;2c")) // vt100 with advanced video
+		}
+	case 'm': // graphic rendition: not supported
+	}
+}
+
+func (ts *TerminalScreen) params() []int {
+	if len(ts.p.param) == 0 {
+		return nil
+	}
+	u := []int{}
+	for _, s := range strings.Split(string(ts.p.param), ";") {
+		v, _ := strconv.Atoi(s) // ex: sub params "38:2" become zero
+		u = append(u, v)
+	}
+	return u
+}
+
+func (ts *TerminalScreen) privateMode(m int, on bool) {
+	switch m {
+	case 1049: // alt screen with saved cursor
+		if on {
+			ts.alt.saved.cx, ts.alt.saved.cy = ts.cx, ts.cy
+			ts.altScreen(true)
+			ts.eraseDisplay(2)
+		} else {
+			ts.altScreen(false)
+			ts.cx, ts.cy = ts.clampX(ts.alt.saved.cx), ts.clampY(ts.alt.saved.cy)
+		}
+	case 47, 1047:
+		ts.altScreen(on)
+	}
+}
+
+func (ts *TerminalScreen) altScreen(on bool) {
+	if on == ts.alt.on {
+		return
+	}
+	ts.alt.on = on
+	if on {
+		ts.alt.lines = ts.lines
+		ts.lines = tsResizeLines(nil, ts.cols, ts.rows)
+	} else {
+		ts.lines = ts.alt.lines
+		ts.alt.lines = nil
+	}
+	ts.top, ts.bot = 0, ts.rows-1
+}
+
+//----------
+
+func (ts *TerminalScreen) put(ru rune) {
+	if ts.wrapNext {
+		ts.wrapNext = false
+		ts.cx = 0
+		ts.lineFeed()
+	}
+	ts.lines[ts.cy][ts.cx] = ru
+	if ts.cx == ts.cols-1 {
+		ts.wrapNext = true
+	} else {
+		ts.cx++
+	}
+}
+
+func (ts *TerminalScreen) lineFeed() {
+	ts.wrapNext = false
+	if ts.cy == ts.bot {
+		if ts.top == 0 && !ts.alt.on {
+			ts.addScrollback(ts.lines[0])
+		}
+		ts.scrollUpRegion(ts.top, ts.bot, 1)
+	} else if ts.cy < ts.rows-1 {
+		ts.cy++
+	}
+}
+
+func (ts *TerminalScreen) reverseIndex() {
+	ts.wrapNext = false
+	if ts.cy == ts.top {
+		ts.scrollDownRegion(ts.top, ts.bot, 1)
+	} else if ts.cy > 0 {
+		ts.cy--
+	}
+}
+
+func (ts *TerminalScreen) eraseDisplay(mode int) {
+	switch mode {
+	case 0: // from cursor to end
+		tsFill(ts.lines[ts.cy][ts.cx:])
+		for _, l := range ts.lines[ts.cy+1:] {
+			tsFill(l)
+		}
+	case 1: // from start to cursor
+		for _, l := range ts.lines[:ts.cy] {
+			tsFill(l)
+		}
+		tsFill(ts.lines[ts.cy][:ts.cx+1])
+	case 2, 3: // all (3: including scrollback)
+		for _, l := range ts.lines {
+			tsFill(l)
+		}
+		if mode == 3 {
+			ts.scrollback = nil
+		}
+	}
+}
+
+//----------
+
+func (ts *TerminalScreen) scrollUpRegion(top, bot, n int) {
+	n = mathutil.Min(n, bot-top+1)
+	for i := 0; i < n; i++ {
+		copy(ts.lines[top:bot], ts.lines[top+1:bot+1])
+		ts.lines[bot] = tsNewLine(ts.cols)
+	}
+}
+
+func (ts *TerminalScreen) scrollDownRegion(top, bot, n int) {
+	n = mathutil.Min(n, bot-top+1)
+	for i := 0; i < n; i++ {
+		copy(ts.lines[top+1:bot+1], ts.lines[top:bot])
+		ts.lines[top] = tsNewLine(ts.cols)
+	}
+}
+
+func (ts *TerminalScreen) addScrollback(l []rune) {
+	l2 := append([]rune{}, tsTrimLine(l)...)
+	ts.scrollback = append(ts.scrollback, l2)
+	if len(ts.scrollback) > tsScrollbackMax {
+		ts.scrollback = ts.scrollback[len(ts.scrollback)-tsScrollbackMax:]
+	}
+}
+
+//----------
+
+func (ts *TerminalScreen) saveCursor() {
+	ts.saved.cx, ts.saved.cy = ts.cx, ts.cy
+}
+func (ts *TerminalScreen) restoreCursor() {
+	ts.cx, ts.cy = ts.clampX(ts.saved.cx), ts.clampY(ts.saved.cy)
+	ts.wrapNext = false
+}
+
+//----------
+
+// Cursor up/down movements stop at the scroll region if inside it.
+func (ts *TerminalScreen) regionTopFor(y int) int {
+	if y >= ts.top {
+		return ts.top
+	}
+	return 0
+}
+func (ts *TerminalScreen) regionBotFor(y int) int {
+	if y <= ts.bot {
+		return ts.bot
+	}
+	return ts.rows - 1
+}
+
+func (ts *TerminalScreen) clampX(x int) int { return mathutil.Max(0, mathutil.Min(x, ts.cols-1)) }
+func (ts *TerminalScreen) clampY(y int) int { return mathutil.Max(0, mathutil.Min(y, ts.rows-1)) }
+
+//----------
+
+const tsScrollbackMax = 2000
+
+type tsState int
+
+const (
+	tssGround tsState = iota
+	tssEsc
+	tssCsi
+	tssOsc
+	tssOscEsc
+	tssSkip1
+)
+
+//----------
+
+func tsNewLine(cols int) []rune {
+	l := make([]rune, cols)
+	tsFill(l)
+	return l
+}
+
+func tsFill(l []rune) {
+	for i := range l {
+		l[i] = ' '
+	}
+}
+
+func tsTrimLine(l []rune) []rune {
+	n := len(l)
+	for ; n > 0 && l[n-1] == ' '; n-- {
+	}
+	return l[:n]
+}
+
+func tsResizeLines(lines [][]rune, cols, rows int) [][]rune {
+	u := make([][]rune, rows)
+	for y := range u {
+		l := tsNewLine(cols)
+		if y < len(lines) {
+			copy(l, lines[y])
+		}
+		u[y] = l
+	}
+	return u
+}
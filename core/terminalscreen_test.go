@@ -0,0 +1,92 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestTerminalScreen1(t *testing.T) {
+	type result struct {
+		in  string
+		out string
+		ci  int
+	}
+	w := []result{
+		{"abc", "abc", 3},
+		{"abc\r\ndef", "abc\ndef", 7},
+		{"abc\ndef", "abc\n   de\nf", 11},
+		{"abcdefgh", "abcde\nfgh", 9},               // autowrap
+		{"abcde", "abcde", 4},                       // cursor at the last column
+		{"abc\x1b[2Dx", "axc", 2},                   // cursor back
+		{"abc\x1b[1;2Hx", "axc", 2},                 // cursor position
+		{"abc\x1b[2J", "   ", 3},                    // erase display
+		{"abc\x1b[2G\x1b[K", "a", 1},                // erase line from cursor
+		{"abc\x1b[2G\x1b[P", "ac", 1},               // delete char
+		{"abc\x1b[2G\x1b[@", "a bc", 1},             // insert blank
+		{"a\r\nb\r\nc\x1b[2;1H\x1b[L", "a\n\nb", 2}, // insert line
+		{"a\x1b[3;1Hb\x1b[1;1H\x1b[M", "\nb", 0},    // delete line
+		{"\x1b]0;title\x07ab", "ab", 2},             // osc skipped
+		{"ab\x1b7\r\ncd\x1b8e", "abe\ncd", 3},       // save/restore cursor
+		{"\x1b[31mab\x1b[0m", "ab", 2},              // graphic rendition ignored
+		{"é\xc3", "é", 2},                           // incomplete rune
+	}
+	for i, u := range w {
+		ts := NewTerminalScreen(5, 3)
+		ts.Write([]byte(u.in))
+		b, ci := ts.Render()
+		if string(b) != u.out || ci != u.ci {
+			t.Fatalf("%v: %q, %v: expecting %q, %v", i, b, ci, u.out, u.ci)
+		}
+	}
+}
+
+func TestTerminalScreenScroll(t *testing.T) {
+	ts := NewTerminalScreen(5, 3)
+	ts.Write([]byte("1\r\n2\r\n3\r\n4"))
+	b, ci := ts.Render()
+	if string(b) != "1\n2\n3\n4" || ci != 7 {
+		t.Fatalf("%q %v", b, ci)
+	}
+
+	// scroll region: lines outside the region are kept
+	ts = NewTerminalScreen(5, 4)
+	ts.Write([]byte("a\r\nb\r\nc\r\nd\x1b[2;3r\x1b[3;1H\nx"))
+	b, _ = ts.Render()
+	if string(b) != "a\nc\nx\nd" {
+		t.Fatalf("%q", b)
+	}
+}
+
+func TestTerminalScreenAlt(t *testing.T) {
+	ts := NewTerminalScreen(5, 3)
+	ts.Write([]byte("ab\x1b[?1049hxyz"))
+	b, _ := ts.Render()
+	if string(b) != "  xyz" { // cursor position is kept
+		t.Fatalf("%q", b)
+	}
+	ts.Write([]byte("\x1b[?1049l"))
+	b, ci := ts.Render()
+	if string(b) != "ab" || ci != 2 {
+		t.Fatalf("%q %v", b, ci)
+	}
+}
+
+func TestTerminalScreenReply(t *testing.T) {
+	ts := NewTerminalScreen(5, 3)
+	reply := ""
+	ts.reply = func(b []byte) { reply += string(b) }
+	ts.Write([]byte("\r\nab\x1b[6n"))
+	if reply != "\x1b[2;3R" {
+		t.Fatalf("%q", reply)
+	}
+}
+
+func TestTerminalScreenResize(t *testing.T) {
+	ts := NewTerminalScreen(5, 3)
+	ts.Write([]byte("1\r\n2\r\n3"))
+	ts.Resize(3, 2) // cursor line kept visible
+	ts.Write([]byte("\r\n4"))
+	b, _ := ts.Render()
+	if string(b) != "1\n2\n3\n4" {
+		t.Fatalf("%q", b)
+	}
+}
@@ -29,6 +29,8 @@ func main() {
 	flag.StringVar(&opt.ColorTheme, "colortheme", "light", "available: light, dark, acme")
 	flag.IntVar(&opt.CommentsColor, "commentscolor", 0, "Colorize comments. Can be set to zero to use a percentage of the font color. Ex: 0=auto, 1=Black, 0xff0000=red.")
 	flag.IntVar(&opt.StringsColor, "stringscolor", 0, "Colorize strings. Can be set to zero to not colorize. Ex: 0xff0000=red.")
+	flag.IntVar(&opt.KeywordsColor, "keywordscolor", 0, "Colorize keywords of known languages (by file extension). Can be set to zero to not colorize. Ex: 0x0000ff=blue.")
+	flag.IntVar(&opt.NumbersColor, "numberscolor", 0, "Colorize numbers of known languages (by file extension). Can be set to zero to not colorize. Ex: 0xff0000=red.")
 	flag.IntVar(&opt.ScrollBarWidth, "scrollbarwidth", 0, "Textarea scrollbar width in pixels. A value of 0 takes 3/4 of the font size.")
 	flag.BoolVar(&opt.ScrollBarLeft, "scrollbarleft", true, "set scrollbars on the left side")
 	flag.BoolVar(&opt.Shadows, "shadows", true, "shadow effects on some elements")
@@ -36,7 +38,8 @@ func main() {
 	flag.StringVar(&opt.SessionName, "sessionname", "", "open existing session")
 	flag.BoolVar(&opt.UseMultiKey, "usemultikey", false, "use multi-key to compose characters (Ex: [multi-key, ~, a] = ã)")
 	flag.StringVar(&opt.Plugins, "plugins", "", "comma separated string of plugin filenames")
-	flag.Var(&opt.LSProtos, "lsproto", "Language-server-protocol register options. Can be specified multiple times.\nFormat: language,extensions,network{tcp,tcpclient,stdio},cmd,optional{stderr}\nExamples:\n"+lsproto.RegistrationExamples())
+	flag.StringVar(&opt.KeyMap, "keymap", "", "key bindings filename, one binding per line (Ex: \"ctrl+shift+k rwedit.RemoveLines\")")
+	flag.Var(&opt.LSProtos, "lsproto", "Language-server-protocol register options. Can be specified multiple times.\nFormat: language,extensions,network{tcp,tcpclient,stdio},cmd,optional{stderr,formatOnSave,settings=<json>}\nExamples:\n"+lsproto.RegistrationExamples())
 	cpuProfileFlag := flag.String("cpuprofile", "", "profile cpu filename")
 	version := flag.Bool("version", false, "output version and exit")
 
@@ -104,6 +104,14 @@ func (l *Root) OnInputEvent(ev0 interface{}, p image.Point) event.Handled {
 
 //----------
 
+// Textarea (or toolbar textarea) at the point.
+func (l *Root) TextAreaAtPoint(p image.Point) (*TextArea, bool) {
+	ta := l.ContextFloatBox.visitToFindTA(p, l)
+	return ta, ta != nil
+}
+
+//----------
+
 func (l *Root) selAnnEv(typ RootSelectAnnotationType) {
 	ev2 := &RootSelectAnnotationEvent{typ}
 	l.EvReg.RunCallbacks(RootSelectAnnotationEventId, ev2)
@@ -32,6 +32,9 @@ func (sq *RowSquare) Paint() {
 	if sq.state.hasAny(RowStateEdited) {
 		bg = sq.TreeThemePaletteColor("rs_edited")
 	}
+	if sq.state.hasAny(RowStateReadOnly) {
+		bg = sq.TreeThemePaletteColor("rs_read_only")
+	}
 	if sq.state.hasAny(RowStateNotExist) {
 		bg = sq.TreeThemePaletteColor("rs_not_exist")
 	}
@@ -71,6 +74,11 @@ func (sq *RowSquare) Paint() {
 		c := sq.TreeThemePaletteColor("rs_annotations_edited")
 		imageutil.FillRectangle(img, r, c)
 	}
+	if sq.state.hasAny(RowStatePaused) {
+		r := sq.miniSq(3)
+		c := sq.TreeThemePaletteColor("rs_paused")
+		imageutil.FillRectangle(img, r, c)
+	}
 }
 func (sq *RowSquare) miniSq(i int) image.Rectangle {
 	// mini squares
@@ -141,4 +149,6 @@ const (
 	RowStateDuplicateHighlight
 	RowStateAnnotations
 	RowStateAnnotationsEdited
+	RowStateReadOnly
+	RowStatePaused
 )
@@ -74,6 +74,10 @@ func (ta *TextArea) handleInputEvent2(ev0 interface{}, p image.Point) event.Hand
 				if ta.selAnnCurEv(ev.Point, TASelAnnTypePrintAllPrevious) {
 					return true
 				}
+			case m.Is(event.ModCtrl | event.ModAlt):
+				if ta.selAnnCurEv(ev.Point, TASelAnnTypeInspect) {
+					return true
+				}
 			}
 			if !ta.SupportClickInsideSelection || !ta.PointIndexInsideSelection(ev.Point) {
 				rwedit.MoveCursorToPoint(ta.EditCtx(), ev.Point, false)
@@ -89,10 +93,15 @@ func (ta *TextArea) handleInputEvent2(ev0 interface{}, p image.Point) event.Hand
 			ta.ENode.Cursor = event.PointerCursor
 		case event.ButtonLeft:
 			m := ev.Mods.ClearLocks()
-			if m.Is(event.ModCtrl) {
+			switch {
+			case m.Is(event.ModCtrl):
 				if ta.selAnnCurEv(ev.Point, TASelAnnTypeCurrent) {
 					return true
 				}
+			case m.Is(event.ModCtrl | event.ModShift):
+				if ta.selAnnCurEv(ev.Point, TASelAnnTypeBreakpoint) {
+					return true
+				}
 			}
 		case event.ButtonWheelUp:
 			m := ev.Mods.ClearLocks()
@@ -245,6 +254,8 @@ const (
 	TASelAnnTypeCurrentNext
 	TASelAnnTypePrint
 	TASelAnnTypePrintAllPrevious
+	TASelAnnTypeBreakpoint // toggle
+	TASelAnnTypeInspect
 )
 
 //----------
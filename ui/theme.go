@@ -20,6 +20,8 @@ var (
 	ScrollBarWidth        int = 0 // 0=based on a portion of the font size
 	TextAreaCommentsColor color.Color
 	TextAreaStringsColor  color.Color
+	TextAreaKeywordsColor color.Color
+	TextAreaNumbersColor  color.Color
 )
 
 const (
@@ -37,6 +39,12 @@ func userPalette() widget.Palette {
 	if TextAreaStringsColor != nil {
 		pal["text_colorize_string_fg"] = TextAreaStringsColor
 	}
+	if TextAreaKeywordsColor != nil {
+		pal["text_colorize_keyword_fg"] = TextAreaKeywordsColor
+	}
+	if TextAreaNumbersColor != nil {
+		pal["text_colorize_number_fg"] = TextAreaNumbersColor
+	}
 	return pal
 }
 
@@ -199,10 +207,12 @@ func rowSquarePalette() widget.Palette {
 		"rs_edited":              color.RGBA{0, 0, 255, 255},         // blue
 		"rs_disk_changes":        color.RGBA{255, 0, 0, 255},         // red
 		"rs_not_exist":           color.RGBA{255, 153, 0, 255},       // orange
+		"rs_read_only":           color.RGBA{128, 128, 128, 255},     // grey
 		"rs_duplicate":           color.RGBA{136, 136, 204, 255},     // blueish
 		"rs_duplicate_highlight": color.RGBA{255, 255, 0, 255},       // yellow
 		"rs_annotations":         color.RGBA{0xd3, 0x54, 0x00, 0xff}, // pumpkin
 		"rs_annotations_edited":  color.RGBA{255, 255, 0, 255},       // yellow
+		"rs_paused":              color.RGBA{255, 0, 255, 255},       // magenta
 	}
 	return pal
 }
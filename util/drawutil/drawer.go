@@ -58,3 +58,17 @@ type SyntaxHighlightComment struct {
 	S, E   string // {start,end} sequence
 	IsLine bool   // single line comment (end argument is ignored)
 }
+
+type SyntaxHighlightString struct {
+	S, E      string // {start,end} sequence (ex: "`", `"""`)
+	Escape    byte   // escapes the next byte (ex: '\\'), zero for none
+	Multiline bool   // a newline doesn't end the string
+	MaxLen    int    // max length between the sequences, longer is not a string (ex: to ignore apostrophes in text), zero for unlimited
+}
+
+// Per-language syntax rules (comments are defined separately).
+type SyntaxHighlightRules struct {
+	Strings  []*SyntaxHighlightString // checked in order (longer start sequences first)
+	Keywords []string
+	Numbers  bool
+}
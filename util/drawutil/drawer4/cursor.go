@@ -11,7 +11,9 @@ type Cursor struct {
 	d *Drawer
 }
 
-func (c *Cursor) Init() {}
+func (c *Cursor) Init() {
+	c.d.st.cursor.extraI = 0
+}
 
 func (c *Cursor) Iter() {
 	if c.d.Opt.Cursor.On {
@@ -25,7 +27,7 @@ func (c *Cursor) Iter() {
 }
 
 func (c *Cursor) iter2() {
-	if c.d.st.runeR.ri == c.d.opt.cursor.offset {
+	if c.d.st.runeR.ri == c.d.opt.cursor.offset || c.isExtra() {
 		c.draw()
 	}
 	// delayed draw
@@ -39,6 +41,18 @@ func (c *Cursor) End() {}
 
 //----------
 
+// Additional cursors (multi-cursor).
+func (c *Cursor) isExtra() bool {
+	ri := c.d.st.runeR.ri
+	u := c.d.Opt.Cursor.Extra
+	i := &c.d.st.cursor.extraI
+	for ; *i < len(u) && u[*i] < ri; *i++ {
+	}
+	return *i < len(u) && u[*i] == ri
+}
+
+//----------
+
 func (c *Cursor) draw() {
 	// pen bounds
 	penb := c.d.iters.runeR.penBoundsRect()
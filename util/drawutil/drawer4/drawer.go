@@ -76,7 +76,10 @@ type Drawer struct {
 			updated bool
 		}
 		syntaxH struct {
-			updated bool
+			updated       bool
+			checkpoints   []shCheckpoint
+			keywordsRules *drawutil.SyntaxHighlightRules
+			keywords      map[string]bool
 		}
 	}
 
@@ -94,6 +97,7 @@ type Drawer struct {
 			On         bool
 			Fg         color.Color
 			AddedWidth int
+			Extra      []int // additional cursors offsets (sorted)
 		}
 		Colorize struct {
 			Groups []*ColorizeGroup
@@ -126,6 +130,13 @@ type Drawer struct {
 			String struct {
 				Fg, Bg color.Color
 			}
+			Keyword struct {
+				Fg, Bg color.Color
+			}
+			Number struct {
+				Fg, Bg color.Color
+			}
+			Rules *drawutil.SyntaxHighlightRules // nil uses default rules (double/single quoted strings)
 			Group ColorizeGroup
 		}
 	}
@@ -179,7 +190,8 @@ type State struct {
 	}
 	bgFill struct{}
 	cursor struct {
-		delay *CursorDelay
+		delay  *CursorDelay
+		extraI int // index in opt.cursor.extra
 	}
 	pointOf struct {
 		index int
@@ -274,8 +286,14 @@ func (d *Drawer) limitedReaderPadSpace(offset int) iorw.ReaderAt {
 //----------
 
 func (d *Drawer) ContentChanged() {
+	d.ContentChangedAt(0)
+}
+
+// Same as ContentChanged, but keeps the syntax highlight state computed before the index (ex: the index of an edit).
+func (d *Drawer) ContentChangedAt(index int) {
 	d.opt.measure.updated = false
 	d.opt.syntaxH.updated = false
+	d.opt.syntaxH.checkpoints = shTrimCheckpoints(d.opt.syntaxH.checkpoints, index)
 	d.opt.wordH.updatedWord = false
 	d.opt.wordH.updatedOps = false
 	d.opt.parenthesisH.updated = false
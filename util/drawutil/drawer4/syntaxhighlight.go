@@ -1,11 +1,31 @@
 package drawer4
 
 import (
+	"bytes"
+	"image/color"
+
 	"github.com/jmigpin/editor/util/drawutil"
-	"github.com/jmigpin/editor/util/iout/iorw"
-	"github.com/jmigpin/editor/util/scanutil"
 )
 
+// The content is tokenized from the start, and the tokenizer state is saved at checkpoints. Drawing resumes from the nearest checkpoint before the visible offset, so multiline constructs (ex: block comments, Go backquotes) that started way above are colored correctly. Edits only invalidate the checkpoints after the edit index (ContentChangedAt).
+
+var shCheckpointInterval = 16 * 1024
+
+// Max bytes to tokenize in one update from the nearest checkpoint. Beyond this (ex: jumping to the end of huge content), tokenizing restarts near the visible offset and no checkpoints are saved.
+var shMaxTokenize = 16 * 1024 * 1024
+
+// Max bytes read ahead to decide on a token (ex: single line strings). Also the margin of checkpoints invalidated before an edit index.
+const shLookahead = 2500
+
+var shDefaultRules = &drawutil.SyntaxHighlightRules{
+	Strings: []*drawutil.SyntaxHighlightString{
+		{S: `"`, E: `"`, Escape: '\\'},
+		{S: "'", E: "'", Escape: '\\', MaxLen: 4},
+	},
+}
+
+//----------
+
 func updateSyntaxHighlightOps(d *Drawer) {
 	if !d.Opt.SyntaxHighlight.On {
 		d.Opt.SyntaxHighlight.Group.Ops = nil
@@ -17,102 +37,438 @@ func updateSyntaxHighlightOps(d *Drawer) {
 	}
 	d.opt.syntaxH.updated = true
 
-	pad := 2500
-	sh := &SyntaxHighlight{d: d}
-	d.Opt.SyntaxHighlight.Group.Ops = sh.do(pad)
+	o, n, _, _ := d.visibleLen()
+	d.Opt.SyntaxHighlight.Group.Ops = syntaxHighlightOps(d, o, o+n)
+}
+
+func syntaxHighlightOps(d *Drawer, min, max int) []*ColorizeOp {
+	sh := &d.opt.syntaxH
+	tk := newSHTokenizer(d)
+
+	// resume from the nearest checkpoint
+	cp := shCheckpoint{offset: d.reader.Min()}
+	for _, c := range sh.checkpoints {
+		if c.offset > min {
+			break
+		}
+		cp = c
+	}
+	save := true
+	if min-cp.offset > shMaxTokenize {
+		cp = shCheckpoint{offset: min - shLookahead}
+		save = false
+	}
+	tk.i, tk.st = cp.offset, cp.st
+	tk.tokStart = cp.offset
+
+	if save {
+		tk.saveCheckpoints(&sh.checkpoints)
+	}
+	tk.emitMin = min
+	tk.run(min)
+	tk.run(max)
+	tk.emitOpen()
+	return tk.ops
 }
 
 //----------
 
-type SyntaxHighlight struct {
-	d   *Drawer
-	sc  *scanutil.Scanner
-	ops []*ColorizeOp
+// Discards the checkpoints that could have read content at or after the index.
+func shTrimCheckpoints(cps []shCheckpoint, index int) []shCheckpoint {
+	k := len(cps)
+	for k > 0 && cps[k-1].offset+shLookahead >= index {
+		k--
+	}
+	return cps[:k]
 }
 
-func (sh *SyntaxHighlight) do(pad int) []*ColorizeOp {
-	// limit reading to be able to handle big content
-	o, n, _, _ := sh.d.visibleLen()
-	min, max := o, o+n
-	r := iorw.NewLimitedReaderAtPad(sh.d.reader, min, max, pad)
+//----------
 
-	sh.sc = scanutil.NewScanner(r)
-	sh.sc.Advance()
+type shCheckpoint struct {
+	offset int
+	st     shState
+}
 
-	for !sh.sc.Match.End() {
-		sh.normal(pad)
-	}
-	return sh.ops
+type shState struct {
+	mode shMode
+	k    int // index of the comment/string definition
+}
+
+type shMode int
+
+const (
+	shmNormal shMode = iota
+	shmLineComment
+	shmBlockComment
+	shmString // multiline strings only, single line strings are read at once
+)
+
+//----------
+
+type shTokenizer struct {
+	d        *Drawer
+	comments []*drawutil.SyntaxHighlightComment
+	rules    *drawutil.SyntaxHighlightRules
+	keywords map[string]bool
+
+	comment, str, keyword, number shColors
+
+	i        int
+	st       shState
+	tokStart int
+
+	buf     []byte
+	bufI    int // index of buf start
+	emitMin int // only tokens ending after this offset emit ops
+	ops     []*ColorizeOp
+
+	cps    *[]shCheckpoint
+	nextCp int
 }
-func (sh *SyntaxHighlight) normal(pad int) {
-	opt := &sh.d.Opt.SyntaxHighlight
-	switch {
-	case sh.comments():
-		// ok
-	case sh.sc.Match.Quote('"', '\\', true, pad) ||
-		sh.sc.Match.Quote('\'', '\\', true, 4):
 
-		// unable to support multiline quotes (Ex: Go backquotes) since the whole file is not parsed, just a section.
-		// Also, in the case of Go backquotes, probably only .go files should support them.
+type shColors struct {
+	fg, bg color.Color
+}
+
+func newSHTokenizer(d *Drawer) *shTokenizer {
+	opt := &d.Opt.SyntaxHighlight
+	tk := &shTokenizer{d: d, comments: opt.Comment.Defs, rules: opt.Rules}
+	tk.comment = shColors{opt.Comment.Fg, opt.Comment.Bg}
+	tk.str = shColors{opt.String.Fg, opt.String.Bg}
+	tk.keyword = shColors{opt.Keyword.Fg, opt.Keyword.Bg}
+	tk.number = shColors{opt.Number.Fg, opt.Number.Bg}
+	if tk.rules == nil {
+		tk.rules = shDefaultRules
+	}
 
-		op1 := &ColorizeOp{
-			Offset: sh.sc.Start,
-			Fg:     opt.String.Fg,
-			Bg:     opt.String.Bg,
+	// keywords map cached while the rules don't change
+	sh := &d.opt.syntaxH
+	if sh.keywordsRules != tk.rules {
+		sh.keywordsRules = tk.rules
+		sh.keywords = map[string]bool{}
+		for _, w := range tk.rules.Keywords {
+			sh.keywords[w] = true
 		}
-		op2 := &ColorizeOp{Offset: sh.sc.Pos}
-		sh.ops = append(sh.ops, op1, op2)
-		sh.sc.Advance()
-	default:
-		_ = sh.sc.ReadRune()
-		sh.sc.Advance()
 	}
+	tk.keywords = sh.keywords
+	return tk
 }
 
-func (sh *SyntaxHighlight) comments() bool {
-	opt := &sh.d.Opt.SyntaxHighlight
-	for _, c := range opt.Comment.Defs {
-		if sh.comment(c) {
-			return true
+//----------
+
+func (tk *shTokenizer) saveCheckpoints(cps *[]shCheckpoint) {
+	tk.cps = cps
+	tk.nextCp = tk.d.reader.Min() + shCheckpointInterval
+	if n := len(*cps); n > 0 {
+		tk.nextCp = (*cps)[n-1].offset + shCheckpointInterval
+	}
+}
+
+func (tk *shTokenizer) run(end int) {
+	if m := tk.d.reader.Max(); end > m {
+		end = m
+	}
+	for tk.i < end {
+		if tk.cps != nil && tk.i >= tk.nextCp {
+			*tk.cps = append(*tk.cps, shCheckpoint{tk.i, tk.st})
+			tk.nextCp = tk.i + shCheckpointInterval
+		}
+		if !tk.step() {
+			break // read error
 		}
 	}
+}
+
+func (tk *shTokenizer) step() bool {
+	switch tk.st.mode {
+	case shmNormal:
+		return tk.normal()
+	case shmLineComment:
+		return tk.lineComment()
+	case shmBlockComment:
+		return tk.blockComment()
+	case shmString:
+		return tk.multilineString()
+	}
 	return false
 }
-func (sh *SyntaxHighlight) comment(c *drawutil.SyntaxHighlightComment) bool {
-	// must match sequence start (line or multiline)
-	if !sh.sc.Match.Sequence(c.S) {
+
+//----------
+
+func (tk *shTokenizer) normal() bool {
+	// comments
+	for k, c := range tk.comments {
+		if tk.hasSeq(tk.i, c.S) {
+			mode := shmBlockComment
+			if c.IsLine {
+				mode = shmLineComment
+			}
+			tk.enter(mode, k, len(c.S))
+			return true
+		}
+	}
+
+	// strings
+	for k, s := range tk.rules.Strings {
+		if !tk.hasSeq(tk.i, s.S) {
+			continue
+		}
+		if s.Multiline && s.MaxLen == 0 {
+			tk.enter(shmString, k, len(s.S))
+			return true
+		}
+		if e, ok := tk.lookaheadString(s); ok {
+			tk.emit(tk.i, e, tk.str)
+			tk.i = e
+			return true
+		}
+	}
+
+	b := tk.bytes(tk.i, 1)
+	if len(b) == 0 {
 		return false
 	}
+	c := b[0]
 
-	opt := &sh.d.Opt.SyntaxHighlight
-	fg := opt.Comment.Fg
-	bg := opt.Comment.Bg
+	// numbers
+	if tk.rules.Numbers && c >= '0' && c <= '9' {
+		e := tk.scanNumber(tk.i)
+		tk.emitColor(tk.i, e, tk.number)
+		tk.i = e
+		return true
+	}
 
-	// single line comment
-	if c.IsLine {
-		op1 := &ColorizeOp{Offset: sh.sc.Start, Fg: fg, Bg: bg}
-		sh.sc.Match.ToNewlineOrEnd()
-		op2 := &ColorizeOp{Offset: sh.sc.Pos}
-		sh.ops = append(sh.ops, op1, op2)
-		sh.sc.Advance()
+	// identifiers (also consumed to not match numbers inside)
+	if shIsIdentStart(c) {
+		e := tk.i + 1
+		for ; ; e++ {
+			b := tk.bytes(e, 1)
+			if len(b) == 0 || !shIsIdent(b[0]) {
+				break
+			}
+		}
+		if len(tk.keywords) > 0 && e-tk.i <= 32 {
+			w := tk.bytes(tk.i, e-tk.i)
+			if tk.keywords[string(w)] {
+				tk.emitColor(tk.i, e, tk.keyword)
+			}
+		}
+		tk.i = e
 		return true
 	}
 
-	// multiline comment
-	// start
-	op := &ColorizeOp{Offset: sh.sc.Start, Fg: fg, Bg: bg}
-	sh.ops = append(sh.ops, op)
-	sh.sc.Advance()
-	// loop until it finds ending sequence
-	for !sh.sc.Match.End() {
-		if sh.sc.Match.Sequence(c.E) {
-			// end
-			op = &ColorizeOp{Offset: sh.sc.Pos}
-			sh.ops = append(sh.ops, op)
-			break
+	tk.i++
+	return true
+}
+
+func (tk *shTokenizer) enter(mode shMode, k, seqLen int) {
+	tk.st = shState{mode: mode, k: k}
+	tk.tokStart = tk.i
+	tk.i += seqLen
+}
+
+func (tk *shTokenizer) leave(end int, c shColors) {
+	tk.emit(tk.tokStart, end, c)
+	tk.st = shState{}
+	tk.i = end
+}
+
+//----------
+
+func (tk *shTokenizer) lineComment() bool {
+	b := tk.bytes(tk.i, 32*1024)
+	if len(b) == 0 {
+		return false
+	}
+	j := bytes.IndexByte(b, '\n')
+	if j < 0 {
+		tk.i += len(b)
+		if tk.i >= tk.d.reader.Max() {
+			tk.leave(tk.i, tk.comment)
 		}
-		_ = sh.sc.ReadRune()
+		return true
 	}
-	sh.sc.Advance()
+	tk.leave(tk.i+j, tk.comment)
 	return true
 }
+
+func (tk *shTokenizer) blockComment() bool {
+	c := tk.comments[tk.st.k]
+	b := tk.bytes(tk.i, 32*1024)
+	if len(b) == 0 {
+		return false
+	}
+	j := bytes.Index(b, []byte(c.E))
+	if j < 0 {
+		// keep the bytes that could be the start of the end sequence
+		if tk.i+len(b) >= tk.d.reader.Max() {
+			tk.i += len(b)
+			tk.leave(tk.i, tk.comment)
+			return true
+		}
+		adv := len(b) - (len(c.E) - 1)
+		if adv < 1 {
+			adv = 1
+		}
+		tk.i += adv
+		return true
+	}
+	tk.leave(tk.i+j+len(c.E), tk.comment)
+	return true
+}
+
+func (tk *shTokenizer) multilineString() bool {
+	s := tk.rules.Strings[tk.st.k]
+	b := tk.bytes(tk.i, 32*1024)
+	if len(b) == 0 {
+		return false
+	}
+	atMax := tk.i+len(b) >= tk.d.reader.Max()
+	e, ok, j := shStringEnd(b, s, atMax)
+	if ok {
+		tk.leave(tk.i+e, tk.str)
+		return true
+	}
+	tk.i += j
+	if atMax && j == len(b) {
+		tk.leave(tk.i, tk.str)
+	}
+	return true
+}
+
+// Reads a string that is decided at once (single line, or with a max length).
+func (tk *shTokenizer) lookaheadString(s *drawutil.SyntaxHighlightString) (int, bool) {
+	max := shLookahead
+	if s.MaxLen > 0 && s.MaxLen < max {
+		max = s.MaxLen
+	}
+	start := tk.i + len(s.S)
+	b := tk.bytes(start, max+len(s.E))
+	atMax := start+len(b) >= tk.d.reader.Max()
+	e, ok, _ := shStringEnd(b, s, atMax)
+	if !ok || e-len(s.E) > max {
+		return 0, false
+	}
+	return start + e, true
+}
+
+// Returns the index after the end sequence if found. Otherwise, returns how many bytes can be skipped (the remaining could be an incomplete escape or end sequence).
+func shStringEnd(b []byte, s *drawutil.SyntaxHighlightString, atMax bool) (int, bool, int) {
+	for j := 0; j < len(b); j++ {
+		if s.Escape != 0 && b[j] == s.Escape {
+			if j+1 >= len(b) && !atMax {
+				return 0, false, j
+			}
+			j++
+			continue
+		}
+		if b[j] == '\n' && !s.Multiline {
+			return 0, false, j
+		}
+		if bytes.HasPrefix(b[j:], []byte(s.E)) {
+			return j + len(s.E), true, 0
+		}
+		if len(b)-j < len(s.E) && !atMax {
+			return 0, false, j
+		}
+	}
+	return 0, false, len(b)
+}
+
+func (tk *shTokenizer) scanNumber(i int) int {
+	prev := byte(0)
+	for ; ; i++ {
+		b := tk.bytes(i, 1)
+		if len(b) == 0 {
+			return i
+		}
+		c := b[0]
+		ok := shIsIdent(c) || c == '.' ||
+			((c == '+' || c == '-') && (prev == 'e' || prev == 'E' || prev == 'p' || prev == 'P'))
+		if !ok {
+			return i
+		}
+		prev = c
+	}
+}
+
+//----------
+
+func (tk *shTokenizer) hasSeq(i int, s string) bool {
+	if s == "" {
+		return false
+	}
+	b := tk.bytes(i, len(s))
+	return len(b) == len(s) && string(b) == s
+}
+
+// Returns up to n bytes at index i (less at the end of the content, or on error).
+func (tk *shTokenizer) bytes(i, n int) []byte {
+	if i >= tk.bufI && i+n <= tk.bufI+len(tk.buf) {
+		return tk.buf[i-tk.bufI : i-tk.bufI+n]
+	}
+	r := tk.d.reader
+	m := n
+	if m < 32*1024 {
+		m = 32 * 1024
+	}
+	if i < r.Min() {
+		i = r.Min()
+	}
+	if i+m > r.Max() {
+		m = r.Max() - i
+	}
+	if m <= 0 {
+		return nil
+	}
+	b, err := r.ReadFastAt(i, m)
+	if err != nil {
+		return nil
+	}
+	tk.buf, tk.bufI = b, i
+	if n > len(b) {
+		n = len(b)
+	}
+	return b[:n]
+}
+
+//----------
+
+func (tk *shTokenizer) emitColor(a, b int, c shColors) {
+	if c.fg == nil && c.bg == nil {
+		return
+	}
+	tk.emit(a, b, c)
+}
+
+func (tk *shTokenizer) emit(a, b int, c shColors) {
+	if b <= tk.emitMin {
+		return
+	}
+	op1 := &ColorizeOp{Offset: a, Fg: c.fg, Bg: c.bg}
+	op2 := &ColorizeOp{Offset: b}
+	tk.ops = append(tk.ops, op1, op2)
+}
+
+// Emits the start of a token that continues after the visible range.
+func (tk *shTokenizer) emitOpen() {
+	c := tk.comment
+	switch tk.st.mode {
+	case shmNormal:
+		return
+	case shmString:
+		c = tk.str
+	}
+	tk.ops = append(tk.ops, &ColorizeOp{Offset: tk.tokStart, Fg: c.fg, Bg: c.bg})
+}
+
+//----------
+
+func shIsIdentStart(c byte) bool {
+	return c == '_' || c >= 0x80 ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func shIsIdent(c byte) bool {
+	return shIsIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,155 @@
+package drawer4
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+
+	"github.com/jmigpin/editor/util/drawutil"
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+var (
+	shTestComment = color.RGBA{1, 0, 0, 255}
+	shTestString  = color.RGBA{2, 0, 0, 255}
+	shTestKeyword = color.RGBA{3, 0, 0, 255}
+	shTestNumber  = color.RGBA{4, 0, 0, 255}
+)
+
+var shTestGoRules = &drawutil.SyntaxHighlightRules{
+	Strings: []*drawutil.SyntaxHighlightString{
+		{S: `"`, E: `"`, Escape: '\\'},
+		{S: "`", E: "`", Multiline: true},
+	},
+	Keywords: []string{"func", "return"},
+	Numbers:  true,
+}
+
+func newSHTestDrawer(s string) *Drawer {
+	d := New()
+	d.SetReader(iorw.NewBytesReadWriterAt([]byte(s)))
+	opt := &d.Opt.SyntaxHighlight
+	opt.On = true
+	opt.Comment.Defs = []*drawutil.SyntaxHighlightComment{
+		{S: "//", IsLine: true},
+		{S: "/*", E: "*/"},
+	}
+	opt.Comment.Fg = shTestComment
+	opt.String.Fg = shTestString
+	opt.Keyword.Fg = shTestKeyword
+	opt.Number.Fg = shTestNumber
+	opt.Rules = shTestGoRules
+	return d
+}
+
+func shTestColors(d *Drawer, min, max int) string {
+	ops := syntaxHighlightOps(d, min, max)
+	sb := &strings.Builder{}
+	for i := min; i < max; i++ {
+		var fg color.Color
+		for _, op := range ops {
+			if op.Offset <= i {
+				fg = op.Fg
+			}
+		}
+		switch fg {
+		case shTestComment:
+			sb.WriteByte('c')
+		case shTestString:
+			sb.WriteByte('s')
+		case shTestKeyword:
+			sb.WriteByte('k')
+		case shTestNumber:
+			sb.WriteByte('n')
+		default:
+			sb.WriteByte('.')
+		}
+	}
+	return sb.String()
+}
+
+func shTestSetInterval(t *testing.T, v int) {
+	t.Helper()
+	old := shCheckpointInterval
+	shCheckpointInterval = v
+	t.Cleanup(func() { shCheckpointInterval = old })
+}
+
+//----------
+
+func TestSyntaxHighlight1(t *testing.T) {
+	s := "func f() int { return 12 + a1 } // c"
+	d := newSHTestDrawer(s)
+	res := shTestColors(d, 0, len(s))
+	exp := "kkkk...........kkkkkk.nn........cccc"
+	if res != exp {
+		t.Fatalf("\n%v\n%v", res, exp)
+	}
+}
+
+func TestSyntaxHighlight2MultilineBeforeVisible(t *testing.T) {
+	shTestSetInterval(t, 4)
+
+	// visible range starts inside a backquote string and ends inside a block comment
+	s := "a := `1\n\"2\n/*3` \"b\" /*\n`4`\n*/ 5"
+	d := newSHTestDrawer(s)
+	min := strings.Index(s, "/*3")
+	max := strings.Index(s, "`4`") + 1
+	res := shTestColors(d, min, max)
+	exp := "ssss.sss.cccc"
+	if res != exp {
+		t.Fatalf("\n%v\n%v", res, exp)
+	}
+	if len(d.opt.syntaxH.checkpoints) == 0 {
+		t.Fatal("expecting checkpoints")
+	}
+
+	// resume from the checkpoints
+	res = shTestColors(d, min, max)
+	if res != exp {
+		t.Fatalf("\n%v\n%v", res, exp)
+	}
+}
+
+func TestSyntaxHighlight3ContentChangedAt(t *testing.T) {
+	shTestSetInterval(t, 4)
+
+	s := "0123456789 /* a\nb */ c"
+	d := newSHTestDrawer(s)
+	_ = shTestColors(d, 0, len(s))
+	n := len(d.opt.syntaxH.checkpoints)
+	if n == 0 {
+		t.Fatal("expecting checkpoints")
+	}
+
+	// remove the comment start: following checkpoints are discarded
+	rw := d.reader.(iorw.ReadWriterAt)
+	i := strings.Index(s, "/*")
+	if err := rw.OverwriteAt(i, 2, []byte("xx")); err != nil {
+		t.Fatal(err)
+	}
+	d.ContentChangedAt(i)
+	for _, cp := range d.opt.syntaxH.checkpoints {
+		if cp.offset+shLookahead >= i {
+			t.Fatalf("checkpoint not discarded: %v", cp.offset)
+		}
+	}
+
+	min := strings.Index(s, "b */")
+	res := shTestColors(d, min, len(s))
+	exp := "......"
+	if res != exp {
+		t.Fatalf("\n%v\n%v", res, exp)
+	}
+}
+
+func TestSyntaxHighlight4DefaultRules(t *testing.T) {
+	s := "it's \"a\\\"b\" 'c' \"d\n"
+	d := newSHTestDrawer(s)
+	d.Opt.SyntaxHighlight.Rules = nil
+	res := shTestColors(d, 0, len(s))
+	exp := ".....ssssss.sss...."
+	if res != exp {
+		t.Fatalf("\n%v\n%v", res, exp)
+	}
+}
@@ -3,6 +3,9 @@ package iorw
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"math/rand"
+	"strings"
 	"testing"
 	"unicode"
 )
@@ -42,6 +45,150 @@ func TestRW1(t *testing.T) {
 
 //----------
 
+func TestPieceTable1(t *testing.T) {
+	s := "0123"
+	rw := NewPieceTableReadWriterAt([]byte(s))
+	type ow struct {
+		i int
+		l int
+		s string
+		e string // expected
+	}
+
+	var tests = []*ow{
+		{1, 0, "ab", "0ab123"},
+		{3, 0, "c", "0abc123"},
+		{5, 0, "ab", "0abc1ab23"},
+		{1, 2, "", "0c1ab23"},
+		{3, 2, "", "0c123"},
+		{1, 0, "ab", "0abc123"},
+		{0, 7, "abcde", "abcde"},
+		{0, 5, "abc", "abc"},
+		{0, 1, "abcd", "abcdbc"},
+		{3, 2, "000", "abc000c"},
+		{7, 0, "f", "abc000cf"},
+		{8, 0, "g", "abc000cfg"},
+	}
+
+	for _, w := range tests {
+		if err := rw.OverwriteAt(w.i, w.l, []byte(w.s)); err != nil {
+			t.Fatal(err)
+		}
+		b, err := ReadFastFull(rw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != w.e {
+			t.Fatal(string(b) + " != " + w.e)
+		}
+	}
+
+	if err := rw.OverwriteAt(5, 5, nil); err == nil {
+		t.Fatal("expecting error")
+	}
+}
+
+func TestPieceTableRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	b := bytes.Repeat([]byte("0123456789"), 100)
+	rw1 := NewBytesReadWriterAt(MakeBytesCopy(b))
+	rw2 := NewPieceTableReadWriterAt(MakeBytesCopy(b))
+	for k := 0; k < 2000; k++ {
+		i, del, p := randomEdit(r, rw1.Max())
+		if err := rw1.OverwriteAt(i, del, p); err != nil {
+			t.Fatal(err)
+		}
+		if err := rw2.OverwriteAt(i, del, p); err != nil {
+			t.Fatal(err)
+		}
+
+		// partial read (can span pieces)
+		i = r.Intn(rw1.Max() + 1)
+		n := r.Intn(50)
+		b1, err1 := rw1.ReadFastAt(i, n)
+		b2, err2 := rw2.ReadFastAt(i, n)
+		if !bytes.Equal(b1, b2) || (err1 == nil) != (err2 == nil) {
+			t.Fatalf("%v: %q != %q (%v, %v)", k, b1, b2, err1, err2)
+		}
+	}
+	b1, _ := ReadFastFull(rw1)
+	b2, _ := ReadFastFull(rw2)
+	if !bytes.Equal(b1, b2) {
+		t.Fatal("content differs")
+	}
+}
+
+func randomEdit(r *rand.Rand, max int) (int, int, []byte) {
+	i := r.Intn(max + 1)
+	del := 0
+	if r.Intn(3) == 0 {
+		del = r.Intn(max - i + 1)
+		if del > 20 {
+			del = 20
+		}
+	}
+	p := []byte("abc"[:r.Intn(4)])
+	return i, del, p
+}
+
+func TestChunkedReaderAt(t *testing.T) {
+	s := strings.Repeat("0123456789", 10)
+	r := NewChunkedReaderAt(strings.NewReader(s), len(s))
+	r.chunkSize = 7
+	r.maxChunks = 3
+	rd := NewStringReaderAt(s)
+	for i := 0; i <= len(s); i++ {
+		for n := 0; n < 20; n++ {
+			b1, err1 := rd.ReadFastAt(i, n)
+			b2, err2 := r.ReadFastAt(i, n)
+			if !bytes.Equal(b1, b2) || err1 != err2 {
+				t.Fatalf("%v,%v: %q != %q (%v, %v)", i, n, b1, b2, err1, err2)
+			}
+		}
+	}
+	if len(r.chunks) > 3 {
+		t.Fatal(len(r.chunks))
+	}
+	i, err := Index(r, 0, []byte("890"), false)
+	if err != nil || i != 8 {
+		t.Fatal(i, err)
+	}
+
+	rw := NewReadOnlyReadWriterAt(r)
+	if err := rw.OverwriteAt(0, 1, nil); err != ErrReadOnly {
+		t.Fatal(err)
+	}
+}
+
+//----------
+
+func BenchmarkBytesRandomEdits(b *testing.B) {
+	rw := NewBytesReadWriterAt(bytes.Repeat([]byte("0123456789"), 1024*1024))
+	benchmarkRandomEdits(b, rw)
+}
+
+func BenchmarkPieceTableRandomEdits(b *testing.B) {
+	rw := NewPieceTableReadWriterAt(bytes.Repeat([]byte("0123456789"), 1024*1024))
+	benchmarkRandomEdits(b, rw)
+}
+
+func benchmarkRandomEdits(b *testing.B, rw ReadWriterAt) {
+	r := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for k := 0; k < b.N; k++ {
+		i, del, p := randomEdit(r, rw.Max())
+		if err := rw.OverwriteAt(i, del, p); err != nil {
+			b.Fatal(err)
+		}
+		// drawer-like read near the edit
+		if _, err := rw.ReadFastAt(i, 100); err != nil && i != rw.Max() {
+			b.Fatal(err)
+		}
+	}
+}
+
+//----------
+
 func TestIndex1(t *testing.T) {
 	s := "0123456789"
 	for i := 0; i < 32*1024; i++ {
@@ -70,6 +217,59 @@ func TestIndex2(t *testing.T) {
 	}
 }
 
+func TestSearcher1(t *testing.T) {
+	s := strings.Repeat("0123456789", 10*1024) + "ba cd aé cd"
+	rw := NewStringReaderAt(s)
+	opt := SearchOpt{Regexp: true, WholeWord: true}
+	sr, err := NewSearcher(`A(.)\s*(cd)`, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := sr.Index(context.Background(), rw, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil || m[0] != 102400+6 || m[1] != len(s) {
+		t.Fatalf("%v", m)
+	}
+	b, err := sr.Expand(rw, "[$2$1]", m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "[cdé]" {
+		t.Fatal(string(b))
+	}
+}
+
+func TestSearcher2Anchors(t *testing.T) {
+	rw := NewStringReaderAt("ab ab\nab")
+	type entry struct {
+		pat string
+		i   int
+		exp []int
+	}
+	entries := []entry{
+		{`^ab`, 1, []int{6, 8}},
+		{`\Aab`, 1, nil},
+		{`\Aab`, 0, []int{0, 2}},
+		{`\bb`, 1, nil},
+		{`\ba(b)`, 1, []int{3, 5, 4, 5}},
+	}
+	for _, e := range entries {
+		sr, err := NewSearcher(e.pat, SearchOpt{Regexp: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := sr.Index(context.Background(), rw, e.i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(m) != fmt.Sprint(e.exp) {
+			t.Fatalf("%q at %v: expecting %v, got %v", e.pat, e.i, e.exp, m)
+		}
+	}
+}
+
 func TestLastIndex1(t *testing.T) {
 	s := "a\n0123\nb"
 	rw := NewStringReaderAt(s)
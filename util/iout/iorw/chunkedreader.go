@@ -0,0 +1,154 @@
+package iorw
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Read-only ReaderAt that loads the content in chunks on demand (useful for huge files). Keeps a limited number of chunks in memory.
+type ChunkedReaderAt struct {
+	r         io.ReaderAt
+	size      int
+	chunkSize int
+	maxChunks int
+
+	mu     sync.Mutex
+	chunks map[int]*crChunk // [chunk index]
+	clock  int              // access counter, used to evict chunks
+}
+
+type crChunk struct {
+	b    []byte
+	used int // clock at last use
+}
+
+func NewChunkedReaderAt(r io.ReaderAt, size int) *ChunkedReaderAt {
+	return &ChunkedReaderAt{
+		r:         r,
+		size:      size,
+		chunkSize: 64 * 1024,
+		maxChunks: 256,
+		chunks:    map[int]*crChunk{},
+	}
+}
+
+//----------
+
+// Reads that span more then this size fail, instead of allocating memory for a big part of the content.
+const ChunkedReaderAtMaxRead = 32 * 1024 * 1024
+
+// Implement ReaderAt
+func (r *ChunkedReaderAt) ReadFastAt(i, n int) ([]byte, error) {
+	if i < 0 {
+		return nil, fmt.Errorf("bad index: %v<0", i)
+	}
+	if i > r.size {
+		return nil, fmt.Errorf("bad index: %v>%v", i, r.size)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("bad arg: %v<0", n)
+	}
+	if i == r.size {
+		return nil, io.EOF
+	}
+	if i+n > r.size {
+		n = r.size - i
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// inside one chunk (common case)
+	ci := i / r.chunkSize
+	if (i+n-1)/r.chunkSize == ci {
+		c, err := r.chunk(ci)
+		if err != nil {
+			return nil, err
+		}
+		j := i - ci*r.chunkSize
+		return c.b[j : j+n], nil
+	}
+
+	if n > ChunkedReaderAtMaxRead {
+		return nil, fmt.Errorf("read too large: %v bytes (max=%v)", n, ChunkedReaderAtMaxRead)
+	}
+
+	// copy from the chunks
+	buf := make([]byte, n)
+	for c := 0; c < n; {
+		k := i + c
+		ch, err := r.chunk(k / r.chunkSize)
+		if err != nil {
+			return nil, err
+		}
+		j := k - (k/r.chunkSize)*r.chunkSize
+		c += copy(buf[c:], ch.b[j:])
+	}
+	return buf, nil
+}
+
+// Implement ReaderAt
+func (r *ChunkedReaderAt) Min() int { return 0 }
+
+// Implement ReaderAt
+func (r *ChunkedReaderAt) Max() int { return r.size }
+
+//----------
+
+func (r *ChunkedReaderAt) chunk(ci int) (*crChunk, error) {
+	r.clock++
+	if c, ok := r.chunks[ci]; ok {
+		c.used = r.clock
+		return c, nil
+	}
+
+	// read chunk
+	start := ci * r.chunkSize
+	n := r.chunkSize
+	if start+n > r.size {
+		n = r.size - start
+	}
+	b := make([]byte, n)
+	k, err := r.r.ReadAt(b, int64(start))
+	if err != nil && !(err == io.EOF && k == n) {
+		return nil, err
+	}
+
+	// evict least recently used
+	if len(r.chunks) >= r.maxChunks {
+		min, minCi := -1, 0
+		for ci2, c := range r.chunks {
+			if min < 0 || c.used < min {
+				min, minCi = c.used, ci2
+			}
+		}
+		delete(r.chunks, minCi)
+	}
+
+	c := &crChunk{b: b, used: r.clock}
+	r.chunks[ci] = c
+	return c, nil
+}
+
+//----------
+
+var ErrReadOnly = errors.New("read-only")
+
+// ReadWriterAt that refuses all writes.
+type ReadOnlyReadWriterAt struct {
+	ReaderAt
+}
+
+func NewReadOnlyReadWriterAt(r ReaderAt) *ReadOnlyReadWriterAt {
+	return &ReadOnlyReadWriterAt{r}
+}
+
+// Implement WriterAt
+func (rw *ReadOnlyReadWriterAt) OverwriteAt(i, del int, p []byte) error {
+	return ErrReadOnly
+}
@@ -0,0 +1,222 @@
+package iorw
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Piece table: the content is a list of pieces that point to immutable buffers (the original content, and an append-only buffer with the inserted data). Edits only split/insert pieces, avoiding to shift the content tail (useful for large contents).
+// Slices returned by ReadFastAt are never modified by later writes.
+type PieceTableReadWriterAt struct {
+	mu     sync.Mutex // reads can merge pieces
+	pieces []*ptPiece
+	size   int
+	add    []byte // append-only
+	tail   int    // index of the piece that ends at the end of the add buffer (extended by consecutive inserts), -1 if none
+}
+
+type ptPiece struct {
+	off int // content offset
+	b   []byte
+}
+
+func (p *ptPiece) end() int { return p.off + len(p.b) }
+
+// The slice b is used as the original content and should not be modified afterwards.
+func NewPieceTableReadWriterAt(b []byte) *PieceTableReadWriterAt {
+	rw := &PieceTableReadWriterAt{tail: -1}
+	if len(b) > 0 {
+		rw.pieces = []*ptPiece{{off: 0, b: b}}
+		rw.size = len(b)
+	}
+	return rw
+}
+
+//----------
+
+// Implement ReaderAt
+func (rw *PieceTableReadWriterAt) ReadFastAt(i, n int) ([]byte, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if i < 0 {
+		return nil, fmt.Errorf("bad index: %v<0", i)
+	}
+	if i > rw.size {
+		return nil, fmt.Errorf("bad index: %v>%v", i, rw.size)
+	}
+
+	// before "i==len" to allow reading an empty buffer (ex: readfull("") without err)
+	if n == 0 {
+		return nil, nil
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("bad arg: %v<0", n)
+	}
+
+	if i == rw.size {
+		return nil, io.EOF
+	}
+
+	// i>=0 && i<len && n>=0 -> n>=1
+	if i+n > rw.size {
+		n = rw.size - i
+	}
+
+	// inside one piece (common case)
+	k := rw.pieceIndex(i)
+	p := rw.pieces[k]
+	if i+n <= p.end() {
+		j := i - p.off
+		return p.b[j : j+n], nil
+	}
+
+	// full content read (ex: save): merge all pieces to keep later reads cheap
+	if n == rw.size {
+		rw.merge()
+		return rw.pieces[0].b, nil
+	}
+
+	// copy from the pieces
+	buf := make([]byte, n)
+	c := 0
+	for ; c < n; k++ {
+		p := rw.pieces[k]
+		j := i + c - p.off
+		c += copy(buf[c:], p.b[j:])
+	}
+	return buf, nil
+}
+
+// Implement ReaderAt
+func (rw *PieceTableReadWriterAt) Min() int { return 0 }
+
+// Implement ReaderAt
+func (rw *PieceTableReadWriterAt) Max() int {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.size
+}
+
+//----------
+
+// Implement WriterAt
+func (rw *PieceTableReadWriterAt) OverwriteAt(i, del int, p []byte) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	if i < 0 || i > rw.size {
+		return fmt.Errorf("iorw.OverwriteAt: bad index: %v", i)
+	}
+	if del < 0 {
+		return fmt.Errorf("iorw.OverwriteAt: bad del: %v<0", del)
+	}
+	if i+del > rw.size {
+		return fmt.Errorf("iorw.OverwriteAt: del %v>%v", i+del, rw.size)
+	}
+	if del == 0 && len(p) == 0 {
+		return nil
+	}
+
+	// replacing all the content: release the buffers
+	if i == 0 && del == rw.size {
+		rw.pieces = nil
+		rw.size = 0
+		rw.add = nil
+		rw.tail = -1
+		del = 0
+	}
+
+	// consecutive inserts (ex: typing) extend the last inserted piece
+	if del == 0 && rw.tail >= 0 && rw.pieces[rw.tail].end() == i {
+		tp := rw.pieces[rw.tail]
+		start := len(rw.add) - len(tp.b)
+		rw.add = append(rw.add, p...)
+		tp.b = rw.add[start:len(rw.add):len(rw.add)]
+		rw.size += len(p)
+		rw.updateOffsets(rw.tail + 1)
+		return nil
+	}
+
+	// delete
+	a := rw.split(i)
+	b := rw.split(i + del)
+	rw.pieces = append(rw.pieces[:a], rw.pieces[b:]...)
+	rw.size -= del
+	rw.tail = -1
+
+	// insert
+	if len(p) > 0 {
+		start := len(rw.add)
+		rw.add = append(rw.add, p...)
+		np := &ptPiece{b: rw.add[start:len(rw.add):len(rw.add)]}
+		rw.pieces = append(rw.pieces, nil)
+		copy(rw.pieces[a+1:], rw.pieces[a:])
+		rw.pieces[a] = np
+		rw.size += len(p)
+		rw.tail = a
+	}
+
+	rw.updateOffsets(a)
+	return nil
+}
+
+//----------
+
+// Index of the piece that contains the index i (i<size).
+func (rw *PieceTableReadWriterAt) pieceIndex(i int) int {
+	return sort.Search(len(rw.pieces), func(k int) bool {
+		return rw.pieces[k].end() > i
+	})
+}
+
+// Splits the piece that contains the index i. Returns the index of the piece that starts at i (len(pieces) if i==size).
+func (rw *PieceTableReadWriterAt) split(i int) int {
+	if i == rw.size {
+		return len(rw.pieces)
+	}
+	k := rw.pieceIndex(i)
+	p := rw.pieces[k]
+	if p.off == i {
+		return k
+	}
+	j := i - p.off
+	p2 := &ptPiece{off: i, b: p.b[j:]}
+	p.b = p.b[:j:j]
+	rw.pieces = append(rw.pieces, nil)
+	copy(rw.pieces[k+2:], rw.pieces[k+1:])
+	rw.pieces[k+1] = p2
+	if rw.tail > k {
+		rw.tail++
+	} else if rw.tail == k {
+		rw.tail = k + 1 // the right side keeps ending at the add buffer end
+	}
+	return k + 1
+}
+
+func (rw *PieceTableReadWriterAt) updateOffsets(k int) {
+	off := 0
+	if k > 0 {
+		off = rw.pieces[k-1].end()
+	}
+	for ; k < len(rw.pieces); k++ {
+		rw.pieces[k].off = off
+		off += len(rw.pieces[k].b)
+	}
+}
+
+// Merges all pieces into one new buffer.
+func (rw *PieceTableReadWriterAt) merge() {
+	if len(rw.pieces) <= 1 {
+		return
+	}
+	buf := make([]byte, 0, rw.size)
+	for _, p := range rw.pieces {
+		buf = append(buf, p.b...)
+	}
+	rw.pieces = []*ptPiece{{off: 0, b: buf}}
+	rw.add = nil
+	rw.tail = -1
+}
@@ -183,7 +183,7 @@ func TestAll1(t *testing.T) {
 				est: state{s: "01234\nabc", si: 6, ci: 8, son: true},
 				f: func(ctx *Ctx) error {
 					cctx := context.Background()
-					_, err := Find(cctx, ctx, "ab")
+					_, err := Find(cctx, ctx, "ab", iorw.SearchOpt{})
 					return err
 				},
 			})
@@ -194,7 +194,7 @@ func TestAll1(t *testing.T) {
 				est: state{s: "01234\nabc", si: 6, ci: 8, son: true},
 				f: func(ctx *Ctx) error {
 					cctx := context.Background()
-					_, err := Find(cctx, ctx, "ab")
+					_, err := Find(cctx, ctx, "ab", iorw.SearchOpt{})
 					return err
 				},
 			})
@@ -303,7 +303,112 @@ func TestAll1(t *testing.T) {
 				st:  state{s: "0123401234", ci: 4},
 				est: state{s: "0404", ci: 1},
 				f: func(ctx *Ctx) error {
-					_, err := Replace(ctx, "123", "")
+					_, err := Replace(context.Background(), ctx, "123", "", iorw.SearchOpt{})
+					return err
+				},
+			})
+		},
+		func() {
+			testEntry(&test{
+				st:  state{s: "a1 b22 c333", ci: 0},
+				est: state{s: "1=a 22=b 333=c", ci: 0},
+				f: func(ctx *Ctx) error {
+					opt := iorw.SearchOpt{Regexp: true}
+					_, err := Replace(context.Background(), ctx, `([a-z])(\d+)`, "$2=$1", opt)
+					return err
+				},
+			})
+		},
+		func() {
+			testEntry(&test{
+				st:  state{s: "Foo foo", ci: 0},
+				est: state{s: "Foo x", ci: 0},
+				f: func(ctx *Ctx) error {
+					opt := iorw.SearchOpt{CaseSensitive: true}
+					_, err := Replace(context.Background(), ctx, "foo", "x", opt)
+					return err
+				},
+			})
+		},
+		func() {
+			testEntry(&test{
+				st:  state{s: "Ab ab abc", ci: 0},
+				est: state{s: "Ab x abc", ci: 0},
+				f: func(ctx *Ctx) error {
+					opt := iorw.SearchOpt{WholeWord: true, CaseSensitive: true}
+					_, err := Replace(context.Background(), ctx, "ab", "x", opt)
+					return err
+				},
+			})
+		},
+		func() {
+			testEntry(&test{
+				st:  state{s: "abc ab ABx AB", ci: 1},
+				est: state{s: "abc ab ABx AB", si: 11, ci: 13, son: true},
+				f: func(ctx *Ctx) error {
+					cctx := context.Background()
+					if _, err := Find(cctx, ctx, "ab", iorw.SearchOpt{WholeWord: true}); err != nil {
+						return err
+					}
+					_, err := Find(cctx, ctx, "ab", iorw.SearchOpt{WholeWord: true})
+					return err
+				},
+			})
+		},
+		func() {
+			testEntry(&test{
+				st:  state{s: "x1\ny22\n", ci: 3},
+				est: state{s: "x1\ny22\n", si: 0, ci: 2, son: true},
+				f: func(ctx *Ctx) error {
+					// wraps around
+					cctx := context.Background()
+					_, err := Find(cctx, ctx, `^X\d$`, iorw.SearchOpt{Regexp: true})
+					return err
+				},
+			})
+		},
+		func() {
+			testEntry(&test{
+				st:  state{s: "abab\nb", ci: 1},
+				est: state{s: "abab\nb", si: 5, ci: 6, son: true},
+				f: func(ctx *Ctx) error {
+					// anchor sees the text before the cursor
+					cctx := context.Background()
+					_, err := Find(cctx, ctx, `^b`, iorw.SearchOpt{Regexp: true})
+					return err
+				},
+			})
+		},
+		func() {
+			testEntry(&test{
+				st:  state{s: "abab\n", ci: 0},
+				est: state{s: "Xab\n", ci: 0},
+				f: func(ctx *Ctx) error {
+					opt := iorw.SearchOpt{Regexp: true}
+					_, err := Replace(context.Background(), ctx, `^ab`, "X", opt)
+					return err
+				},
+			})
+		},
+		func() {
+			testEntry(&test{
+				st:  state{s: "aaa", ci: 0},
+				est: state{s: "Xaa", ci: 0},
+				f: func(ctx *Ctx) error {
+					opt := iorw.SearchOpt{Regexp: true}
+					_, err := Replace(context.Background(), ctx, `\ba`, "X", opt)
+					return err
+				},
+			})
+		},
+		func() {
+			testEntry(&test{
+				st:  state{s: "aaa aa", si: 1, ci: 6, son: true},
+				est: state{s: "aaa Xa", si: 1, ci: 6, son: true},
+				f: func(ctx *Ctx) error {
+					// selection starting inside a word
+					opt := iorw.SearchOpt{Regexp: true}
+					_, err := Replace(context.Background(), ctx, `\ba`, "X", opt)
 					return err
 				},
 			})
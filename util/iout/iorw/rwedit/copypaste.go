@@ -2,12 +2,13 @@ package rwedit
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/jmigpin/editor/util/uiutil/event"
 )
 
 func Copy(ctx *Ctx) error {
-	if b, ok := ctx.Selection(); ok {
+	if b, ok := ctx.AllSelections(); ok {
 		ctx.Fns.SetClipboardData(event.CIClipboard, string(b))
 	}
 	return nil
@@ -19,7 +20,20 @@ func Paste(ctx *Ctx, ci event.ClipboardIndex) {
 			ctx.Fns.Error(fmt.Errorf("rwedit.paste: %w", err))
 			return
 		}
-		if err := InsertString(ctx, s); err != nil {
+		// one line per cursor if the number of lines matches (ex: copied from multiple cursors)
+		lines := strings.Split(s, "\n")
+		if len(lines) != ctx.MC.Len()+1 || len(lines) == 1 {
+			lines = nil
+		}
+		k := len(lines)
+		err = ForEachCursor(ctx, func() error {
+			if lines != nil {
+				k-- // cursors are visited from the end
+				return InsertString(ctx, lines[k])
+			}
+			return InsertString(ctx, s)
+		})
+		if err != nil {
 			ctx.Fns.Error(fmt.Errorf("rwedit.paste: insertstring: %w", err))
 		}
 	})
@@ -13,11 +13,12 @@ import (
 type Ctx struct {
 	RW  iorw.ReadWriterAt
 	C   Cursor
+	MC  *MultiCursor // additional cursors
 	Fns CtxFns
 }
 
 func NewCtx() *Ctx {
-	ctx := &Ctx{C: &SimpleCursor{}, Fns: EmptyCtxFns()}
+	ctx := &Ctx{C: &SimpleCursor{}, MC: &MultiCursor{}, Fns: EmptyCtxFns()}
 	return ctx
 }
 
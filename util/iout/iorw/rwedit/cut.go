@@ -5,21 +5,22 @@ import (
 )
 
 func Cut(ctx *Ctx) error {
-	a, b, ok := ctx.C.SelectionIndexes()
+	s, ok := ctx.AllSelections()
 	if !ok {
 		return nil
 	}
-
-	s, err := ctx.RW.ReadFastAt(a, b-a)
-	if err != nil {
-		return err
-	}
 	ctx.Fns.SetClipboardData(event.CIClipboard, string(s))
 
-	if err := ctx.RW.OverwriteAt(a, b-a, nil); err != nil {
-		return err
-	}
-	ctx.C.SetSelectionOff()
-	ctx.C.SetIndex(a)
-	return nil
+	return ForEachCursor(ctx, func() error {
+		a, b, ok := ctx.C.SelectionIndexes()
+		if !ok {
+			return nil
+		}
+		if err := ctx.RW.OverwriteAt(a, b-a, nil); err != nil {
+			return err
+		}
+		ctx.C.SetSelectionOff()
+		ctx.C.SetIndex(a)
+		return nil
+	})
 }
@@ -1,48 +1,41 @@
 package rwedit
 
 import (
-	"bytes"
 	"context"
-	"errors"
-	"io"
 
 	"github.com/jmigpin/editor/util/iout/iorw"
 )
 
-func Find(cctx context.Context, ctx *Ctx, str string) (bool, error) {
+func Find(cctx context.Context, ctx *Ctx, str string, opt iorw.SearchOpt) (bool, error) {
 	if str == "" {
 		return false, nil
 	}
+	s, err := iorw.NewSearcher(str, opt)
+	if err != nil {
+		return false, err
+	}
 
-	// ignore case
-	lowb := bytes.ToLower([]byte(str))
-
-	i, err := find2(cctx, ctx, lowb) // ignores case
-	if err != nil || i < 0 {
+	m, err := find2(cctx, ctx, s)
+	if err != nil || m == nil {
 		return false, err
 	}
-	ctx.C.SetSelection(i, i+len(lowb)) // cursor at end to allow searching next
+	ctx.C.SetSelection(m[0], m[1]) // cursor at end to allow searching next
 	return true, nil
 }
 
-func find2(cctx context.Context, ctx *Ctx, b []byte) (int, error) {
+func find2(cctx context.Context, ctx *Ctx, s *iorw.Searcher) ([]int, error) {
 	ci := ctx.C.Index()
 
 	// index to end
-	i, err := iorw.IndexCtx(cctx, ctx.RW, ci, b, true)
-	if err != nil || i >= 0 {
-		return i, err
+	m, err := s.Index(cctx, ctx.RW, ci)
+	if err != nil || m != nil {
+		return m, err
 	}
 
 	// start to index
-	e := ci + len(b) - 1
-	rd := iorw.NewLimitedReaderAt(ctx.RW, 0, e)
-	k, err := iorw.IndexCtx(cctx, rd, 0, b, true)
-	if err != nil {
-		if errors.Is(err, io.EOF) {
-			return -1, nil
-		}
-		return -1, err
+	m, err = s.Index(cctx, ctx.RW, ctx.RW.Min())
+	if err != nil || m == nil || m[0] >= ci {
+		return nil, err
 	}
-	return k, nil
+	return m, nil
 }
@@ -1,6 +1,7 @@
 package rwedit
 
 import (
+	"image"
 	"unicode"
 
 	"github.com/jmigpin/editor/util/uiutil/event"
@@ -45,9 +46,16 @@ func (in *Input) handle() (event.Handled, error) {
 func (in *Input) onMouseDown(ev *event.MouseDown) (event.Handled, error) {
 	switch ev.Button {
 	case event.ButtonLeft:
-		if ev.Mods.ClearLocks().Is(event.ModShift) {
+		switch m := ev.Mods.ClearLocks(); {
+		case m.Is(event.ModAlt):
+			AddCursorAtPoint(in.ctx, ev.Point)
+		case m.Is(event.ModAlt | event.ModShift):
+			BoxSelectStart(in.ctx, ev.Point)
+		case m.Is(event.ModShift):
+			in.ctx.MC.Clear()
 			MoveCursorToPoint(in.ctx, ev.Point, true)
-		} else {
+		default:
+			in.ctx.MC.Clear()
 			MoveCursorToPoint(in.ctx, ev.Point, false)
 		}
 		return true, nil
@@ -63,7 +71,7 @@ func (in *Input) onMouseDown(ev *event.MouseDown) (event.Handled, error) {
 
 func (in *Input) onMouseDragMove(ev *event.MouseDragMove) (event.Handled, error) {
 	if ev.Buttons.Has(event.ButtonLeft) {
-		MoveCursorToPoint(in.ctx, ev.Point, true)
+		in.mouseDragSelect(ev.Point, ev.Mods)
 		return true, nil
 	}
 	return false, nil
@@ -71,17 +79,27 @@ func (in *Input) onMouseDragMove(ev *event.MouseDragMove) (event.Handled, error)
 func (in *Input) onMouseDragEnd(ev *event.MouseDragEnd) (event.Handled, error) {
 	switch ev.Button {
 	case event.ButtonLeft:
-		MoveCursorToPoint(in.ctx, ev.Point, true)
+		in.mouseDragSelect(ev.Point, ev.Mods)
 		return true, nil
 	}
 	return false, nil
 }
 
+func (in *Input) mouseDragSelect(p image.Point, mods event.KeyModifiers) {
+	m := mods.ClearLocks()
+	if m.Is(event.ModAlt|event.ModShift) || in.ctx.MC.box.on {
+		BoxSelect(in.ctx, p)
+		return
+	}
+	MoveCursorToPoint(in.ctx, p, true)
+}
+
 //----------
 
 func (in *Input) onMouseClick(ev *event.MouseClick) (event.Handled, error) {
 	switch ev.Button {
 	case event.ButtonMiddle:
+		in.ctx.MC.Clear()
 		MoveCursorToPoint(in.ctx, ev.Point, false)
 		Paste(in.ctx, event.CIPrimary)
 		return true, nil
@@ -111,7 +129,41 @@ func (in *Input) onMouseTripleClick(ev *event.MouseTripleClick) (event.Handled,
 
 //----------
 
-func (in *Input) onKeyDown(ev *event.KeyDown) (_ event.Handled, err error) {
+func (in *Input) onKeyDown(ev *event.KeyDown) (event.Handled, error) {
+	if in.ctx.MC.Len() == 0 || !in.multiCursorKey(ev) {
+		return in.onKeyDown2(ev)
+	}
+	h := event.Handled(false)
+	err := ForEachCursor(in.ctx, func() error {
+		h2, err := in.onKeyDown2(ev)
+		h = h || h2
+		return err
+	})
+	if err == nil {
+		in.ctx.Fns.MakeIndexVisible(in.ctx.C.Index())
+	}
+	return h, err
+}
+
+// Keys that are applied at every cursor.
+func (in *Input) multiCursorKey(ev *event.KeyDown) bool {
+	mcl := ev.Mods.ClearLocks()
+	switch ev.KeySym {
+	case event.KSymRight, event.KSymLeft,
+		event.KSymHome, event.KSymEnd,
+		event.KSymBackspace, event.KSymDelete,
+		event.KSymReturn, event.KSymSpace:
+		return true
+	case event.KSymUp, event.KSymDown:
+		return !mcl.HasAny(event.ModCtrl) // not moving/duplicating lines
+	}
+	if mcl.HasAny(event.ModCtrl) || (ev.KeySym >= event.KSymF1 && ev.KeySym <= event.KSymF12) {
+		return false
+	}
+	return unicode.IsPrint(ev.Rune)
+}
+
+func (in *Input) onKeyDown2(ev *event.KeyDown) (_ event.Handled, err error) {
 	mcl := ev.Mods.ClearLocks()
 
 	makeCursorVisible := func() {
@@ -130,9 +182,13 @@ func (in *Input) onKeyDown(ev *event.KeyDown) (_ event.Handled, err error) {
 		event.KSymCapsLock,
 		event.KSymNumLock,
 		event.KSymInsert,
-		event.KSymEscape,
 		event.KSymSuperL: // windows key
 		// ignore these
+	case event.KSymEscape:
+		if in.ctx.MC.Len() > 0 {
+			in.ctx.MC.Clear()
+			return true, nil
+		}
 	case event.KSymRight:
 		switch {
 		case mcl.Is(event.ModCtrl | event.ModShift):
@@ -281,6 +337,13 @@ func (in *Input) onKeyDown(ev *event.KeyDown) (_ event.Handled, err error) {
 				err = Redo(in.ctx)
 				return true, nil
 			}
+		case mcl.Is(event.ModCtrl | event.ModAlt):
+			switch ev.KeySym {
+			case event.KSymN:
+				err = AddCursorNextOccurrence(in.ctx)
+				makeCursorVisible()
+				return true, err
+			}
 		case ev.KeySym >= event.KSymF1 && ev.KeySym <= event.KSymF12:
 			// do nothing
 		case !unicode.IsPrint(ev.Rune):
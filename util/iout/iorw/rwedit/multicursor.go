@@ -0,0 +1,280 @@
+package rwedit
+
+import (
+	"bytes"
+	"image"
+	"sort"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+)
+
+// Additional cursors for multi-cursor editing (the main cursor is ctx.C). Edits done with ForEachCursor are applied at every cursor.
+type MultiCursor struct {
+	cs       []SimpleCursor // sorted by index
+	OnChange func()
+
+	box struct { // column/box selection
+		on    bool
+		start image.Point
+	}
+}
+
+func (mc *MultiCursor) Cursors() []SimpleCursor {
+	return append([]SimpleCursor{}, mc.cs...)
+}
+
+func (mc *MultiCursor) Len() int {
+	return len(mc.cs)
+}
+
+func (mc *MultiCursor) Clear() {
+	mc.box.on = false
+	if len(mc.cs) == 0 {
+		return
+	}
+	mc.set(nil)
+}
+
+func (mc *MultiCursor) set(cs []SimpleCursor) {
+	sort.Slice(cs, func(a, b int) bool {
+		return cs[a].minIndex() < cs[b].minIndex()
+	})
+	mc.cs = cs
+	if mc.OnChange != nil {
+		mc.OnChange()
+	}
+}
+
+// Keeps the cursors positions stable on a write (at index i, n deleted bytes, k inserted bytes).
+func (mc *MultiCursor) StableWrite(i, n, k int) {
+	if len(mc.cs) == 0 {
+		return
+	}
+	stable := func(v int) int {
+		switch {
+		case v <= i:
+			return v
+		case v < i+n: // inside the deleted range
+			return i
+		default:
+			return v - n + k
+		}
+	}
+	cs := mc.Cursors()
+	for j := range cs {
+		c := &cs[j]
+		c.index = stable(c.index)
+		if c.sel.on {
+			c.sel.index = stable(c.sel.index)
+		}
+	}
+	mc.set(cs)
+}
+
+//----------
+
+// Runs fn once for each cursor (main and additional), with the cursor set as the ctx main cursor. The cursors are visited from the end of the text to allow fn to edit without invalidating the next cursors.
+func ForEachCursor(ctx *Ctx, fn func() error) error {
+	if ctx.MC.Len() == 0 {
+		return fn()
+	}
+
+	type entry struct {
+		c    SimpleCursor
+		main bool
+	}
+	u := []*entry{{c: ctx.C.Get(), main: true}}
+	for _, c := range ctx.MC.cs {
+		u = append(u, &entry{c: c})
+	}
+	sort.SliceStable(u, func(a, b int) bool {
+		return u[a].c.minIndex() > u[b].c.minIndex()
+	})
+
+	var err error
+	for k, e := range u {
+		ctx.C.Set(e.c)
+		max := ctx.RW.Max()
+		if err2 := fn(); err2 != nil && err == nil {
+			err = err2
+		}
+		e.c = ctx.C.Get()
+		// the cursors already visited are after the edit
+		if d := ctx.RW.Max() - max; d != 0 {
+			for _, e2 := range u[:k] {
+				e2.c.shift(d)
+			}
+		}
+	}
+
+	// restore cursors (merge equal cursors)
+	var main SimpleCursor
+	cs := []SimpleCursor{}
+	seen := map[SimpleCursor]bool{}
+	for _, e := range u {
+		if e.main {
+			main = e.c
+		}
+	}
+	seen[main] = true
+	for _, e := range u {
+		if !seen[e.c] {
+			seen[e.c] = true
+			cs = append(cs, e.c)
+		}
+	}
+	ctx.C.Set(main)
+	ctx.MC.set(cs)
+	return err
+}
+
+//----------
+
+// The main cursor goes to the point, and its previous position becomes an additional cursor.
+func AddCursorAtPoint(ctx *Ctx, p image.Point) {
+	i := ctx.Fns.GetIndex(p)
+	c := ctx.C.Get()
+	if c.Index() == i && !c.HaveSelection() {
+		return
+	}
+	ctx.MC.box.on = false
+	ctx.MC.set(append(ctx.MC.Cursors(), c))
+	ctx.C.SetIndexSelectionOff(i)
+}
+
+// Selects the next occurrence of the main cursor selection with the main cursor. The previous selection becomes an additional cursor. Without a selection, the word at the cursor is selected.
+func AddCursorNextOccurrence(ctx *Ctx) error {
+	b, ok := ctx.Selection()
+	if !ok {
+		return SelectWord(ctx)
+	}
+
+	// search after the last cursor
+	all := append(ctx.MC.Cursors(), ctx.C.Get())
+	start := 0
+	taken := map[int]bool{}
+	for _, c := range all {
+		if a, b, ok := c.SelectionIndexes(); ok {
+			taken[a] = true
+			if b > start {
+				start = b
+			}
+		}
+	}
+	i, err := iorw.Index(ctx.RW, start, b, false)
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		// wrap around
+		i, err = iorw.Index(ctx.RW, 0, b, false)
+		if err != nil {
+			return err
+		}
+	}
+	if i < 0 || taken[i] {
+		return nil // no more occurrences
+	}
+
+	ctx.MC.set(append(ctx.MC.Cursors(), ctx.C.Get()))
+	ctx.C.SetSelection(i, i+len(b))
+	return nil
+}
+
+//----------
+
+// Column/box selection: one cursor per line between the points, selecting the columns between the points.
+func BoxSelectStart(ctx *Ctx, p image.Point) {
+	ctx.MC.Clear()
+	ctx.MC.box.on = true
+	ctx.MC.box.start = p
+	MoveCursorToPoint(ctx, p, false)
+}
+
+func BoxSelect(ctx *Ctx, p image.Point) {
+	if !ctx.MC.box.on {
+		BoxSelectStart(ctx, p)
+		return
+	}
+	p0 := ctx.MC.box.start
+	lh := ctx.Fns.LineHeight()
+	if lh <= 0 {
+		return
+	}
+
+	// points at the start of the lines
+	y0, y1 := ctx.Fns.GetPoint(ctx.Fns.GetIndex(p0)).Y, ctx.Fns.GetPoint(ctx.Fns.GetIndex(p)).Y
+	step := lh
+	if y1 < y0 {
+		step = -lh
+	}
+	cs := []SimpleCursor{}
+	for y := y0; ; y += step {
+		a := ctx.Fns.GetIndex(image.Point{p0.X, y + lh/2})
+		b := ctx.Fns.GetIndex(image.Point{p.X, y + lh/2})
+		c := SimpleCursor{}
+		if a == b {
+			c.SetIndex(b)
+		} else {
+			c.SetSelection(a, b)
+		}
+		cs = append(cs, c)
+		if (step > 0 && y >= y1) || (step < 0 && y <= y1) {
+			break
+		}
+	}
+
+	// last line has the main cursor
+	main := cs[len(cs)-1]
+	ctx.C.Set(main)
+	ctx.MC.set(cs[:len(cs)-1])
+}
+
+//----------
+
+func (c *SimpleCursor) minIndex() int {
+	if a, _, ok := c.SelectionIndexes(); ok {
+		return a
+	}
+	return c.index
+}
+
+func (c *SimpleCursor) shift(d int) {
+	c.index += d
+	if c.sel.on {
+		c.sel.index += d
+	}
+}
+
+//----------
+
+// Selections of the additional cursors and the main cursor, sorted.
+func (ctx *Ctx) AllSelectionsIndexes() [][2]int {
+	u := [][2]int{}
+	cs := append(ctx.MC.Cursors(), ctx.C.Get())
+	for _, c := range cs {
+		if a, b, ok := c.SelectionIndexes(); ok {
+			u = append(u, [2]int{a, b})
+		}
+	}
+	sort.Slice(u, func(a, b int) bool {
+		return u[a][0] < u[b][0]
+	})
+	return u
+}
+
+// Selected texts of all cursors, sorted by index and joined with newlines.
+func (ctx *Ctx) AllSelections() ([]byte, bool) {
+	u := [][]byte{}
+	for _, s := range ctx.AllSelectionsIndexes() {
+		b, err := ctx.RW.ReadFastAt(s[0], s[1]-s[0])
+		if err != nil {
+			return nil, false
+		}
+		u = append(u, iorw.MakeBytesCopy(b))
+	}
+	if len(u) == 0 {
+		return nil, false
+	}
+	return bytes.Join(u, []byte("\n")), true
+}
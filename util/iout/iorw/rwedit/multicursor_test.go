@@ -0,0 +1,129 @@
+package rwedit
+
+import (
+	"image"
+	"testing"
+
+	"github.com/jmigpin/editor/util/iout/iorw"
+	"github.com/jmigpin/editor/util/uiutil/event"
+)
+
+func TestMultiCursorInsert(t *testing.T) {
+	ctx := newMCTestCtx("aaa\nbbb\nccc")
+	ctx.C.SetIndex(8)
+	ctx.MC.set([]SimpleCursor{{index: 0}, {index: 4}})
+
+	if err := ForEachCursor(ctx, func() error { return InsertString(ctx, "xy") }); err != nil {
+		t.Fatal(err)
+	}
+	mcTestContent(t, ctx, "xyaaa\nxybbb\nxyccc")
+	if ctx.C.Index() != 14 {
+		t.Fatal(ctx.C.Index())
+	}
+	u := ctx.MC.Cursors()
+	if len(u) != 2 || u[0].Index() != 2 || u[1].Index() != 8 {
+		t.Fatal(u)
+	}
+
+	if err := ForEachCursor(ctx, func() error { return Backspace(ctx) }); err != nil {
+		t.Fatal(err)
+	}
+	mcTestContent(t, ctx, "xaaa\nxbbb\nxccc")
+}
+
+func TestMultiCursorMerge(t *testing.T) {
+	ctx := newMCTestCtx("ab")
+	ctx.C.SetIndex(1)
+	ctx.MC.set([]SimpleCursor{{index: 2}})
+
+	// both cursors end at the start
+	if err := ForEachCursor(ctx, func() error {
+		StartOfString(ctx, false)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if ctx.MC.Len() != 0 || ctx.C.Index() != 0 {
+		t.Fatal(ctx.MC.Cursors(), ctx.C.Index())
+	}
+}
+
+func TestMultiCursorNextOccurrence(t *testing.T) {
+	ctx := newMCTestCtx("ab ab ab")
+	ctx.C.SetIndex(1)
+	for i := 0; i < 4; i++ {
+		if err := AddCursorNextOccurrence(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ctx.MC.Len() != 2 {
+		t.Fatal(ctx.MC.Cursors())
+	}
+	b, _ := ctx.AllSelections()
+	if string(b) != "ab\nab\nab" {
+		t.Fatal(string(b))
+	}
+
+	// replace all occurrences
+	if err := ForEachCursor(ctx, func() error { return InsertString(ctx, "c") }); err != nil {
+		t.Fatal(err)
+	}
+	mcTestContent(t, ctx, "c c c")
+}
+
+func TestMultiCursorPasteLines(t *testing.T) {
+	ctx := newMCTestCtx("a\nb")
+	ctx.C.SetIndex(3)
+	ctx.MC.set([]SimpleCursor{{index: 1}})
+	ctx.Fns.GetClipboardData = func(i event.ClipboardIndex, fn func(string, error)) {
+		fn("1\n2", nil)
+	}
+	Paste(ctx, event.CIClipboard)
+	mcTestContent(t, ctx, "a1\nb2")
+}
+
+func TestMultiCursorBoxSelect(t *testing.T) {
+	ctx := newMCTestCtx("abcd\nefgh\nijkl")
+	// monospace grid: 10x10 per rune
+	ctx.Fns.LineHeight = func() int { return 10 }
+	ctx.Fns.GetPoint = func(i int) image.Point {
+		return image.Point{X: i % 5 * 10, Y: i / 5 * 10}
+	}
+	ctx.Fns.GetIndex = func(p image.Point) int {
+		x, y := p.X/10, p.Y/10
+		if x > 4 {
+			x = 4
+		}
+		return y*5 + x
+	}
+
+	BoxSelectStart(ctx, image.Point{10, 0})
+	BoxSelect(ctx, image.Point{30, 20})
+	b, _ := ctx.AllSelections()
+	if string(b) != "bc\nfg\njk" {
+		t.Fatalf("%q", b)
+	}
+	if err := ForEachCursor(ctx, func() error { return Delete(ctx) }); err != nil {
+		t.Fatal(err)
+	}
+	mcTestContent(t, ctx, "ad\neh\nil")
+}
+
+//----------
+
+func newMCTestCtx(s string) *Ctx {
+	ctx := NewCtx()
+	ctx.RW = iorw.NewBytesReadWriterAt([]byte(s))
+	return ctx
+}
+
+func mcTestContent(t *testing.T, ctx *Ctx, s string) {
+	t.Helper()
+	b, err := iorw.ReadFastFull(ctx.RW)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != s {
+		t.Fatalf("%q, expecting %q", b, s)
+	}
+}
@@ -1,16 +1,20 @@
 package rwedit
 
 import (
+	"context"
+
 	"github.com/jmigpin/editor/util/iout/iorw"
 )
 
-func Replace(ctx *Ctx, old, new string) (bool, error) {
+// Replaces all occurrences inside the selection (or in all the content if there is no selection). With a regexp search, the new string can have "$1" references to the submatches.
+func Replace(cctx context.Context, ctx *Ctx, old, new string, opt iorw.SearchOpt) (bool, error) {
 	if old == "" {
 		return false, nil
 	}
-
-	oldb := []byte(old)
-	newb := []byte(new)
+	s, err := iorw.NewSearcher(old, opt)
+	if err != nil {
+		return false, err
+	}
 
 	a, b, ok := ctx.C.SelectionIndexes()
 	if !ok {
@@ -18,7 +22,7 @@ func Replace(ctx *Ctx, old, new string) (bool, error) {
 		b = ctx.RW.Max()
 	}
 
-	ci, replaced, err := replace2(ctx, oldb, newb, a, b)
+	ci, replaced, err := replace2(cctx, ctx, s, new, a, b)
 	if err != nil {
 		return replaced, err
 	}
@@ -26,23 +30,29 @@ func Replace(ctx *Ctx, old, new string) (bool, error) {
 	return replaced, nil
 }
 
-func replace2(ctx *Ctx, oldb, newb []byte, a, b int) (int, bool, error) {
+func replace2(cctx context.Context, ctx *Ctx, s *iorw.Searcher, new string, a, b int) (int, bool, error) {
 	ci := ctx.C.Index()
 	replaced := false
 	for a < b {
-		rd := iorw.NewLimitedReaderAt(ctx.RW, a, b)
-		i, err := iorw.Index(rd, a, oldb, false)
+		// content before the search start is visible to the regexp anchors
+		rd := iorw.NewLimitedReaderAt(ctx.RW, ctx.RW.Min(), b)
+		m, err := s.Index(cctx, rd, a)
 		if err != nil {
 			return ci, replaced, err
 		}
-		if i < 0 {
+		if m == nil {
 			return ci, replaced, nil
 		}
-		if err := ctx.RW.OverwriteAt(i, len(oldb), newb); err != nil {
+		i, n := m[0], m[1]-m[0]
+		newb, err := s.Expand(rd, new, m)
+		if err != nil {
+			return ci, replaced, err
+		}
+		if err := ctx.RW.OverwriteAt(i, n, newb); err != nil {
 			return ci, replaced, err
 		}
 		replaced = true
-		d := -len(oldb) + len(newb)
+		d := -n + len(newb)
 		b += d
 		a = i + len(newb)
 
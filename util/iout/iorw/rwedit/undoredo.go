@@ -1,8 +1,11 @@
 package rwedit
 
+// The additional cursors are cleared since the history only keeps the main cursor.
 func Undo(ctx *Ctx) error {
+	ctx.MC.Clear()
 	return ctx.Fns.Undo()
 }
 func Redo(ctx *Ctx) error {
+	ctx.MC.Clear()
 	return ctx.Fns.Redo()
 }
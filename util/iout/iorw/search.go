@@ -0,0 +1,207 @@
+package iorw
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"unicode/utf8"
+)
+
+type SearchOpt struct {
+	Regexp        bool // go regexp syntax
+	WholeWord     bool
+	CaseSensitive bool
+}
+
+// Searches a pattern in a ReaderAt, reading it in chunks (works on big buffers). Empty matches are ignored. The regexp anchors (ex: "^", "\b") take into account the text before the search start index.
+type Searcher struct {
+	opt SearchOpt
+	sep []byte // literal search
+	re  *regexp.Regexp
+	// same as re but consumes one leading rune of context: allows anchors to see the rune before the search start index
+	reCtx *regexp.Regexp
+}
+
+func NewSearcher(pattern string, opt SearchOpt) (*Searcher, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+	s := &Searcher{opt: opt}
+	if opt.Regexp {
+		flags := "(?m)"
+		if !opt.CaseSensitive {
+			flags += "(?i)"
+		}
+		re, err := regexp.Compile(flags + pattern)
+		if err != nil {
+			return nil, err
+		}
+		s.re = re
+		s.reCtx = regexp.MustCompile(flags + "(?s:.)(" + pattern + ")")
+		return s, nil
+	}
+	s.sep = []byte(pattern)
+	if !opt.CaseSensitive {
+		s.sep = bytes.ToLower(s.sep)
+	}
+	return s, nil
+}
+
+//----------
+
+// Returns the first match at or after index i: the match start/end indexes followed by the regexp submatches indexes (-1 if a submatch didn't participate). Returns nil if not found.
+func (s *Searcher) Index(ctx context.Context, r ReaderAt, i int) ([]int, error) {
+	for {
+		m, err := s.index2(ctx, r, i)
+		if err != nil || m == nil {
+			return nil, err
+		}
+		ok, err := s.acceptMatch(r, m[0], m[1])
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return m, nil
+		}
+		// continue after the start of the rejected match
+		_, size, err := ReadRuneAt(r, m[0])
+		if err != nil {
+			return nil, nil
+		}
+		i = m[0] + size
+	}
+}
+
+func (s *Searcher) index2(ctx context.Context, r ReaderAt, i int) ([]int, error) {
+	if s.re == nil {
+		k, err := IndexCtx(ctx, r, i, s.sep, !s.opt.CaseSensitive)
+		if err != nil || k < 0 {
+			return nil, err
+		}
+		return []int{k, k + len(s.sep)}, nil
+	}
+
+	// start reading at the previous rune to give context to the anchors
+	re, start := s.re, i
+	if i > r.Min() {
+		_, size, err := ReadLastRuneAt(r, i)
+		if err != nil {
+			return nil, err
+		}
+		re, start = s.reCtx, i-size
+	}
+
+	rr := &searchRuneReader{ctx: ctx, r: r, i: start}
+	m := re.FindReaderSubmatchIndex(rr)
+	if rr.err != nil {
+		return nil, rr.err
+	}
+	if m != nil && re == s.reCtx {
+		m = m[2:] // the pattern match is the first group
+	}
+	for k := range m {
+		if m[k] >= 0 {
+			m[k] += start
+		}
+	}
+	return m, nil
+}
+
+func (s *Searcher) acceptMatch(r ReaderAt, a, b int) (bool, error) {
+	if a == b {
+		return false, nil
+	}
+	if !s.opt.WholeWord {
+		return true, nil
+	}
+	if a > r.Min() {
+		ru, _, err := ReadLastRuneAt(r, a)
+		if err != nil {
+			return false, err
+		}
+		if IsWordRune(ru) {
+			return false, nil
+		}
+	}
+	if b < r.Max() {
+		ru, _, err := ReadRuneAt(r, b)
+		if err != nil {
+			return false, err
+		}
+		if IsWordRune(ru) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+//----------
+
+// Returns the replacement for a match from Index. Regexp searches expand the "$1", "${name}" references in the template with the submatches. Literal searches return the template.
+func (s *Searcher) Expand(r ReaderAt, template string, m []int) ([]byte, error) {
+	if s.re == nil {
+		return []byte(template), nil
+	}
+	src, err := r.ReadFastAt(m[0], m[1]-m[0])
+	if err != nil {
+		return nil, err
+	}
+	m2 := make([]int, len(m))
+	for k, v := range m {
+		m2[k] = -1
+		if v >= 0 {
+			m2[k] = v - m[0]
+		}
+	}
+	return s.re.Expand(nil, []byte(template), src, m2), nil
+}
+
+//----------
+
+// Implements io.RuneReader. Reads in chunks, and checks the context on each chunk read.
+type searchRuneReader struct {
+	ctx context.Context
+	r   ReaderAt
+	i   int    // index of buf start
+	buf []byte // copy of the chunk
+	err error  // non-EOF error (the regexp ends the search on any error)
+}
+
+func (rr *searchRuneReader) ReadRune() (rune, int, error) {
+	if len(rr.buf) < utf8.UTFMax && !utf8.FullRune(rr.buf) {
+		if err := rr.fill(); err != nil {
+			return 0, 0, err
+		}
+	}
+	if len(rr.buf) == 0 {
+		return 0, 0, io.EOF
+	}
+	ru, size := utf8.DecodeRune(rr.buf)
+	rr.buf = rr.buf[size:]
+	rr.i += size
+	return ru, size, nil
+}
+
+func (rr *searchRuneReader) fill() error {
+	if err := rr.ctx.Err(); err != nil {
+		rr.err = err
+		return err
+	}
+	k := rr.i + len(rr.buf)
+	n := 32 * 1024
+	if m := rr.r.Max(); k+n > m {
+		n = m - k
+	}
+	if n <= 0 {
+		return nil
+	}
+	b, err := rr.r.ReadFastAt(k, n)
+	if err != nil {
+		rr.err = err
+		return err
+	}
+	rr.buf = append(rr.buf, b...) // copy
+	return nil
+}
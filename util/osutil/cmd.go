@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"os/exec"
 	"strings"
 	"sync"
@@ -32,6 +33,11 @@ type Cmd struct {
 		fns     []func()
 		closers []io.Closer
 	}
+
+	pty struct {
+		pts     *os.File      // closed in the parent after start
+		outDone chan struct{} // output copy done
+	}
 }
 
 // If Start() is not called, Cancel() must be called to clear resources.
@@ -70,6 +76,11 @@ func (cmd *Cmd) start2() error {
 		return err
 	}
 
+	// the child has its own copy, allows reading the master to end when the child exits
+	if cmd.pty.pts != nil {
+		cmd.pty.pts.Close()
+	}
+
 	// Ensure callback is called before the first stdout/stderr write (works since the process takes longer to launch and write back, but in theory this could be called after some output comes out). Always works if stdin/out/err was setup with SetupStdio() since the copy loop starts after the callback.
 	if cmd.PreOutputCallback != nil {
 		cmd.PreOutputCallback()
@@ -95,7 +106,12 @@ func (cmd *Cmd) Wait() error {
 		cmd.disableEnsureStop() // no need to kill process anymore
 		cmd.Cancel()            // clear resources
 	}()
-	return cmd.Cmd.Wait()
+	err := cmd.Cmd.Wait()
+	if cmd.pty.outDone != nil {
+		// don't lose the last output still in the pty
+		<-cmd.pty.outDone
+	}
+	return err
 }
 
 func (cmd *Cmd) Run() error {
@@ -164,6 +180,37 @@ func (cmd *Cmd) setupStdio2(ir io.Reader, ow, ew io.Writer) error {
 	return nil
 }
 
+// Runs the cmd under a pseudo-terminal: stdin/stdout/stderr are the pty slave, and the pty master output/input is copied to/from rw. Returns the pty master to allow setting the window size (see SetPtySize).
+func (cmd *Cmd) SetupPty(rw io.ReadWriter) (*os.File, error) {
+	if cmd.setupCalled {
+		return nil, fmt.Errorf("setup already called")
+	}
+	cmd.setupCalled = true
+
+	ptm, pts, err := OpenPty()
+	if err != nil {
+		return nil, err
+	}
+	cmd.addCopyCloser(ptm)
+	cmd.addCopyCloser(pts) // in case start is not called
+
+	cmd.Cmd.Stdin = pts
+	cmd.Cmd.Stdout = pts
+	cmd.Cmd.Stderr = pts
+	SetupExecCmdSysProcAttrPty(cmd.Cmd)
+	cmd.pty.pts = pts
+
+	cmd.pty.outDone = make(chan struct{})
+	cmd.copy.fns = append(cmd.copy.fns, func() {
+		defer close(cmd.pty.outDone)
+		io.Copy(rw, ptm) // ends with an error when all slaves are closed
+	})
+	cmd.copy.fns = append(cmd.copy.fns, func() {
+		io.Copy(ptm, rw)
+	})
+	return ptm, nil
+}
+
 //----------
 
 func (cmd *Cmd) runCopyFns() {
@@ -0,0 +1,136 @@
+package osutil
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matches paths with the patterns of ".gitignore" files. Rules from a ".gitignore" only apply to paths inside its directory. The last matching rule wins (later rules, and rules of sub directories, take precedence). Paths inside an ignored directory are not matched, the caller is expected to skip the directory (ex: while walking).
+type GitIgnore struct {
+	rules []*giRule
+}
+
+type giRule struct {
+	base    string // directory of the .gitignore
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Reads the ".gitignore" file in the directory (if it exists).
+func (gi *GitIgnore) AddDir(dir string) error {
+	b, err := ioutil.ReadFile(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	gi.AddPatterns(dir, b)
+	return nil
+}
+
+func (gi *GitIgnore) AddPatterns(dir string, b []byte) {
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for sc.Scan() {
+		if r := parseGiRule(dir, sc.Text()); r != nil {
+			gi.rules = append(gi.rules, r)
+		}
+	}
+}
+
+func (gi *GitIgnore) Ignored(path string, isDir bool) bool {
+	ignored := false
+	for _, r := range gi.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(r.base, path)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue // not inside the rule directory
+		}
+		if r.re.MatchString(filepath.ToSlash(rel)) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+//----------
+
+func parseGiRule(dir, s string) *giRule {
+	s = strings.TrimRight(s, " \t\r")
+	if s == "" || strings.HasPrefix(s, "#") {
+		return nil
+	}
+	r := &giRule{base: dir}
+	if strings.HasPrefix(s, "!") {
+		r.negate = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, `\`) {
+		s = s[1:] // escaped "#" or "!"
+	}
+	if strings.HasSuffix(s, "/") {
+		r.dirOnly = true
+		s = strings.TrimRight(s, "/")
+	}
+	if s == "" {
+		return nil
+	}
+
+	// a pattern with a slash is relative to the .gitignore directory, otherwise it matches at any depth
+	anchored := strings.Contains(s, "/")
+	s = strings.TrimPrefix(s, "/")
+	prefix := "^"
+	if !anchored {
+		prefix = "^(.*/)?"
+	}
+	re, err := regexp.Compile(prefix + giGlobToRegexp(s) + "$")
+	if err != nil {
+		return nil
+	}
+	r.re = re
+	return r
+}
+
+func giGlobToRegexp(s string) string {
+	sb := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case strings.HasPrefix(s[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(s[i:], "/**") && i+3 == len(s):
+			sb.WriteString("/.*")
+			i += 2
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '[':
+			j := strings.IndexByte(s[i+1:], ']')
+			if j < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			class := s[i+1 : i+1+j]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += 1 + j
+		case c == '\\' && i+1 < len(s):
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(s[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,44 @@
+package osutil
+
+import (
+	"testing"
+)
+
+func TestGitIgnore1(t *testing.T) {
+	gi := &GitIgnore{}
+	gi.AddPatterns("/a", []byte(`
+# comment
+*.o
+/bin
+build/
+doc/**/*.tmp
+!keep.o
+`))
+	gi.AddPatterns("/a/sub", []byte("*.txt\n"))
+
+	type entry struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}
+	w := []entry{
+		{"/a/x.o", false, true},
+		{"/a/c/x.o", false, true},
+		{"/a/c/keep.o", false, false},
+		{"/a/bin", true, true},
+		{"/a/c/bin", true, false},
+		{"/a/build", false, false},
+		{"/a/c/build", true, true},
+		{"/a/doc/x.tmp", false, true},
+		{"/a/doc/e/f/x.tmp", false, true},
+		{"/a/x.tmp", false, false},
+		{"/a/x.txt", false, false},
+		{"/a/sub/c/x.txt", false, true},
+		{"/b/x.o", false, false},
+	}
+	for _, e := range w {
+		if v := gi.Ignored(e.path, e.isDir); v != e.ignored {
+			t.Fatalf("%v: expected %v", e.path, e.ignored)
+		}
+	}
+}
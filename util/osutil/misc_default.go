@@ -3,6 +3,7 @@
 package osutil
 
 import (
+	"os"
 	"os/exec"
 	"strings"
 
@@ -19,6 +20,11 @@ func SetupExecCmdSysProcAttr(cmd *exec.Cmd) {
 	cmd.SysProcAttr = &unix.SysProcAttr{Setsid: true}
 }
 
+// The cmd stdin must be the pty slave (controlling terminal).
+func SetupExecCmdSysProcAttrPty(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &unix.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+}
+
 func KillExecCmd(cmd *exec.Cmd) error {
 	// negative pid (but !=-1) sends signals to the process group
 	return unix.Kill(-cmd.Process.Pid, unix.SIGKILL)
@@ -35,3 +41,10 @@ func ShellRunArgs(args ...string) []string {
 func ExecName(name string) string {
 	return name
 }
+
+//----------
+
+// Non-blocking exclusive lock, fails if already locked. Released when the file is closed.
+func TryLockFile(f *os.File) error {
+	return unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+}
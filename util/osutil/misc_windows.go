@@ -3,6 +3,7 @@
 package osutil
 
 import (
+	"os"
 	"os/exec"
 
 	"golang.org/x/sys/windows"
@@ -21,6 +22,10 @@ func SetupExecCmdSysProcAttr(cmd *exec.Cmd) {
 	}
 }
 
+func SetupExecCmdSysProcAttrPty(cmd *exec.Cmd) {
+	SetupExecCmdSysProcAttr(cmd)
+}
+
 func KillExecCmd(cmd *exec.Cmd) error {
 	return cmd.Process.Kill()
 
@@ -41,3 +46,12 @@ func ShellRunArgs(args ...string) []string {
 func ExecName(name string) string {
 	return name + ".exe"
 }
+
+//----------
+
+// Non-blocking exclusive lock, fails if already locked. Released when the file is closed.
+func TryLockFile(f *os.File) error {
+	flags := uint32(windows.LOCKFILE_EXCLUSIVE_LOCK | windows.LOCKFILE_FAIL_IMMEDIATELY)
+	ol := &windows.Overlapped{}
+	return windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, 1, 0, ol)
+}
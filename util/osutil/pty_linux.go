@@ -0,0 +1,41 @@
+// +build linux
+
+package osutil
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Opens a pseudo-terminal. The slave (pts) is to be used as the cmd stdio, and closed by the caller after the cmd starts. Reading the master (ptm) returns an error after the cmd (and its children) closes the slave.
+func OpenPty() (ptm, pts *os.File, _ error) {
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("pty: %w", err)
+	}
+	ptm = os.NewFile(uintptr(fd), "/dev/ptmx")
+	// unlock slave
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		ptm.Close()
+		return nil, nil, fmt.Errorf("pty: unlock: %w", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		ptm.Close()
+		return nil, nil, fmt.Errorf("pty: number: %w", err)
+	}
+	name := fmt.Sprintf("/dev/pts/%d", n)
+	pts, err = os.OpenFile(name, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		ptm.Close()
+		return nil, nil, fmt.Errorf("pty: %w", err)
+	}
+	return ptm, pts, nil
+}
+
+func SetPtySize(ptm *os.File, cols, rows int) error {
+	ws := &unix.Winsize{Col: uint16(cols), Row: uint16(rows)}
+	return unix.IoctlSetWinsize(int(ptm.Fd()), unix.TIOCSWINSZ, ws)
+}
@@ -0,0 +1,50 @@
+package osutil
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestCmdPty1(t *testing.T) {
+	ctx := context.Background()
+	cmd := NewCmd(ctx, "sh", "-c", "test -t 0 && test -t 1 && stty size && echo done")
+	rw := &ptyTestRW{}
+	ptm, err := cmd.SetupPty(rw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPtySize(ptm, 80, 24); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	s := rw.String()
+	if !strings.Contains(s, "24 80") || !strings.Contains(s, "done") {
+		t.Fatalf("%q", s)
+	}
+}
+
+//----------
+
+type ptyTestRW struct {
+	sync.Mutex
+	buf bytes.Buffer
+}
+
+func (rw *ptyTestRW) Read(b []byte) (int, error) {
+	select {} // no input
+}
+func (rw *ptyTestRW) Write(b []byte) (int, error) {
+	rw.Lock()
+	defer rw.Unlock()
+	return rw.buf.Write(b)
+}
+func (rw *ptyTestRW) String() string {
+	rw.Lock()
+	defer rw.Unlock()
+	return rw.buf.String()
+}
@@ -0,0 +1,16 @@
+// +build !linux
+
+package osutil
+
+import (
+	"fmt"
+	"os"
+)
+
+func OpenPty() (ptm, pts *os.File, _ error) {
+	return nil, nil, fmt.Errorf("pty: not supported on this platform")
+}
+
+func SetPtySize(ptm *os.File, cols, rows int) error {
+	return fmt.Errorf("pty: not supported on this platform")
+}
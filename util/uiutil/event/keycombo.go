@@ -0,0 +1,71 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key with modifiers (ex: "ctrl+shift+k").
+type KeyCombo struct {
+	Mods   KeyModifiers
+	KeySym KeySym
+}
+
+func KeyDownCombo(ev *KeyDown) KeyCombo {
+	return KeyCombo{ev.Mods.ClearLocks(), ev.KeySym}
+}
+
+func ParseKeyCombo(s string) (KeyCombo, error) {
+	kc := KeyCombo{}
+	u := strings.Split(strings.ToLower(strings.TrimSpace(s)), "+")
+	for i, name := range u {
+		name = strings.TrimSpace(name)
+		if i < len(u)-1 {
+			m, ok := keyComboMods[name]
+			if !ok {
+				return kc, fmt.Errorf("bad key modifier: %q", name)
+			}
+			kc.Mods |= m
+			continue
+		}
+		ks, ok := keyComboSyms[name]
+		if !ok {
+			return kc, fmt.Errorf("bad key: %q", name)
+		}
+		kc.KeySym = ks
+	}
+	return kc, nil
+}
+
+func (kc KeyCombo) String() string {
+	u := []string{}
+	for _, name := range keyComboModsOrder {
+		if kc.Mods.HasAny(keyComboMods[name]) {
+			u = append(u, name)
+		}
+	}
+	ks := strings.TrimPrefix(kc.KeySym.String(), "KSym")
+	u = append(u, strings.ToLower(ks))
+	return strings.Join(u, "+")
+}
+
+//----------
+
+var keyComboModsOrder = []string{"ctrl", "shift", "alt", "altgr", "super"}
+var keyComboMods = map[string]KeyModifiers{
+	"ctrl":  ModCtrl,
+	"shift": ModShift,
+	"alt":   ModAlt,
+	"altgr": ModAltGr,
+	"super": Mod4,
+}
+
+// Lowercase names of the keysyms without the "KSym" prefix (ex: "escape", "f1", "a").
+var keyComboSyms = func() map[string]KeySym {
+	m := map[string]KeySym{}
+	for ks := KSym_dummy_ + 1; ks <= KSymMenu; ks++ {
+		name := strings.ToLower(strings.TrimPrefix(ks.String(), "KSym"))
+		m[name] = ks
+	}
+	return m
+}()
@@ -83,7 +83,20 @@ func (t *Text) SetStr(str string) error {
 
 func (t *Text) contentChanged() {
 	t.Drawer.ContentChanged()
+	t.contentChanged2()
+}
+
+// Content changed at (or after) the index, allows the drawer to keep state computed before the index.
+func (t *Text) contentChangedAt(index int) {
+	if d, ok := t.Drawer.(*drawer4.Drawer); ok {
+		d.ContentChangedAt(index)
+	} else {
+		t.Drawer.ContentChanged()
+	}
+	t.contentChanged2()
+}
 
+func (t *Text) contentChanged2() {
 	// content changing can influence the layout in the case of dynamic sized textareas (needs layout). Also in the case of scrollareas that need to recalc scrollbars.
 	t.MarkNeedsLayoutAndPaint()
 }
@@ -2,7 +2,9 @@ package widget
 
 import (
 	"image"
+	"sort"
 
+	"github.com/jmigpin/editor/util/drawutil/drawer4"
 	"github.com/jmigpin/editor/util/evreg"
 	"github.com/jmigpin/editor/util/iout/iorw"
 	"github.com/jmigpin/editor/util/iout/iorw/rwedit"
@@ -35,6 +37,7 @@ func NewTextEdit(uiCtx UIContext) *TextEdit {
 	te.ctx = rwedit.NewCtx()
 	te.ctx.RW = te.rwu
 	te.ctx.C = rwedit.NewTriggerCursor(te.onCursorChange)
+	te.ctx.MC.OnChange = te.onMultiCursorChange
 	te.ctx.Fns.Error = uiCtx.Error
 	te.ctx.Fns.GetPoint = te.GetPoint
 	te.ctx.Fns.GetIndex = te.GetIndex
@@ -81,7 +84,7 @@ func (te *TextEdit) SetRWFromMaster(m *TextEdit) {
 func (te *TextEdit) onWrite2(ev interface{}) {
 	e := ev.(*iorw.RWEvWrite2)
 	if e.Changed {
-		te.contentChanged()
+		te.contentChangedAt(e.Index)
 	}
 }
 
@@ -90,7 +93,7 @@ func (te *TextEdit) HandleRWWrite2(ev *iorw.RWEvWrite2) {
 	te.stableRuneOffset(&ev.RWEvWrite)
 	te.stableCursor(&ev.RWEvWrite)
 	if ev.Changed {
-		te.contentChanged()
+		te.contentChangedAt(ev.Index)
 	}
 }
 
@@ -107,6 +110,18 @@ func (te *TextEdit) onCursorChange() {
 	te.MarkNeedsPaint()
 }
 
+func (te *TextEdit) onMultiCursorChange() {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		u := []int{}
+		for _, c := range te.ctx.MC.Cursors() {
+			u = append(u, c.Index())
+		}
+		sort.Ints(u)
+		d.Opt.Cursor.Extra = u
+	}
+	te.MarkNeedsPaint()
+}
+
 //----------
 
 func (te *TextEdit) Cursor() rwedit.Cursor {
@@ -169,6 +184,7 @@ func (te *TextEdit) OnInputEvent(ev interface{}, p image.Point) event.Handled {
 //----------
 
 func (te *TextEdit) SetBytes(b []byte) error {
+	te.ctx.MC.Clear()
 	te.BeginUndoGroup()
 	defer te.EndUndoGroup()
 	defer func() {
@@ -179,6 +195,7 @@ func (te *TextEdit) SetBytes(b []byte) error {
 }
 
 func (te *TextEdit) SetBytesClearPos(b []byte) error {
+	te.ctx.MC.Clear()
 	te.BeginUndoGroup()
 	defer te.EndUndoGroup()
 	err := iorw.SetBytes(te.ctx.RW, b)
@@ -188,6 +205,7 @@ func (te *TextEdit) SetBytesClearPos(b []byte) error {
 
 // Keeps position (useful for file save)
 func (te *TextEdit) SetBytesClearHistory(b []byte) error {
+	te.ctx.MC.Clear()
 	te.rwu.History.Clear()
 	rw := te.rwu.ReadWriterAt // bypass history
 	if err := iorw.SetBytes(rw, b); err != nil {
@@ -245,6 +263,7 @@ func (te *TextEdit) stableRuneOffset(ev *iorw.RWEvWrite) {
 }
 
 func (te *TextEdit) stableCursor(ev *iorw.RWEvWrite) {
+	te.ctx.MC.StableWrite(ev.Index, ev.Dn, ev.In)
 	c := te.Cursor()
 	ci := StableOffsetScroll(c.Index(), ev.Index, ev.Dn, ev.In)
 	if c.HaveSelection() {
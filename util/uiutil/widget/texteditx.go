@@ -72,16 +72,25 @@ func (te *TextEditX) Paint() {
 func (te *TextEditX) updateSelectionOpt() {
 	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
 		g := d.Opt.Colorize.Groups[4]
-		c := te.Cursor()
-		if s, e, ok := c.SelectionIndexes(); ok {
+		if sels := te.EditCtx().AllSelectionsIndexes(); len(sels) > 0 {
 			// colors
 			pcol := te.TreeThemePaletteColor
 			fg := pcol("text_selection_fg")
 			bg := pcol("text_selection_bg")
-			// colorize ops
-			g.Ops = []*drawer4.ColorizeOp{
-				{Offset: s, Fg: fg, Bg: bg},
-				{Offset: e},
+			// colorize ops (includes multi-cursor selections)
+			g.Ops = nil
+			for _, u := range sels {
+				// merge overlapping selections (ops must be sorted)
+				if l := len(g.Ops); l > 0 && u[0] <= g.Ops[l-1].Offset {
+					if u[1] > g.Ops[l-1].Offset {
+						g.Ops[l-1].Offset = u[1]
+					}
+					continue
+				}
+				g.Ops = append(g.Ops,
+					&drawer4.ColorizeOp{Offset: u[0], Fg: fg, Bg: bg},
+					&drawer4.ColorizeOp{Offset: u[1]},
+				)
 			}
 			// don't draw other colorizations
 			d.Opt.WordHighlight.Group.Off = true
@@ -205,6 +214,23 @@ func (te *TextEditX) updateFlashOpt4(d *drawer4.Drawer) {
 
 //----------
 
+// Colors from a terminal output (ex: ansi escape sequences). The ops should be sorted by offset.
+func (te *TextEditX) SetTerminalColorizeOps(ops []*drawer4.ColorizeOp) {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		d.Opt.Colorize.Groups[3].Ops = ops
+		te.MarkNeedsPaint()
+	}
+}
+
+func (te *TextEditX) TerminalColorizeOps() []*drawer4.ColorizeOp {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		return d.Opt.Colorize.Groups[3].Ops
+	}
+	return nil
+}
+
+//----------
+
 func (te *TextEditX) EnableParenthesisMatch(v bool) {
 	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
 		d.Opt.ParenthesisHighlight.On = v
@@ -255,6 +281,15 @@ func (te *TextEditX) SetCommentStrings(a ...interface{}) {
 	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
 		opt := &d.Opt.SyntaxHighlight
 		opt.Comment.Defs = cs
+		d.ContentChanged() // tokenizer state depends on the definitions
+	}
+}
+
+// Keywords, numbers and strings rules. Nil uses the default rules (double/single quoted strings).
+func (te *TextEditX) SetSyntaxRules(r *drawutil.SyntaxHighlightRules) {
+	if d, ok := te.Drawer.(*drawer4.Drawer); ok {
+		d.Opt.SyntaxHighlight.Rules = r
+		d.ContentChanged() // tokenizer state depends on the rules
 	}
 }
 
@@ -290,5 +325,9 @@ func (te *TextEditX) OnThemeChange() {
 		opt.Comment.Bg = pcol("text_colorize_comments_bg")
 		opt.String.Fg = pcol("text_colorize_string_fg")
 		opt.String.Bg = pcol("text_colorize_string_bg")
+		opt.Keyword.Fg = pcol("text_colorize_keyword_fg")
+		opt.Keyword.Bg = pcol("text_colorize_keyword_bg")
+		opt.Number.Fg = pcol("text_colorize_number_fg")
+		opt.Number.Bg = pcol("text_colorize_number_bg")
 	}
 }
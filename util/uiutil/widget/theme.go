@@ -19,6 +19,10 @@ var DefaultPalette = Palette{
 	"text_colorize_string_bg":    nil,
 	"text_colorize_comments_fg":  cint(0x757575), // grey 600
 	"text_colorize_comments_bg":  nil,
+	"text_colorize_keyword_fg":   nil,
+	"text_colorize_keyword_bg":   nil,
+	"text_colorize_number_fg":    nil,
+	"text_colorize_number_bg":    nil,
 	"text_highlightword_fg":      nil,
 	"text_highlightword_bg":      cint(0xc6ee9e), // green
 	"text_wrapline_fg":           cint(0x0),